@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// CommentEditWindow adalah batas waktu penulis boleh mengubah komentarnya
+const CommentEditWindow = 15 * time.Minute
+
+// Tabel achievement_comments. Balasan (ParentID terisi) selalu menempel ke
+// komentar akar, sehingga thread hanya satu tingkat.
+type AchievementComment struct {
+	ID               string  `json:"id" db:"id"`
+	AchievementRefID string  `json:"achievementId" db:"achievement_ref_id"`
+	ParentID         *string `json:"parentId" db:"parent_id"`
+
+	AuthorID string `json:"authorId" db:"author_id"`
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Author *User `json:"author,omitempty" db:"-"`
+
+	Body string `json:"body" db:"body"`
+
+	// Jangkar opsional: path field (mis. "details.rank") atau URL lampiran
+	AnchorField         *string `json:"anchorField" db:"anchor_field"`
+	AnchorAttachmentURL *string `json:"anchorAttachmentUrl" db:"anchor_attachment_url"`
+
+	IsResolved bool       `json:"isResolved" db:"is_resolved"`
+	ResolvedBy *string    `json:"resolvedBy" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolvedAt" db:"resolved_at"`
+
+	EditedAt  *time.Time `json:"editedAt" db:"edited_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+
+	// Diisi saat query (tabel achievement_comment_mentions)
+	Mentions []User `json:"mentions" db:"-"`
+	// Balasan untuk komentar akar (diisi saat menyusun thread)
+	Replies []AchievementComment `json:"replies,omitempty" db:"-"`
+}
+
+// Editable: hanya penulis, dan hanya dalam CommentEditWindow sejak dibuat
+func (c *AchievementComment) Editable(userID string, now time.Time) bool {
+	return c.AuthorID == userID && now.Sub(c.CreatedAt) <= CommentEditWindow
+}
+
+// CommentThreads menyusun komentar (urut waktu) menjadi daftar komentar akar
+// beserta balasannya
+func CommentThreads(comments []AchievementComment) []AchievementComment {
+	roots := make([]AchievementComment, 0)
+	index := make(map[string]int)
+	for _, c := range comments {
+		if c.ParentID == nil {
+			index[c.ID] = len(roots)
+			roots = append(roots, c)
+		}
+	}
+	for _, c := range comments {
+		if c.ParentID == nil {
+			continue
+		}
+		if i, ok := index[*c.ParentID]; ok {
+			roots[i].Replies = append(roots[i].Replies, c)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Tabel achievement_status_history (Append-only, satu baris per transisi status)
+type AchievementStatusHistory struct {
+	ID               string `json:"id" db:"id"`
+	AchievementRefID string `json:"achievementId" db:"achievement_ref_id"`
+
+	// NULL untuk event pembuatan (created)
+	FromStatus *string `json:"fromStatus" db:"from_status"`
+	ToStatus   string  `json:"toStatus" db:"to_status"`
+
+	ActorID *string `json:"actorId" db:"actor_id"`
+
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Actor *User `json:"actor,omitempty" db:"-"`
+
+	Note      string    `json:"note" db:"note"`
+	Points    *int      `json:"points" db:"points"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+
+	// Versi konten saat transisi (versi yang diajukan/disetujui/ditolak)
+	ContentVersion *int `json:"contentVersion" db:"content_version"`
+}
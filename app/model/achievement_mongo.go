@@ -10,21 +10,27 @@ import (
 type Achievement struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	StudentID       string             `bson:"studentId" json:"studentId"` // UUID Reference
-	AchievementType string             `bson:"achievementType" json:"achievementType"`
-	Title           string             `bson:"title" json:"title"`
-	Description     string             `bson:"description" json:"description"`
+	AchievementType string             `bson:"achievementType" json:"achievementType" validate:"required,max=50"` // Key di registry achievement_types
+	Title           string             `bson:"title" json:"title" validate:"required,max=200"`
+	Description     string             `bson:"description" json:"description" validate:"max=5000"`
 
 	// Field dinamis
 	Details AchievementDetails `bson:"details" json:"details"`
 
 	Attachments []AchievementAttachment `bson:"attachments" json:"attachments"`
-	Tags        []string                `bson:"tags" json:"tags"`
-	Points      int                     `bson:"points" json:"points"`
+	// Anggota tim (selain ketua) yang sudah konfirmasi; dipakai statistik per mahasiswa
+	TeamMemberIDs []string `bson:"teamMemberIds,omitempty" json:"teamMemberIds,omitempty"`
+	Tags        []string                `bson:"tags" json:"tags" validate:"max=20"`
+	Points      int                     `bson:"points" json:"points" validate:"gte=0"`
 
 	// Soft delete fields
 	IsDeleted bool       `bson:"isDeleted,omitempty" json:"isDeleted,omitempty"`
 	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
 
+	// Flag pencabutan (statistik MongoDB mengecualikan dokumen ini)
+	IsRevoked bool       `bson:"isRevoked,omitempty" json:"isRevoked,omitempty"`
+	RevokedAt *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
 }
@@ -33,9 +39,9 @@ type Achievement struct {
 type AchievementDetails struct {
 	// Competition
 	CompetitionName  string `bson:"competitionName,omitempty" json:"competitionName,omitempty"`
-	CompetitionLevel string `bson:"competitionLevel,omitempty" json:"competitionLevel,omitempty"`
-	Rank             int    `bson:"rank,omitempty" json:"rank,omitempty"`
-	MedalType        string `bson:"medalType,omitempty" json:"medalType,omitempty"`
+	CompetitionLevel string `bson:"competitionLevel,omitempty" json:"competitionLevel,omitempty" validate:"omitempty,oneof=local regional national international"`
+	Rank             int    `bson:"rank,omitempty" json:"rank,omitempty" validate:"gte=0"`
+	MedalType        string `bson:"medalType,omitempty" json:"medalType,omitempty" validate:"omitempty,oneof=gold silver bronze"`
 
 	// Publication
 	PublicationType  string   `bson:"publicationType,omitempty" json:"publicationType,omitempty"`
@@ -43,6 +49,8 @@ type AchievementDetails struct {
 	Authors          []string `bson:"authors,omitempty" json:"authors,omitempty"`
 	Publisher        string   `bson:"publisher,omitempty" json:"publisher,omitempty"`
 	ISSN             string   `bson:"issn,omitempty" json:"issn,omitempty"`
+	DOI              string   `bson:"doi,omitempty" json:"doi,omitempty"`
+	Indexing         string   `bson:"indexing,omitempty" json:"indexing,omitempty"` // scopus, wos, sinta1-6 (dipakai rubrik poin)
 
 	// Organization
 	OrganizationName string         `bson:"organizationName,omitempty" json:"organizationName,omitempty"`
@@ -72,5 +80,6 @@ type AchievementAttachment struct {
 	FileName   string    `bson:"fileName" json:"fileName"`
 	FileURL    string    `bson:"fileUrl" json:"fileUrl"`
 	FileType   string    `bson:"fileType" json:"fileType"`
+	Category   string    `bson:"category,omitempty" json:"category,omitempty"` // Kategori bukti (mis. certificate, photo)
 	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
 }
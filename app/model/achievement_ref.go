@@ -17,7 +17,7 @@ type AchievementReference struct {
 	// Field Title (Tambahan Modul 6 Search/Sort)
 	Title              string     `json:"title" db:"title"`
 
-	// Enum (draft, submitted, verified, rejected)
+	// Enum (draft, submitted, verified, rejected, deleted, revoked) - lihat achievement_status.go
 	Status             string     `json:"status" db:"status"`
 
 	SubmittedAt        *time.Time `json:"submittedAt" db:"submitted_at"`
@@ -29,6 +29,69 @@ type AchievementReference struct {
 	Verifier           *User      `json:"verifier,omitempty" db:"-"`
 
 	RejectionNote      string     `json:"rejectionNote" db:"rejection_note"`
+
+	// Verifikator yang ditahan saat pergantian dosen wali (kebijakan 'keep').
+	// NULL berarti verifikasi mengikuti dosen wali aktif mahasiswa.
+	AssignedVerifierID *string    `json:"assignedVerifierId,omitempty" db:"assigned_verifier_id"`
+
+	// Putaran pengajuan & tahap verifikasi yang sedang berjalan (NULL jika tidak submitted)
+	VerificationRound  int                 `json:"verificationRound" db:"verification_round"`
+	CurrentStage       *VerificationStage  `json:"currentStage,omitempty" db:"-"`
+
+	// Status SLA tahap yang sedang berjalan (nil jika tidak submitted / tanpa SLA)
+	SLA                *SLAStatus          `json:"sla,omitempty" db:"-"`
+
+	// Seluruh tahap pada putaran terakhir (hanya diisi di detail)
+	Stages             []VerificationStage `json:"stages,omitempty" db:"-"`
+
+	// Poin dari rubrik: saran saat disimpan/diajukan, final saat diverifikasi.
+	// Poin yang diberikan tercatat di points_ledger (dicerminkan ke dokumen Mongo).
+	SuggestedPoints    *int       `json:"suggestedPoints" db:"suggested_points"`
+	RubricVersion      *int       `json:"rubricVersion" db:"rubric_version"`
+	// Alasan dosen jika poin yang diberikan berbeda dari hasil rubrik
+	PointsOverrideReason *string  `json:"pointsOverrideReason,omitempty" db:"points_override_reason"`
+
+	// Prestasi tim: StudentID adalah ketua, anggota di Team (hanya diisi di detail)
+	IsTeam             bool         `json:"isTeam" db:"is_team"`
+	// Kebijakan verifikasi tim (leader, per_member), disalin saat submit
+	TeamVerification   *string      `json:"teamVerification,omitempty" db:"team_verification"`
+	Team               []TeamMember `json:"team,omitempty" db:"-"`
+
+	// Dugaan duplikat untuk verifikator (tidak ditampilkan ke mahasiswa).
+	// Daftar lengkap hanya diisi di detail.
+	DuplicateFlagCount int             `json:"duplicateFlagCount,omitempty" db:"-"`
+	DuplicateFlags     []DuplicateFlag `json:"duplicateFlags,omitempty" db:"-"`
+
+	// Masa berlaku sertifikat (salinan details.validUntil) & statusnya saat query
+	ValidUntil         *time.Time           `json:"-" db:"valid_until"`
+	Certification      *CertificationStatus `json:"certification,omitempty" db:"-"`
+
+	// Tempat sampah: waktu dihapus & batas pemulihan (hanya untuk status deleted)
+	DeletedAt          *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	PurgeAt            *time.Time `json:"purgeAt,omitempty" db:"-"`
+
+	// Keterangan pencabutan (hanya jika status revoked)
+	Revocation         *Revocation `json:"revocation,omitempty" db:"-"`
+
+	// Naik setiap kali status/konten berubah (compare-and-set)
+	Version            int        `json:"version" db:"version"`
+	// Versi konten terakhir (lihat achievement_content_versions, 0 = belum ada salinan)
+	ContentVersion     int        `json:"contentVersion" db:"content_version"`
+
 	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt          time.Time  `json:"updatedAt" db:"updated_at"`
-}
\ No newline at end of file
+
+	// Aksi yang boleh dilakukan user yang sedang login (dihitung di service)
+	AvailableActions   []string   `json:"availableActions,omitempty" db:"-"`
+	// Kelengkapan bukti wajib (hanya untuk draft & rejected)
+	Evidence *EvidenceCompleteness `json:"evidence,omitempty" db:"-"`
+}
+
+// Revocation berasal dari kolom revoked_by, revoked_at, revocation_reason.
+// Frontend menampilkannya sebagai banner pada prestasi yang dicabut.
+type Revocation struct {
+	RevokedAt     time.Time `json:"revokedAt"`
+	RevokedBy     *string   `json:"revokedBy"`
+	RevokedByName string    `json:"revokedByName,omitempty"`
+	Reason        string    `json:"reason"`
+}
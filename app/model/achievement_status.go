@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// TrashRetention adalah lama draft yang dihapus bisa dipulihkan sebelum
+// dihapus permanen oleh job purge
+const TrashRetention = 30 * 24 * time.Hour
+
+// Status prestasi (kolom achievement_references.status)
+const (
+	StatusDraft     = "draft"
+	StatusSubmitted = "submitted"
+	StatusVerified  = "verified"
+	StatusRejected  = "rejected"
+	StatusDeleted   = "deleted"
+	// Dicabut Admin setelah diverifikasi (status akhir, poin dibatalkan)
+	StatusRevoked = "revoked"
+)
+
+// Aksi terhadap prestasi
+const (
+	ActionEdit     = "edit"
+	ActionSubmit   = "submit"
+	ActionWithdraw = "withdraw"
+	ActionVerify   = "verify"
+	ActionReject   = "reject"
+	ActionRevise   = "revise"
+	ActionDelete   = "delete"
+	ActionRevoke   = "revoke"
+	ActionRestore  = "restore"
+)
+
+// AchievementAction adalah satu aksi beserta status asal dan tujuannya
+type AchievementAction struct {
+	Name string
+	From []string
+	To   string // kosong = tidak mengubah status (mis. edit)
+}
+
+// AchievementActions adalah satu-satunya sumber state machine prestasi.
+// Service menambahkan guard per aksi (achievement_workflow.go); repository
+// memakai CanTransition untuk menolak transisi ilegal saat UpdateStatus.
+// Urutan menentukan urutan availableActions di response.
+var AchievementActions = []AchievementAction{
+	{Name: ActionEdit, From: []string{StatusDraft, StatusRejected}},
+	{Name: ActionSubmit, From: []string{StatusDraft}, To: StatusSubmitted},
+	{Name: ActionWithdraw, From: []string{StatusSubmitted}, To: StatusDraft},
+	{Name: ActionRevise, From: []string{StatusRejected}, To: StatusDraft},
+	{Name: ActionDelete, From: []string{StatusDraft}, To: StatusDeleted},
+	{Name: ActionVerify, From: []string{StatusSubmitted}, To: StatusVerified},
+	{Name: ActionReject, From: []string{StatusSubmitted}, To: StatusRejected},
+	{Name: ActionRevoke, From: []string{StatusVerified}, To: StatusRevoked},
+	// Dipulihkan dari tempat sampah (selama TrashRetention)
+	{Name: ActionRestore, From: []string{StatusDeleted}, To: StatusDraft},
+}
+
+// AllowedFrom mengecek apakah aksi boleh dijalankan dari status tersebut
+func (a AchievementAction) AllowedFrom(status string) bool {
+	for _, from := range a.From {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition mengecek apakah ada aksi yang memindahkan status from -> to
+func CanTransition(from, to string) bool {
+	for _, a := range AchievementActions {
+		if a.To != "" && a.To == to && a.AllowedFrom(from) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusChange adalah permintaan transisi status dengan compare-and-set:
+// update hanya berhasil jika status (dan version, bila diisi) di database
+// masih sama dengan yang dibaca handler sebelumnya.
+type StatusChange struct {
+	ID              string
+	ExpectedStatus  string
+	ExpectedVersion int // 0 = version tidak dicek
+	Status          string
+	ActorID         string
+	Note            string
+	Points          int
+
+	// Tahap verifikasi yang diputuskan (verify/reject). Kosong untuk transisi lain
+	Stage string
+	// Tahap yang dimulai saat submit (disalin dari konfigurasi pipeline)
+	Pipeline []PipelineStage
+	// Tahap berikutnya jika keputusan hanya menyetujui tahap aktif (batch verify)
+	NextStage string
+
+	// Hasil rubrik saat verify (final) beserta alasan jika Points berbeda
+	Calculation    *PointCalculation
+	OverrideReason string
+
+	// Prestasi tim: kebijakan verifikasi (disalin saat submit) dan award per
+	// anggota saat verified. Awards kosong = satu award untuk pemilik.
+	TeamVerification string
+	Awards           []MemberPoints
+}
+
+// BulkItemResult adalah hasil satu prestasi pada verify/reject massal
+type BulkItemResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+
+	// Kondisi prestasi setelah aksi (hanya jika berhasil)
+	Status       string `json:"status,omitempty"`
+	CurrentStage string `json:"currentStage,omitempty"`
+	Points       *int   `json:"points,omitempty"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Tabel achievement_types: registry tipe prestasi beserta schema `details`.
+// Tipe bawaan (is_system) tidak bisa dihapus, tapi schema-nya tetap bisa diubah Admin.
+type AchievementType struct {
+	Key         string `json:"key" db:"key" validate:"required,max=50"`
+	Name        string `json:"name" db:"name" validate:"required,max=100"`
+	Description string `json:"description" db:"description" validate:"max=1000"`
+
+	// JSON Schema untuk field `details` (disimpan sebagai JSONB)
+	Schema DetailSchema `json:"schema" db:"schema"`
+
+	IsSystem bool `json:"isSystem" db:"is_system"`
+	// Kebijakan verifikasi prestasi tim (leader, per_member); kosong = leader
+	TeamVerification string `json:"teamVerification" db:"team_verification" validate:"omitempty,oneof=leader per_member"`
+	// Tipe nonaktif tidak bisa dipakai untuk prestasi baru
+	IsActive bool `json:"isActive" db:"is_active"`
+	// Bukti yang wajib ada sebelum prestasi diajukan (disimpan sebagai JSONB)
+	EvidenceRequirements []EvidenceRequirement `json:"evidenceRequirements" db:"evidence_requirements"`
+	// Waktu bukti wajib terakhir ditetapkan; prestasi yang dibuat sebelumnya
+	// tidak diwajibkan (lampiran lamanya belum punya kategori)
+	EvidenceRequiredSince *time.Time `json:"evidenceRequiredSince,omitempty" db:"evidence_required_since"`
+
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// EvidenceFor mengembalikan bukti wajib yang berlaku untuk prestasi yang dibuat
+// pada createdAt (kosong untuk prestasi sebelum EvidenceRequiredSince)
+func (t *AchievementType) EvidenceFor(createdAt time.Time) []EvidenceRequirement {
+	if t.EvidenceRequiredSince != nil && createdAt.Before(*t.EvidenceRequiredSince) {
+		return nil
+	}
+	return t.EvidenceRequirements
+}
+
+// DetailSchema adalah subset JSON Schema yang dipakai untuk validasi `details`
+// dan untuk merender form di frontend.
+//
+// Keyword yang didukung: type, title, description, properties, required, enum,
+// format (date, date-time, email, uri), minLength, maxLength, minimum, maximum,
+// items, minItems, maxItems, additionalProperties.
+//
+// Aturan tanggal tambahan:
+//
+//	x-notFuture: tanggal tidak boleh melewati waktu sekarang
+//	x-after:     tanggal harus setelah property lain dalam object yang sama
+type DetailSchema struct {
+	Type        string `json:"type,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Properties           map[string]*DetailSchema `json:"properties,omitempty"`
+	Required             []string                 `json:"required,omitempty"`
+	AdditionalProperties *bool                    `json:"additionalProperties,omitempty"`
+
+	Enum      []interface{} `json:"enum,omitempty"`
+	Format    string        `json:"format,omitempty"`
+	MinLength *int          `json:"minLength,omitempty"`
+	MaxLength *int          `json:"maxLength,omitempty"`
+	Minimum   *float64      `json:"minimum,omitempty"`
+	Maximum   *float64      `json:"maximum,omitempty"`
+
+	Items    *DetailSchema `json:"items,omitempty"`
+	MinItems *int          `json:"minItems,omitempty"`
+	MaxItems *int          `json:"maxItems,omitempty"`
+
+	NotFuture bool   `json:"x-notFuture,omitempty"`
+	After     string `json:"x-after,omitempty"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// Jenis perubahan yang menghasilkan versi konten baru
+const (
+	ContentCreated    = "created"
+	ContentEdited     = "edited"
+	ContentAttachment = "attachment"
+	// Isi dokumen lama (sebelum ada versioning) yang disalin saat pertama kali berubah
+	ContentImported = "imported"
+)
+
+// ContentDiffIgnoredFields adalah field dokumen yang dikelola sistem dan tidak
+// ditampilkan di diff antar versi (selalu berubah atau bukan isian mahasiswa)
+var ContentDiffIgnoredFields = []string{"id", "studentId", "points", "teamMemberIds", "isDeleted", "deletedAt", "isRevoked", "revokedAt", "createdAt", "updatedAt"}
+
+// Tabel achievement_content_versions (salinan dokumen MongoDB, immutable).
+// Hanya pemilik yang bisa mengubah konten, jadi setiap versi dibuat olehnya.
+type AchievementContentVersion struct {
+	ID               string `json:"id" db:"id"`
+	AchievementRefID string `json:"achievementId" db:"achievement_ref_id"`
+	Version          int    `json:"version" db:"version"`
+	// Enum (created, edited, attachment, imported)
+	Change    string    `json:"change" db:"change"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+
+	// Isi dokumen (hanya di detail versi, tidak di daftar)
+	Content *Achievement `json:"content,omitempty" db:"content"`
+
+	// Status/keputusan yang tercatat terhadap versi ini (submitted, verified, rejected, ...)
+	Decisions []AchievementStatusHistory `json:"decisions,omitempty" db:"-"`
+}
+
+// ContentChange adalah satu perbedaan field antara dua versi konten.
+// Path memakai notasi titik (mis. details.rank); array dibandingkan utuh.
+type ContentChange struct {
+	Path string `json:"path"`
+	// Enum (added, removed, changed)
+	Op   string      `json:"op"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ContentDiff adalah hasil perbandingan dua versi konten
+type ContentDiff struct {
+	AchievementRefID string          `json:"achievementId"`
+	From             int             `json:"from"`
+	To               int             `json:"to"`
+	Changes          []ContentChange `json:"changes"`
+}
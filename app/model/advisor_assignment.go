@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Tabel advisor_assignments (Riwayat Dosen Wali)
+type AdvisorAssignment struct {
+	ID        string `json:"id" db:"id"`
+	StudentID string `json:"studentId" db:"student_id"`
+
+	// Foreign Key merujuk ke lecturers.id (UUID)
+	LecturerID string `json:"lecturerId" db:"lecturer_id"`
+
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Lecturer *Lecturer `json:"lecturer,omitempty" db:"-"`
+
+	// Periode berlaku. EffectiveTo NULL berarti penugasan masih aktif
+	EffectiveFrom time.Time  `json:"effectiveFrom" db:"effective_from"`
+	EffectiveTo   *time.Time `json:"effectiveTo" db:"effective_to"`
+
+	AssignedBy *string `json:"assignedBy" db:"assigned_by"`
+
+	// Enum (transfer, keep) - kebijakan untuk prestasi berstatus submitted saat pergantian
+	HandoverPolicy string    `json:"handoverPolicy" db:"handover_policy"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Kebijakan serah terima prestasi 'submitted' saat dosen wali diganti
+const (
+	HandoverTransfer = "transfer" // Pindahkan ke dosen wali baru
+	HandoverKeep     = "keep"     // Tetap diverifikasi dosen wali lama sampai diputuskan
+)
+
+// Hasil proses pergantian dosen wali (dipakai untuk notifikasi & response)
+type AdvisorReassignment struct {
+	StudentID         string  `json:"studentId"`
+	PreviousAdvisorID *string `json:"previousAdvisorId"`
+	NewAdvisorID      string  `json:"newAdvisorId"`
+	HandoverPolicy    string  `json:"handoverPolicy"`
+	PendingAffected   int64   `json:"pendingAffected"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// Status masa berlaku sertifikat (dihitung dari details.validUntil saat query)
+const (
+	CertificationValid        = "valid"
+	CertificationExpiringSoon = "expiring_soon"
+	CertificationExpired      = "expired"
+)
+
+// CertificationExpiryWindow: sertifikat berstatus expiring_soon (dan mahasiswa
+// diingatkan untuk memperbarui) sejak 30 hari sebelum habis masa berlakunya
+const CertificationExpiryWindow = 30 * 24 * time.Hour
+
+// CertificationStatus adalah posisi sertifikat terhadap masa berlakunya
+type CertificationStatus struct {
+	// Enum (valid, expiring_soon, expired)
+	State      string     `json:"state"`
+	Expired    bool       `json:"expired"`
+	ValidUntil time.Time  `json:"validUntil"`
+	RemindedAt *time.Time `json:"remindedAt"`
+}
+
+// EvaluateCertification menghitung status sertifikat pada waktu now
+// (nil jika prestasi tidak punya masa berlaku)
+func EvaluateCertification(validUntil *time.Time, remindedAt *time.Time, now time.Time) *CertificationStatus {
+	if validUntil == nil {
+		return nil
+	}
+	status := &CertificationStatus{
+		State:      CertificationValid,
+		ValidUntil: *validUntil,
+		RemindedAt: remindedAt,
+	}
+	switch {
+	case !now.Before(*validUntil):
+		status.State, status.Expired = CertificationExpired, true
+	case validUntil.Sub(now) <= CertificationExpiryWindow:
+		status.State = CertificationExpiringSoon
+	}
+	return status
+}
+
+// CertificationExpiry adalah sertifikat terverifikasi yang akan habis masa
+// berlakunya (diambil oleh job pengingat)
+type CertificationExpiry struct {
+	AchievementRefID string
+	Title            string
+	Student          string // "Nama (NIM)"
+	ValidUntil       time.Time
+}
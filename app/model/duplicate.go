@@ -0,0 +1,170 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Alasan dugaan duplikat (achievement_duplicate_flags.reason)
+const (
+	DuplicateCertificationNumber = "certification_number"
+	DuplicateSimilarDetails      = "similar_details"
+	DuplicateAttachmentHash      = "attachment_hash"
+)
+
+// DuplicateThreshold adalah skor minimal kemiripan details untuk ditandai
+const DuplicateThreshold = 0.8
+
+// Bobot kemiripan details; tanggal wajib sama, organizer opsional
+const (
+	weightName      = 0.5
+	weightEventDate = 0.3
+	weightOrganizer = 0.2
+)
+
+// Tabel achievement_fingerprints: ringkasan details yang sudah dinormalisasi
+type Fingerprint struct {
+	AchievementRefID    string     `json:"achievementId" db:"achievement_ref_id"`
+	AchievementType     string     `json:"achievementType" db:"achievement_type"`
+	Name                string     `json:"name" db:"name"`
+	Organizer           string     `json:"organizer" db:"organizer"`
+	CertificationNumber string     `json:"certificationNumber" db:"certification_number"`
+	EventDate           *time.Time `json:"eventDate" db:"event_date"`
+}
+
+// NewFingerprint mengambil field pembeda dari isi prestasi. Nama diambil dari
+// field spesifik tipe (kompetisi, sertifikasi, publikasi, organisasi), organizer
+// dari penyelenggara/penerbit.
+func NewFingerprint(refID string, content *Achievement) Fingerprint {
+	d := content.Details
+	fp := Fingerprint{
+		AchievementRefID:    refID,
+		AchievementType:     content.AchievementType,
+		Name:                NormalizeText(firstNonEmpty(d.CompetitionName, d.CertificationName, d.PublicationTitle, d.OrganizationName)),
+		Organizer:           NormalizeText(firstNonEmpty(d.Organizer, d.IssuedBy, d.Publisher)),
+		CertificationNumber: normalizeCode(d.CertificationNumber),
+	}
+	if d.EventDate != nil {
+		date := time.Date(d.EventDate.Year(), d.EventDate.Month(), d.EventDate.Day(), 0, 0, 0, 0, time.UTC)
+		fp.EventDate = &date
+	}
+	return fp
+}
+
+// Compare menilai kemiripan dengan prestasi lain. Nomor sertifikat yang sama
+// langsung bernilai 1; selain itu nama & tanggal wajib ada di kedua sisi.
+func (f Fingerprint) Compare(other Fingerprint) (float64, string) {
+	if f.CertificationNumber != "" && f.CertificationNumber == other.CertificationNumber {
+		return 1, DuplicateCertificationNumber
+	}
+	if f.Name == "" || other.Name == "" || f.EventDate == nil || other.EventDate == nil {
+		return 0, ""
+	}
+	if !f.EventDate.Equal(*other.EventDate) {
+		return 0, ""
+	}
+
+	score, weight := weightName*TextSimilarity(f.Name, other.Name)+weightEventDate, weightName+weightEventDate
+	if f.Organizer != "" && other.Organizer != "" {
+		score += weightOrganizer * TextSimilarity(f.Organizer, other.Organizer)
+		weight += weightOrganizer
+	}
+	return score / weight, DuplicateSimilarDetails
+}
+
+// NormalizeText: huruf kecil, tanda baca menjadi spasi, spasi berlebih dibuang
+func NormalizeText(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// TextSimilarity adalah koefisien Dice atas kata (0..1)
+func TextSimilarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(wordsA))
+	for _, w := range wordsA {
+		counts[w]++
+	}
+	shared := 0
+	for _, w := range wordsB {
+		if counts[w] > 0 {
+			counts[w]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(wordsA)+len(wordsB))
+}
+
+// normalizeCode menyamakan penulisan nomor sertifikat (mis. "ABC-123 / 2024")
+func normalizeCode(s string) string {
+	return strings.ReplaceAll(NormalizeText(s), " ", "")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Tabel achievement_duplicate_flags. Dibaca dari sisi prestasi mana pun:
+// Matched selalu berisi prestasi pasangannya.
+type DuplicateFlag struct {
+	ID               string  `json:"id" db:"id"`
+	AchievementRefID string  `json:"-" db:"achievement_ref_id"`
+	MatchedRefID     string  `json:"matchedId" db:"matched_ref_id"`
+	Reason           string  `json:"reason" db:"reason"`
+	Score            float64 `json:"score" db:"score"`
+	Detail           string  `json:"detail" db:"detail"`
+	// Relasi (diisi lewat JOIN manual)
+	Matched   *DuplicateMatch `json:"matched,omitempty" db:"-"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// DuplicateMatch meringkas prestasi pasangan beserta link detailnya
+type DuplicateMatch struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	StudentID   string `json:"studentId"`
+	StudentName string `json:"studentName"`
+	Link        string `json:"link"`
+}
+
+// DuplicateReport adalah laporan pola mencurigakan untuk Admin
+type DuplicateReport struct {
+	FlagsByReason        map[string]int        `json:"flagsByReason"`
+	SharedAttachments    []SharedAttachment    `json:"sharedAttachments"`
+	ReusedCertifications []ReusedCertification `json:"reusedCertifications"`
+	MostFlaggedStudents  []FlaggedStudent      `json:"mostFlaggedStudents"`
+}
+
+// SharedAttachment: file identik (hash sama) yang dipakai lebih dari satu mahasiswa
+type SharedAttachment struct {
+	SHA256         string   `json:"sha256"`
+	FileNames      []string `json:"fileNames"`
+	StudentCount   int      `json:"studentCount"`
+	AchievementIDs []string `json:"achievementIds"`
+}
+
+// ReusedCertification: nomor sertifikat yang muncul di lebih dari satu prestasi
+type ReusedCertification struct {
+	CertificationNumber string   `json:"certificationNumber"`
+	StudentCount        int      `json:"studentCount"`
+	AchievementIDs      []string `json:"achievementIds"`
+}
+
+// FlaggedStudent: mahasiswa dengan prestasi yang paling sering ditandai
+type FlaggedStudent struct {
+	StudentID string `json:"studentId"`
+	NIM       string `json:"nim"`
+	FullName  string `json:"fullName"`
+	FlagCount int    `json:"flagCount"`
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Jenis bukti wajib
+const (
+	EvidenceAttachment = "attachment" // Lampiran (per kategori dan/atau jenis file)
+	EvidenceField      = "field"      // Field details yang harus terisi, mis. DOI publikasi
+)
+
+// EvidenceRequirement adalah satu bukti wajib yang dideklarasikan tipe prestasi
+// (kolom achievement_types.evidence_requirements). Prestasi baru bisa diajukan
+// setelah semua bukti terpenuhi.
+type EvidenceRequirement struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+
+	// attachment: kategori lampiran (field 'category' saat upload, kosong = semua
+	// lampiran), jenis file yang diterima ("image/*" untuk semua gambar), dan
+	// jumlah minimal (default 1)
+	Category  string   `json:"category,omitempty"`
+	FileTypes []string `json:"fileTypes,omitempty"`
+	MinCount  int      `json:"minCount,omitempty"`
+
+	// field: path di details (mis. "doi" atau "customFields.sponsor") dan
+	// regex opsional yang harus cocok dengan nilainya
+	Field   string `json:"field,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// EvidenceItem adalah status satu bukti wajib di checklist
+type EvidenceItem struct {
+	Key       string `json:"key"`
+	Label     string `json:"label"`
+	Satisfied bool   `json:"satisfied"`
+	// Penjelasan yang masih kurang (kosong jika terpenuhi)
+	Missing string `json:"missing,omitempty"`
+}
+
+// EvidenceCompleteness adalah indikator kelengkapan bukti sebuah prestasi
+type EvidenceCompleteness struct {
+	Complete  bool           `json:"complete"`
+	Satisfied int            `json:"satisfied"`
+	Required  int            `json:"required"`
+	Percent   int            `json:"percent"`
+	Items     []EvidenceItem `json:"items"`
+}
+
+// EvaluateEvidence mencocokkan lampiran dan details prestasi dengan bukti wajib
+// tipe prestasinya. Tipe tanpa bukti wajib selalu lengkap.
+func EvaluateEvidence(requirements []EvidenceRequirement, content *Achievement) *EvidenceCompleteness {
+	result := &EvidenceCompleteness{Required: len(requirements), Items: []EvidenceItem{}}
+
+	var details map[string]interface{}
+	raw, _ := json.Marshal(content.Details)
+	_ = json.Unmarshal(raw, &details)
+
+	for _, req := range requirements {
+		item := EvidenceItem{Key: req.Key, Label: req.Label}
+		switch req.Type {
+		case EvidenceAttachment:
+			item.Missing = missingAttachments(req, content.Attachments)
+		case EvidenceField:
+			item.Missing = missingField(req, details)
+		default:
+			item.Missing = "unknown evidence type " + req.Type
+		}
+		item.Satisfied = item.Missing == ""
+		if item.Satisfied {
+			result.Satisfied++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	result.Complete = result.Satisfied == result.Required
+	result.Percent = 100
+	if result.Required > 0 {
+		result.Percent = result.Satisfied * 100 / result.Required
+	}
+	return result
+}
+
+func missingAttachments(req EvidenceRequirement, attachments []AchievementAttachment) string {
+	need := req.MinCount
+	if need < 1 {
+		need = 1
+	}
+
+	have := 0
+	for _, a := range attachments {
+		if req.Category != "" && a.Category != req.Category {
+			continue
+		}
+		if len(req.FileTypes) > 0 && !FileTypeAccepted(a.FileType, req.FileTypes) {
+			continue
+		}
+		have++
+	}
+	if have >= need {
+		return ""
+	}
+
+	msg := fmt.Sprintf("Upload %d more file(s) for %s", need-have, req.Label)
+	if req.Category != "" {
+		msg += fmt.Sprintf(" with category '%s'", req.Category)
+	}
+	if len(req.FileTypes) > 0 {
+		msg += " (" + strings.Join(req.FileTypes, ", ") + ")"
+	}
+	return msg
+}
+
+func missingField(req EvidenceRequirement, details map[string]interface{}) string {
+	var value interface{} = details
+	for _, key := range strings.Split(req.Field, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = obj[key]
+	}
+
+	path := "details." + req.Field
+	if value == nil || value == "" {
+		return "Fill in " + path + " (" + req.Label + ")"
+	}
+	if req.Pattern != "" {
+		str, ok := value.(string)
+		re, err := regexp.Compile(req.Pattern)
+		if !ok || err != nil || !re.MatchString(str) {
+			return path + " has an invalid format for " + req.Label
+		}
+	}
+	return ""
+}
+
+// FileTypeAccepted mengecek MIME type lampiran terhadap daftar jenis file
+// yang diterima ("image/*" cocok dengan semua gambar)
+func FileTypeAccepted(fileType string, accepted []string) bool {
+	fileType = strings.ToLower(fileType)
+	for _, t := range accepted {
+		t = strings.ToLower(t)
+		if t == fileType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(fileType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// IdempotencyKeyHeader adalah header yang dikirim klien untuk request yang aman diulang
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKeyTTL: setelah 24 jam key boleh dipakai lagi untuk request baru
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// Tabel idempotency_keys (PK user_id + key)
+type IdempotencyRecord struct {
+	UserID      string `db:"user_id"`
+	Key         string `db:"key"`
+	Method      string `db:"method"`
+	Path        string `db:"path"`
+	RequestHash string `db:"request_hash"`
+
+	// Snapshot response pertama (StatusCode nil = masih diproses)
+	StatusCode   *int   `db:"status_code"`
+	ContentType  string `db:"content_type"`
+	ResponseBody []byte `db:"response_body"`
+
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+// Matches mengecek apakah request ulang identik dengan request pertama
+func (r *IdempotencyRecord) Matches(method, path, requestHash string) bool {
+	return r.Method == method && r.Path == path && r.RequestHash == requestHash
+}
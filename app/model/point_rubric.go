@@ -0,0 +1,98 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Tabel point_rubrics. Versi rubrik tidak pernah diubah setelah dibuat;
+// perubahan aturan = versi baru, sehingga poin lama tetap bisa direproduksi.
+type PointRubric struct {
+	ID          string    `json:"id" db:"id"`
+	Version     int       `json:"version" db:"version"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	IsActive    bool      `json:"isActive" db:"is_active"`
+	CreatedBy   *string   `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+
+	// Diisi saat mengambil detail rubrik
+	Rules []PointRule `json:"rules,omitempty" db:"-"`
+}
+
+// Tabel point_rubric_rules. Kriteria nil berarti cocok untuk semua nilai.
+type PointRule struct {
+	ID               string  `json:"id" db:"id"`
+	AchievementType  string  `json:"achievementType" db:"achievement_type" validate:"required,max=50"`
+	CompetitionLevel *string `json:"competitionLevel" db:"competition_level" validate:"omitempty,oneof=local regional national international"`
+	Rank             *int    `json:"rank" db:"rank" validate:"omitempty,gt=0"`
+	MedalType        *string `json:"medalType" db:"medal_type" validate:"omitempty,oneof=gold silver bronze"`
+	PublicationType  *string `json:"publicationType" db:"publication_type" validate:"omitempty,max=20"`
+	Indexing         *string `json:"indexing" db:"indexing" validate:"omitempty,max=20"`
+	Position         *string `json:"position" db:"position" validate:"omitempty,max=100"`
+	Points           int     `json:"points" db:"points" validate:"gte=0"`
+	Description      string  `json:"description" db:"description" validate:"max=500"`
+	// Poin prestasi tim: duplicate (poin penuh per anggota) atau split (dibagi rata)
+	TeamPoints string `json:"teamPoints" db:"team_points" validate:"omitempty,oneof=duplicate split"`
+}
+
+// Specificity adalah jumlah kriteria yang terisi; aturan paling spesifik menang
+func (r PointRule) Specificity() int {
+	n := 0
+	for _, set := range []bool{r.CompetitionLevel != nil, r.Rank != nil, r.MedalType != nil, r.PublicationType != nil, r.Indexing != nil, r.Position != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// PointCalculation adalah hasil rubrik untuk satu prestasi
+type PointCalculation struct {
+	RubricVersion int        `json:"rubricVersion"`
+	Points        int        `json:"points"`
+	Rule          *PointRule `json:"rule"` // nil jika tidak ada aturan yang cocok
+}
+
+// Calculate mencari aturan yang cocok dengan prestasi. Aturan cocok jika tipe
+// sama dan setiap kriteria yang terisi sama dengan details; dari yang cocok
+// dipakai yang paling spesifik (jika seri, poin tertinggi).
+func (rb *PointRubric) Calculate(content *Achievement) PointCalculation {
+	calc := PointCalculation{RubricVersion: rb.Version}
+
+	for i := range rb.Rules {
+		rule := &rb.Rules[i]
+		if !rule.Matches(content) {
+			continue
+		}
+		if calc.Rule == nil ||
+			rule.Specificity() > calc.Rule.Specificity() ||
+			(rule.Specificity() == calc.Rule.Specificity() && rule.Points > calc.Rule.Points) {
+			calc.Rule = rule
+			calc.Points = rule.Points
+		}
+	}
+	return calc
+}
+
+// Matches memeriksa semua kriteria aturan terhadap tipe & details prestasi
+func (r PointRule) Matches(content *Achievement) bool {
+	d := content.Details
+	if r.AchievementType != content.AchievementType {
+		return false
+	}
+	if r.Rank != nil && *r.Rank != d.Rank {
+		return false
+	}
+	return criterionMatches(r.CompetitionLevel, d.CompetitionLevel) &&
+		criterionMatches(r.MedalType, d.MedalType) &&
+		criterionMatches(r.PublicationType, d.PublicationType) &&
+		criterionMatches(r.Indexing, d.Indexing) &&
+		criterionMatches(r.Position, d.Position)
+}
+
+// criterionMatches: kriteria nil cocok untuk semua nilai; perbandingan teks
+// tidak membedakan huruf besar/kecil (mis. jabatan "Ketua" vs "ketua")
+func criterionMatches(criterion *string, value string) bool {
+	return criterion == nil || strings.EqualFold(strings.TrimSpace(*criterion), strings.TrimSpace(value))
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Jenis entri buku besar poin
+const (
+	LedgerAward      = "award"
+	LedgerAdjustment = "adjustment"
+	LedgerReversal   = "reversal"
+	LedgerExpiration = "expiration"
+)
+
+// Tabel points_ledger (append-only). Saldo = SUM(points).
+type PointsLedgerEntry struct {
+	ID               string `json:"id" db:"id"`
+	StudentID        string `json:"studentId" db:"student_id"`
+	AchievementRefID string `json:"achievementId" db:"achievement_ref_id"`
+
+	// Enum (award, adjustment, reversal, expiration)
+	EntryType string `json:"entryType" db:"entry_type"`
+	// Bertanda: negatif untuk pengurangan
+	Points int    `json:"points" db:"points"`
+	Reason string `json:"reason" db:"reason"`
+
+	ActorID *string `json:"actorId" db:"actor_id"`
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Actor *User `json:"actor,omitempty" db:"-"`
+
+	// Entri yang dibatalkan (hanya untuk reversal)
+	ReversesEntryID *string `json:"reversesEntryId,omitempty" db:"reverses_entry_id"`
+	// Sudah dibatalkan oleh entri lain (diisi saat query)
+	ReversedBy *string `json:"reversedBy,omitempty" db:"-"`
+
+	RubricVersion *int      `json:"rubricVersion,omitempty" db:"rubric_version"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// PointsBalance adalah total poin satu mahasiswa (untuk leaderboard)
+type PointsBalance struct {
+	StudentID    string `json:"studentId"`
+	NIM          string `json:"nim"`
+	FullName     string `json:"fullName"`
+	ProgramStudy string `json:"programStudy"`
+	TotalPoints  int    `json:"totalPoints"`
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Tabel tags: kosakata tag prestasi yang dikelola Admin. Tag boleh punya
+// induk (ParentKey) sehingga membentuk hierarki, mis. 'ai' di bawah 'technology'.
+type Tag struct {
+	Key         string  `json:"key" db:"key"`
+	Name        string  `json:"name" db:"name"`
+	ParentKey   *string `json:"parentKey" db:"parent_key"`
+	Description string  `json:"description" db:"description"`
+	// Ejaan lain yang dinormalisasi ke tag ini (tabel tag_synonyms)
+	Synonyms []string `json:"synonyms"`
+	// Tag nonaktif tidak muncul di autocomplete
+	IsActive bool `json:"isActive" db:"is_active"`
+
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// TagSuggestion adalah satu hasil autocomplete tag
+type TagSuggestion struct {
+	Key       string  `json:"key"`
+	Name      string  `json:"name"`
+	ParentKey *string `json:"parentKey"`
+	// Sinonim yang cocok dengan input (kosong jika yang cocok key/nama)
+	MatchedSynonym string `json:"matchedSynonym,omitempty"`
+	// Jumlah prestasi yang memakai tag ini
+	Usage int `json:"usage"`
+}
+
+// Panjang maksimal satu tag (sama dengan kolom tags.key)
+const MaxTagLength = 50
+
+// NormalizeTag mengubah tag bebas dari mahasiswa ke bentuk key: huruf kecil,
+// spasi/underscore menjadi '-', karakter selain [a-z0-9+#.-] dibuang.
+// Contoh: "  Machine Learning " -> "machine-learning", "C#" -> "c#".
+func NormalizeTag(tag string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(tag)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '+', r == '#', r == '.':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case r == '-', r == '_', r == ' ', r == '\t', r == '/':
+			dash = true
+		}
+	}
+	return strings.TrimLeft(b.String(), "+#.-")
+}
+
+// NormalizeTags menormalisasi setiap tag, membuang yang kosong dan duplikat
+// (urutan kemunculan pertama dipertahankan)
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := []string{}
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// Peran & status anggota tim (tabel achievement_team_members)
+const (
+	TeamRoleLeader = "leader"
+	TeamRoleMember = "member"
+
+	MemberPending   = "pending"
+	MemberConfirmed = "confirmed"
+	MemberDeclined  = "declined"
+)
+
+// Kebijakan verifikasi tim (achievement_types.team_verification)
+const (
+	// Dosen wali ketua memverifikasi atas nama semua anggota
+	TeamVerificationLeader = "leader"
+	// Tahap dosen wali selesai setelah dosen wali setiap anggota menyetujui
+	TeamVerificationPerMember = "per_member"
+)
+
+// Pembagian poin tim (point_rubric_rules.team_points)
+const (
+	TeamPointsDuplicate = "duplicate"
+	TeamPointsSplit     = "split"
+)
+
+// Tabel achievement_team_members. Ketua adalah pemilik prestasi
+// (achievement_references.student_id) dan selalu berstatus confirmed.
+type TeamMember struct {
+	AchievementRefID string `json:"-" db:"achievement_ref_id"`
+	StudentID        string `json:"studentId" db:"student_id"`
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Student *Student `json:"student,omitempty" db:"-"`
+
+	// Enum (leader, member)
+	Role string `json:"role" db:"role"`
+	// Enum (pending, confirmed, declined)
+	Status      string     `json:"status" db:"status"`
+	RespondedAt *time.Time `json:"respondedAt" db:"responded_at"`
+
+	AdvisorApprovedBy *string    `json:"advisorApprovedBy,omitempty" db:"advisor_approved_by"`
+	AdvisorApprovedAt *time.Time `json:"advisorApprovedAt,omitempty" db:"advisor_approved_at"`
+}
+
+// MemberPoints adalah poin award satu anggota tim
+type MemberPoints struct {
+	StudentID string `json:"studentId"`
+	Points    int    `json:"points"`
+}
+
+// TeamAwards membagi poin final ke anggota yang sudah konfirmasi. Mode split
+// membagi rata dan memberikan sisa pembagian ke anggota paling awal (ketua dulu).
+func TeamAwards(team []TeamMember, total int, mode string) []MemberPoints {
+	var members []TeamMember
+	for _, m := range team {
+		if m.Role == TeamRoleLeader {
+			members = append([]TeamMember{m}, members...)
+		} else if m.Status == MemberConfirmed {
+			members = append(members, m)
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	awards := make([]MemberPoints, len(members))
+	share, rest := total, 0
+	if mode == TeamPointsSplit {
+		share, rest = total/len(members), total%len(members)
+	}
+	for i, m := range members {
+		awards[i] = MemberPoints{StudentID: m.StudentID, Points: share}
+		if i < rest {
+			awards[i].Points++
+		}
+	}
+	return awards
+}
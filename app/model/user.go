@@ -16,6 +16,7 @@ type User struct {
 	Role         *Role     `json:"role,omitempty" db:"-"`
 	
 	IsActive     bool      `json:"isActive" db:"is_active"`
+	Version      int       `json:"version" db:"version"` // Naik setiap update (ETag)
 	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
 }
\ No newline at end of file
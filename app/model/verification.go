@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// Tahap verifikasi bawaan
+const (
+	StageAdvisor        = "advisor"
+	StageStudentAffairs = "student_affairs"
+)
+
+// Status keputusan per tahap
+const (
+	StagePending   = "pending"
+	StageApproved  = "approved"
+	StageRejected  = "rejected"
+	StageCancelled = "cancelled" // pengajuan ditarik / dibuka untuk revisi
+)
+
+// Tabel verification_pipeline_stages (konfigurasi alur per tipe & tingkat)
+type PipelineStage struct {
+	ID string `json:"id" db:"id"`
+
+	// '*' berlaku untuk semua tipe prestasi
+	AchievementType string `json:"achievementType" db:"achievement_type" validate:"required,max=50"`
+	// NULL berlaku untuk semua tingkat kompetisi
+	CompetitionLevel *string `json:"competitionLevel" db:"competition_level" validate:"omitempty,oneof=local regional national international"`
+
+	Order      int    `json:"order" db:"stage_order" validate:"gt=0"`
+	Key        string `json:"key" db:"stage_key" validate:"required,max=50"`
+	Name       string `json:"name" db:"name" validate:"required,max=100"`
+	Permission string `json:"permission" db:"permission" validate:"required,max=100"`
+}
+
+// DefaultPipeline dipakai jika tidak ada konfigurasi yang cocok: cukup dosen wali
+func DefaultPipeline() []PipelineStage {
+	return []PipelineStage{{AchievementType: "*", Order: 1, Key: StageAdvisor, Name: "Dosen Wali", Permission: "achievement:verify"}}
+}
+
+// Tabel achievement_verification_stages (tahap yang dijalani satu pengajuan)
+type VerificationStage struct {
+	ID               string `json:"id" db:"id"`
+	AchievementRefID string `json:"achievementId" db:"achievement_ref_id"`
+
+	// Putaran pengajuan (naik setiap kali submit ulang)
+	Round      int    `json:"round" db:"round"`
+	Order      int    `json:"order" db:"stage_order"`
+	Key        string `json:"key" db:"stage_key"`
+	Name       string `json:"name" db:"name"`
+	Permission string `json:"permission" db:"permission"`
+
+	// Enum (pending, approved, rejected, cancelled)
+	Status string `json:"status" db:"status"`
+
+	// Waktu tahap mulai menunggu keputusan (NULL jika belum sampai tahap ini)
+	StartedAt *time.Time `json:"startedAt" db:"started_at"`
+	DecidedBy *string    `json:"decidedBy" db:"decided_by"`
+
+	// Relasi (Tidak ada di kolom database, diisi lewat JOIN manual)
+	Decider *User `json:"decider,omitempty" db:"-"`
+
+	DecidedAt *time.Time `json:"decidedAt" db:"decided_at"`
+	Note      string     `json:"note" db:"note"`
+	Points    *int       `json:"points" db:"points"`
+	// Versi konten yang disetujui/ditolak pada tahap ini
+	ContentVersion *int `json:"contentVersion" db:"content_version"`
+
+	// Jejak SLA: pengingat terkirim & eskalasi (lihat verification_sla.go).
+	// Setelah dieskalasi, pemegang EscalatedPermission atau user EscalatedTo
+	// juga boleh memutuskan tahap ini.
+	RemindedAt          *time.Time `json:"remindedAt,omitempty" db:"reminded_at"`
+	EscalatedAt         *time.Time `json:"escalatedAt,omitempty" db:"escalated_at"`
+	EscalatedPermission *string    `json:"escalatedPermission,omitempty" db:"escalated_permission"`
+	EscalatedTo         *string    `json:"escalatedTo,omitempty" db:"escalated_to"`
+}
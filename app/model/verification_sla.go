@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// Status SLA tahap verifikasi yang sedang berjalan
+const (
+	SLAOnTime  = "on_time"
+	SLADueSoon = "due_soon"
+	SLAOverdue = "overdue"
+)
+
+// SLADueSoonWindow: tahap berstatus due_soon sejak 24 jam sebelum jatuh tempo
+const SLADueSoonWindow = 24 * time.Hour
+
+// Tabel verification_slas (SLA per stage_key)
+type VerificationSLA struct {
+	StageKey string `json:"stageKey" db:"stage_key"`
+
+	// Tahap jatuh tempo due_days setelah mulai menunggu (pengingat dikirim)
+	DueDays int `json:"dueDays" db:"due_days" validate:"gt=0,lte=365"`
+	// Eskalasi dilakukan escalate_after_days setelah jatuh tempo
+	EscalateAfterDays int `json:"escalateAfterDays" db:"escalate_after_days" validate:"gt=0,lte=365"`
+
+	// Target eskalasi: pemegang permission ini dan/atau user delegasi
+	EscalationPermission *string `json:"escalationPermission" db:"escalation_permission" validate:"omitempty,max=100"`
+	DelegateUserID       *string `json:"delegateUserId" db:"delegate_user_id" validate:"omitempty,uuid"`
+
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// SLAStatus adalah posisi satu tahap terhadap SLA-nya (dihitung saat query)
+type SLAStatus struct {
+	// Enum (on_time, due_soon, overdue)
+	State       string     `json:"state"`
+	DueAt       time.Time  `json:"dueAt"`
+	EscalateAt  time.Time  `json:"escalateAt"`
+	RemindedAt  *time.Time `json:"remindedAt"`
+	EscalatedAt *time.Time `json:"escalatedAt"`
+}
+
+// DueAt & EscalateAt dihitung dari waktu tahap mulai menunggu
+func (s VerificationSLA) DueAt(startedAt time.Time) time.Time {
+	return startedAt.AddDate(0, 0, s.DueDays)
+}
+
+func (s VerificationSLA) EscalateAt(startedAt time.Time) time.Time {
+	return startedAt.AddDate(0, 0, s.DueDays+s.EscalateAfterDays)
+}
+
+// Evaluate menghitung status SLA tahap pada waktu now
+func (s VerificationSLA) Evaluate(stage *VerificationStage, now time.Time) *SLAStatus {
+	if stage == nil || stage.StartedAt == nil {
+		return nil
+	}
+	status := &SLAStatus{
+		State:       SLAOnTime,
+		DueAt:       s.DueAt(*stage.StartedAt),
+		EscalateAt:  s.EscalateAt(*stage.StartedAt),
+		RemindedAt:  stage.RemindedAt,
+		EscalatedAt: stage.EscalatedAt,
+	}
+	switch {
+	case !now.Before(status.DueAt):
+		status.State = SLAOverdue
+	case status.DueAt.Sub(now) <= SLADueSoonWindow:
+		status.State = SLADueSoon
+	}
+	return status
+}
+
+// SLABreach adalah tahap yang sudah lewat jatuh tempo dan belum dieskalasi
+// (diambil oleh job SLA)
+type SLABreach struct {
+	Stage   VerificationStage
+	SLA     VerificationSLA
+	Title   string
+	Student string // "Nama (NIM)"
+	// Dosen yang bertanggung jawab pada tahap advisor (kosong untuk tahap lain)
+	AdvisorName string
+}
@@ -7,6 +7,14 @@ type WebResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Meta    *MetaInfo   `json:"meta,omitempty"` // Pointer agar bisa nil jika tidak ada pagination
+	Errors  []FieldError `json:"errors,omitempty"` // Detail error per field (422 Validation)
+}
+
+// Error validasi per field, dipakai di response 422
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 type MetaInfo struct {
@@ -26,4 +34,8 @@ type PaginationParam struct {
 	SortBy string
 	Order  string
 	Search string
+	// Filter masa berlaku sertifikat (valid, expiring_soon, expired); kosong = semua
+	Certification string
+	// Filter tag (beserta turunan & sinonimnya); kosong = semua
+	Tag string
 }
\ No newline at end of file
@@ -9,9 +9,11 @@ import (
 	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
 
+	"github.com/lib/pq"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AchievementRepository struct {
@@ -44,14 +46,34 @@ func (r *AchievementRepository) Create(ctx context.Context, content *model.Achie
 	oid, _ := res.InsertedID.(primitive.ObjectID)
 	ref.MongoAchievementID = oid.Hex()
 
-	// 2. Insert ke PostgreSQL
+	// 2. Insert ke PostgreSQL (reference + riwayat 'created' dalam satu transaksi)
+	err = r.insertReference(ctx, ref, content)
+
+	if err != nil {
+		// KOMPENSASI (ROLLBACK MANUAL):
+		// Jika simpan ke Postgres gagal, hapus data sampah di Mongo
+		_, _ = r.mongoColl.DeleteOne(ctx, bson.M{"_id": oid})
+		return errors.New("failed to save reference to postgres: " + err.Error())
+	}
+
+	return nil
+}
+
+func (r *AchievementRepository) insertReference(ctx context.Context, ref *model.AchievementReference, content *model.Achievement) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO achievement_references (
-			student_id, mongo_achievement_id, title, status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			student_id, mongo_achievement_id, title, status, created_at, updated_at,
+			suggested_points, rubric_version, valid_until, tags
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id`
 
-	err = r.pgDB.QueryRow(
+	err = tx.QueryRowContext(ctx,
 		query,
 		ref.StudentID,
 		ref.MongoAchievementID,
@@ -59,16 +81,32 @@ func (r *AchievementRepository) Create(ctx context.Context, content *model.Achie
 		ref.Status,
 		ref.CreatedAt,
 		ref.UpdatedAt,
+		ref.SuggestedPoints,
+		ref.RubricVersion,
+		ref.ValidUntil,
+		pq.Array(model.NormalizeTags(content.Tags)),
 	).Scan(&ref.ID)
+	if err != nil {
+		return err
+	}
 
+	// Versi konten pertama
+	ref.ContentVersion, err = saveContentVersion(ctx, tx, ref.ID, nil, content, model.ContentCreated, ref.CreatedAt)
 	if err != nil {
-		// KOMPENSASI (ROLLBACK MANUAL):
-		// Jika simpan ke Postgres gagal, hapus data sampah di Mongo
-		_, _ = r.mongoColl.DeleteOne(ctx, bson.M{"_id": oid})
-		return errors.New("failed to save reference to postgres: " + err.Error())
+		return err
 	}
 
-	return nil
+	// Pembuat prestasi selalu mahasiswa pemiliknya
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO achievement_status_history (achievement_ref_id, from_status, to_status, actor_id, note, points, created_at, content_version)
+		SELECT $1, NULL, $2, s.user_id, '', NULL, $3, $5 FROM students s WHERE s.id = $4`,
+		ref.ID, ref.Status, ref.CreatedAt, ref.StudentID, ref.ContentVersion,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // --- ADD ATTACHMENT ---
@@ -86,54 +124,167 @@ func (r *AchievementRepository) AddAttachment(ctx context.Context, refID string,
 		return errors.New("invalid mongo id format")
 	}
 
-	// 3. Add attachment to MongoDB document
-	_, err = r.mongoColl.UpdateOne(
+	// 3. Add attachment to MongoDB document (ambil isi sebelumnya untuk versi konten)
+	var previous model.Achievement
+	err = r.mongoColl.FindOneAndUpdate(
 		ctx,
 		bson.M{"_id": objID},
 		bson.M{"$push": bson.M{"attachments": attachment}},
-	)
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err != nil {
+		return err
+	}
+	updated := previous
+	updated.Attachments = append(append([]model.AchievementAttachment{}, previous.Attachments...), attachment)
 
-	return err
+	// 4. Naikkan version agar ETag detail ikut berubah, lalu simpan versi konten
+	if err := r.addAttachmentTx(ctx, refID, &previous, &updated, attachment.UploadedAt); err != nil {
+		// KOMPENSASI (ROLLBACK MANUAL): lepas lagi lampiran dari dokumen Mongo
+		_, _ = r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID},
+			bson.M{"$pull": bson.M{"attachments": bson.M{"fileUrl": attachment.FileURL}}})
+		return errors.New("failed to record attachment in postgres: " + err.Error())
+	}
+	return nil
 }
 
-// --- FIND ALL (PAGINATION, SORT, SEARCH) ---
-func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID string, advisorID string) ([]model.AchievementReference, int64, error) {
-	var achievements []model.AchievementReference
-	var total int64
+// addAttachmentTx menjalankan bagian PostgreSQL dari AddAttachment: naikkan
+// version reference lalu simpan salinan konten dengan lampiran baru, dalam satu transaksi
+func (r *AchievementRepository) addAttachmentTx(ctx context.Context, refID string, previous, updated *model.Achievement, now time.Time) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	// Base Query
-	baseQuery := `
-		SELECT 
-			ar.id, ar.student_id, ar.mongo_achievement_id, ar.title, ar.status, 
-			ar.submitted_at, ar.verified_at, ar.verified_by, ar.rejection_note, ar.created_at, ar.updated_at,
-			u.full_name, s.student_id
-		FROM achievement_references ar
-		JOIN students s ON ar.student_id = s.id
-		JOIN users u ON s.user_id = u.id`
+	_, err = tx.ExecContext(ctx,
+		"UPDATE achievement_references SET version = version + 1, updated_at = $2 WHERE id = $1",
+		refID, now,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := saveContentVersion(ctx, tx, refID, previous, updated, model.ContentAttachment, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
+// --- FIND ALL (PAGINATION, SORT, SEARCH) ---
+func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID string, advisorID string) ([]model.AchievementReference, int64, error) {
 	var conditions []string
 	var args []interface{}
 	argId := 1
 
 	// Filter by Student (RBAC)
+	// Termasuk prestasi tim tempat mahasiswa menjadi anggota (kecuali yang ditolak)
 	if studentID != "" {
-		conditions = append(conditions, fmt.Sprintf("ar.student_id = $%d", argId))
+		conditions = append(conditions, fmt.Sprintf(`(ar.student_id = $%d OR EXISTS (
+			SELECT 1 FROM achievement_team_members tm
+			WHERE tm.achievement_ref_id = ar.id AND tm.student_id = $%d AND tm.status != 'declined'))`, argId, argId))
 		args = append(args, studentID)
 		argId++
 	}
 
 	// Filter by Advisor (RBAC)
+	// Termasuk prestasi yang ditahan untuk dosen ini setelah pergantian dosen wali
+	// dan prestasi tim yang anggotanya (sudah konfirmasi) mahasiswa bimbingan dosen ini.
+	// Pengajuan yang ditahan untuk dosen wali lama (kebijakan keep) tidak tampil
+	// di dosen wali baru karena ia tidak bisa memutuskannya.
 	if advisorID != "" {
-		conditions = append(conditions, fmt.Sprintf("s.advisor_id = $%d", argId))
+		conditions = append(conditions, fmt.Sprintf(`((s.advisor_id = $%d AND NOT (ar.status = 'submitted' AND ar.assigned_verifier_id IS NOT NULL AND ar.assigned_verifier_id != $%d))
+			OR ar.assigned_verifier_id = $%d OR EXISTS (
+			SELECT 1 FROM achievement_team_members tm JOIN students ts ON ts.id = tm.student_id
+			WHERE tm.achievement_ref_id = ar.id AND tm.status = 'confirmed' AND ts.advisor_id = $%d))`, argId, argId, argId, argId))
 		args = append(args, advisorID)
 		argId++
 	}
 
+	return r.queryReferences(param, conditions, args, argId)
+}
+
+// FindStageQueue mengambil prestasi yang sedang menunggu keputusan pada tahap
+// yang permission-nya dimiliki user. Tahap 'advisor' hanya untuk dosen yang
+// bertanggung jawab (lecturerID); kosongkan lecturerID untuk non-dosen.
+// Tahap yang dieskalasi juga masuk antrian pemegang escalated_permission dan
+// user delegasi (userID). Prestasi tim dengan kebijakan per_member masuk antrian
+// dosen wali setiap anggota yang belum disetujui.
+func (r *AchievementRepository) FindStageQueue(param model.PaginationParam, permissions []string, lecturerID string, userID string) ([]model.AchievementReference, int64, error) {
+	conditions := []string{
+		"ar.status = 'submitted'",
+		`((cs.permission = ANY($1) AND (cs.stage_key != 'advisor' OR COALESCE(ar.assigned_verifier_id, s.advisor_id)::text = $2
+				OR (ar.team_verification = 'per_member' AND EXISTS (
+					SELECT 1 FROM achievement_team_members tm JOIN students ts ON ts.id = tm.student_id
+					WHERE tm.achievement_ref_id = ar.id AND tm.status = 'confirmed'
+					  AND tm.advisor_approved_at IS NULL AND ts.advisor_id::text = $2))))
+			OR (cs.escalated_at IS NOT NULL AND (cs.escalated_permission = ANY($1) OR cs.escalated_to::text = $3)))`,
+	}
+	args := []interface{}{pq.Array(permissions), lecturerID, userID}
+
+	return r.queryReferences(param, conditions, args, 4)
+}
+
+// queryReferences menjalankan list reference dengan pagination, search & sort.
+// conditions/args adalah filter tambahan; argId adalah nomor placeholder berikutnya.
+func (r *AchievementRepository) queryReferences(param model.PaginationParam, conditions []string, args []interface{}, argId int) ([]model.AchievementReference, int64, error) {
+	var achievements []model.AchievementReference
+	var total int64
+
+	// Base Query
+	// cs = tahap verifikasi yang sedang berjalan (NULL jika tidak submitted)
+	fromClause := `
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		LEFT JOIN achievement_verification_stages cs
+			ON cs.achievement_ref_id = ar.id AND cs.round = ar.verification_round AND cs.stage_key = ar.current_stage
+		LEFT JOIN verification_slas sla ON sla.stage_key = cs.stage_key`
+	baseQuery := `
+		SELECT 
+			ar.id, ar.student_id, ar.mongo_achievement_id, ar.title, ar.status, 
+			ar.submitted_at, ar.verified_at, ar.verified_by, ar.rejection_note, ar.created_at, ar.updated_at,
+			u.full_name, s.student_id, s.advisor_id, ar.assigned_verifier_id, ar.version,
+			ar.verification_round, cs.stage_key, cs.name, cs.permission, cs.stage_order, cs.started_at,
+			cs.reminded_at, cs.escalated_at, cs.escalated_permission, cs.escalated_to,
+			sla.due_days, sla.escalate_after_days,
+			ar.suggested_points, ar.rubric_version, ar.points_override_reason,
+			ar.revoked_at, ar.revoked_by, ar.revocation_reason,
+			ar.is_team, ar.team_verification,
+			(SELECT COUNT(*) FROM achievement_duplicate_flags df
+				WHERE df.achievement_ref_id = ar.id OR df.matched_ref_id = ar.id),
+			ar.valid_until, ar.expiry_reminded_at` + fromClause
+
 	// Exclude soft deleted records
 	conditions = append(conditions, fmt.Sprintf("ar.status != $%d", argId))
 	args = append(args, "deleted")
 	argId++
 
+	// Filter masa berlaku sertifikat (prestasi tanpa validUntil tidak ikut)
+	now := time.Now()
+	switch param.Certification {
+	case model.CertificationExpired:
+		conditions = append(conditions, fmt.Sprintf("ar.valid_until <= $%d", argId))
+	case model.CertificationExpiringSoon:
+		conditions = append(conditions, fmt.Sprintf("ar.valid_until > $%d AND ar.valid_until <= $%d", argId, argId+1))
+	case model.CertificationValid:
+		conditions = append(conditions, fmt.Sprintf("ar.valid_until > $%d", argId))
+	}
+	if param.Certification != "" {
+		args = append(args, now)
+		argId++
+		if param.Certification == model.CertificationExpiringSoon {
+			args = append(args, now.Add(model.CertificationExpiryWindow))
+			argId++
+		}
+	}
+
+	// Filter tag: tag itu, turunannya di hierarki, dan sinonimnya
+	if tag := model.NormalizeTag(param.Tag); tag != "" {
+		conditions = append(conditions, "ar.tags && "+fmt.Sprintf(tagSubtreeSQL, argId))
+		args = append(args, tag)
+		argId++
+	}
+
 	// Search Logic (Title OR Status)
 	if param.Search != "" {
 		searchLike := "%" + strings.ToLower(param.Search) + "%"
@@ -148,11 +299,7 @@ func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID s
 	}
 
 	// 1. Count Total (Untuk Pagination)
-	countQuery := `
-		SELECT COUNT(*) 
-		FROM achievement_references ar 
-		JOIN students s ON ar.student_id = s.id 
-	` + whereClause
+	countQuery := `SELECT COUNT(*) ` + fromClause + whereClause
 
 	if err := r.pgDB.QueryRow(countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
@@ -199,11 +346,25 @@ func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID s
 		var subAt, verAt sql.NullTime
 		var verBy sql.NullString
 		var rejNote sql.NullString
+		var advisorID, assignedVerifier sql.NullString
+		var stage nullableStage
+		var points nullablePoints
+		var revocation nullableRevocation
+		var teamVerification sql.NullString
+		var validUntil, expiryReminded sql.NullTime
 
 		err := rows.Scan(
 			&ar.ID, &ar.StudentID, &ar.MongoAchievementID, &ar.Title, &ar.Status,
 			&subAt, &verAt, &verBy, &rejNote, &ar.CreatedAt, &ar.UpdatedAt,
-			&ar.Student.User.FullName, &ar.Student.StudentID,
+			&ar.Student.User.FullName, &ar.Student.StudentID, &advisorID, &assignedVerifier, &ar.Version,
+			&ar.VerificationRound, &stage.Key, &stage.Name, &stage.Permission, &stage.Order, &stage.StartedAt,
+			&stage.RemindedAt, &stage.EscalatedAt, &stage.EscalatedPermission, &stage.EscalatedTo,
+			&stage.SLADueDays, &stage.SLAEscalateAfterDays,
+			&points.Suggested, &points.RubricVersion, &points.OverrideReason,
+			&revocation.At, &revocation.By, &revocation.Reason,
+			&ar.IsTeam, &teamVerification,
+			&ar.DuplicateFlagCount,
+			&validUntil, &expiryReminded,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -219,7 +380,21 @@ func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID s
 			str := verBy.String
 			ar.VerifiedBy = &str
 		}
+		if advisorID.Valid {
+			str := advisorID.String
+			ar.Student.AdvisorID = &str
+		}
+		if assignedVerifier.Valid {
+			str := assignedVerifier.String
+			ar.AssignedVerifierID = &str
+		}
 		ar.RejectionNote = rejNote.String
+		ar.CurrentStage = stage.toStage(ar.ID, ar.VerificationRound)
+		ar.SLA = stage.slaStatus(ar.CurrentStage, now)
+		points.apply(&ar)
+		ar.Revocation = revocation.toRevocation()
+		ar.TeamVerification = nullStringPtr(teamVerification)
+		ar.Certification = certificationStatus(&ar, validUntil, expiryReminded, now)
 
 		achievements = append(achievements, ar)
 	}
@@ -227,6 +402,103 @@ func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID s
 	return achievements, total, nil
 }
 
+// certificationStatus mengisi ValidUntil lalu menghitung status sertifikat
+func certificationStatus(ref *model.AchievementReference, validUntil, remindedAt sql.NullTime, now time.Time) *model.CertificationStatus {
+	if !validUntil.Valid {
+		return nil
+	}
+	ref.ValidUntil = &validUntil.Time
+	var reminded *time.Time
+	if remindedAt.Valid {
+		reminded = &remindedAt.Time
+	}
+	return model.EvaluateCertification(ref.ValidUntil, reminded, now)
+}
+
+// nullableStage menampung kolom tahap aktif (dan SLA-nya) dari LEFT JOIN
+type nullableStage struct {
+	Key, Name, Permission              sql.NullString
+	Order                              sql.NullInt64
+	StartedAt, RemindedAt, EscalatedAt sql.NullTime
+	EscalatedPermission, EscalatedTo   sql.NullString
+	SLADueDays, SLAEscalateAfterDays   sql.NullInt64
+}
+
+func (n nullableStage) toStage(refID string, round int) *model.VerificationStage {
+	if !n.Key.Valid {
+		return nil
+	}
+	stage := &model.VerificationStage{
+		AchievementRefID: refID,
+		Round:            round,
+		Order:            int(n.Order.Int64),
+		Key:              n.Key.String,
+		Name:             n.Name.String,
+		Permission:       n.Permission.String,
+		Status:           model.StagePending,
+	}
+	if n.StartedAt.Valid {
+		stage.StartedAt = &n.StartedAt.Time
+	}
+	if n.RemindedAt.Valid {
+		stage.RemindedAt = &n.RemindedAt.Time
+	}
+	if n.EscalatedAt.Valid {
+		stage.EscalatedAt = &n.EscalatedAt.Time
+	}
+	stage.EscalatedPermission = nullStringPtr(n.EscalatedPermission)
+	stage.EscalatedTo = nullStringPtr(n.EscalatedTo)
+	return stage
+}
+
+// slaStatus menghitung status SLA tahap aktif (nil jika tahap tidak punya SLA)
+func (n nullableStage) slaStatus(stage *model.VerificationStage, now time.Time) *model.SLAStatus {
+	if stage == nil || !n.SLADueDays.Valid {
+		return nil
+	}
+	sla := model.VerificationSLA{StageKey: stage.Key, DueDays: int(n.SLADueDays.Int64), EscalateAfterDays: int(n.SLAEscalateAfterDays.Int64)}
+	return sla.Evaluate(stage, now)
+}
+
+// nullablePoints menampung kolom poin rubrik yang bisa NULL
+type nullablePoints struct {
+	Suggested, RubricVersion sql.NullInt64
+	OverrideReason           sql.NullString
+}
+
+func (n nullablePoints) apply(ref *model.AchievementReference) {
+	if n.Suggested.Valid {
+		p := int(n.Suggested.Int64)
+		ref.SuggestedPoints = &p
+	}
+	if n.RubricVersion.Valid {
+		v := int(n.RubricVersion.Int64)
+		ref.RubricVersion = &v
+	}
+	if n.OverrideReason.Valid {
+		str := n.OverrideReason.String
+		ref.PointsOverrideReason = &str
+	}
+}
+
+// nullableRevocation menampung kolom pencabutan yang bisa NULL
+type nullableRevocation struct {
+	At                 sql.NullTime
+	By, ByName, Reason sql.NullString
+}
+
+func (n nullableRevocation) toRevocation() *model.Revocation {
+	if !n.At.Valid {
+		return nil
+	}
+	return &model.Revocation{
+		RevokedAt:     n.At.Time,
+		RevokedBy:     nullStringPtr(n.By),
+		RevokedByName: n.ByName.String,
+		Reason:        n.Reason.String,
+	}
+}
+
 // --- FIND DETAIL (HYBRID FETCH) ---
 func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*model.AchievementReference, *model.Achievement, error) {
 	// 1. Ambil data Metadata dari Postgres
@@ -234,12 +506,24 @@ func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*mod
 		SELECT 
 			ar.id, ar.student_id, ar.mongo_achievement_id, ar.title, ar.status, 
 			ar.submitted_at, ar.verified_at, ar.verified_by, ar.rejection_note, ar.created_at, ar.updated_at,
+			ar.assigned_verifier_id, ar.version, ar.verification_round,
 			s.student_id, s.advisor_id, u.full_name,
-			ver_u.full_name
+			ver_u.full_name,
+			cs.stage_key, cs.name, cs.permission, cs.stage_order, cs.started_at,
+			cs.reminded_at, cs.escalated_at, cs.escalated_permission, cs.escalated_to,
+			sla.due_days, sla.escalate_after_days,
+			ar.suggested_points, ar.rubric_version, ar.points_override_reason,
+			ar.revoked_at, ar.revoked_by, rev_u.full_name, ar.revocation_reason,
+			ar.is_team, ar.team_verification,
+			ar.valid_until, ar.expiry_reminded_at, ar.content_version
 		FROM achievement_references ar
 		JOIN students s ON ar.student_id = s.id
 		JOIN users u ON s.user_id = u.id
 		LEFT JOIN users ver_u ON ar.verified_by = ver_u.id
+		LEFT JOIN users rev_u ON ar.revoked_by = rev_u.id
+		LEFT JOIN achievement_verification_stages cs
+			ON cs.achievement_ref_id = ar.id AND cs.round = ar.verification_round AND cs.stage_key = ar.current_stage
+		LEFT JOIN verification_slas sla ON sla.stage_key = cs.stage_key
 		WHERE ar.id = $1 AND ar.status != 'deleted'`
 
 	var ref model.AchievementReference
@@ -251,12 +535,26 @@ func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*mod
 	var rejNote sql.NullString
 	var verifierName sql.NullString
 	var advisorID sql.NullString
+	var assignedVerifier sql.NullString
+	var stage nullableStage
+	var points nullablePoints
+	var revocation nullableRevocation
+	var teamVerification sql.NullString
+	var validUntil, expiryReminded sql.NullTime
 
 	err := r.pgDB.QueryRow(query, id).Scan(
 		&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Title, &ref.Status,
 		&subAt, &verAt, &verBy, &rejNote, &ref.CreatedAt, &ref.UpdatedAt,
+		&assignedVerifier, &ref.Version, &ref.VerificationRound,
 		&ref.Student.StudentID, &advisorID, &ref.Student.User.FullName,
 		&verifierName,
+		&stage.Key, &stage.Name, &stage.Permission, &stage.Order, &stage.StartedAt,
+		&stage.RemindedAt, &stage.EscalatedAt, &stage.EscalatedPermission, &stage.EscalatedTo,
+		&stage.SLADueDays, &stage.SLAEscalateAfterDays,
+		&points.Suggested, &points.RubricVersion, &points.OverrideReason,
+		&revocation.At, &revocation.By, &revocation.ByName, &revocation.Reason,
+		&ref.IsTeam, &teamVerification,
+		&validUntil, &expiryReminded, &ref.ContentVersion,
 	)
 
 	if err != nil {
@@ -281,7 +579,40 @@ func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*mod
 		str := advisorID.String
 		ref.Student.AdvisorID = &str
 	}
+	if assignedVerifier.Valid {
+		str := assignedVerifier.String
+		ref.AssignedVerifierID = &str
+	}
 	ref.RejectionNote = rejNote.String
+	ref.CurrentStage = stage.toStage(ref.ID, ref.VerificationRound)
+	ref.SLA = stage.slaStatus(ref.CurrentStage, time.Now())
+	points.apply(&ref)
+	ref.Revocation = revocation.toRevocation()
+	ref.TeamVerification = nullStringPtr(teamVerification)
+	ref.Certification = certificationStatus(&ref, validUntil, expiryReminded, time.Now())
+
+	// Anggota tim
+	if ref.IsTeam {
+		ref.Team, err = r.FindTeam(ctx, ref.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Dugaan duplikat (disembunyikan dari mahasiswa di service)
+	ref.DuplicateFlags, err = r.FindDuplicateFlags(ctx, ref.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ref.DuplicateFlagCount = len(ref.DuplicateFlags)
+
+	// Tahap verifikasi putaran terakhir
+	if ref.VerificationRound > 0 {
+		ref.Stages, err = r.FindStages(ctx, ref.ID, ref.VerificationRound)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// 2. Ambil data Detail dari MongoDB
 	var content model.Achievement
@@ -298,54 +629,161 @@ func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*mod
 	return &ref, &content, nil
 }
 
-// --- UPDATE STATUS (VERIFIKASI DOSEN & UPDATE POIN) ---
-func (r *AchievementRepository) UpdateStatus(id string, status string, verifiedBy string, note string, points int) error {
-	// 1. Update di PostgreSQL (Status, VerifiedBy, RejectionNote)
-	query := `
-		UPDATE achievement_references 
-		SET status = $1, updated_at = $2, verified_by = $3, verified_at = $4, rejection_note = $5
-		WHERE id = $6
-		RETURNING mongo_achievement_id`
+// FindContents mengambil banyak dokumen MongoDB sekaligus (mis. untuk satu
+// halaman list), dipetakan per mongo_achievement_id. ID yang tidak valid atau
+// tidak ditemukan dilewati.
+func (r *AchievementRepository) FindContents(ctx context.Context, mongoIDs []string) (map[string]*model.Achievement, error) {
+	contents := make(map[string]*model.Achievement, len(mongoIDs))
+	objIDs := make([]primitive.ObjectID, 0, len(mongoIDs))
+	for _, id := range mongoIDs {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return contents, nil
+	}
+
+	cursor, err := r.mongoColl.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc model.Achievement
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		contents[doc.ID.Hex()] = &doc
+	}
+	return contents, cursor.Err()
+}
+
+func nullStringPtr(n sql.NullString) *string {
+	if !n.Valid {
+		return nil
+	}
+	str := n.String
+	return &str
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// ErrAchievementNotEditable dikembalikan jika status berubah (bukan lagi draft/rejected) saat update
+var ErrAchievementNotEditable = errors.New("achievement is no longer editable")
+
+// --- UPDATE CONTENT (EDIT DRAFT) ---
+// Update dokumen MongoDB lalu title di PostgreSQL. Jika update Postgres gagal,
+// dokumen Mongo dikembalikan ke isi sebelumnya (kompensasi manual seperti Create).
+func (r *AchievementRepository) UpdateContent(ctx context.Context, ref *model.AchievementReference, previous *model.Achievement, content *model.Achievement) error {
+	objID, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+	if err != nil {
+		return errors.New("invalid mongo id format")
+	}
 
 	now := time.Now()
+	content.UpdatedAt = now
 
-	var verBy interface{} = nil
-	if verifiedBy != "" {
-		verBy = verifiedBy
+	editable := func(a *model.Achievement) bson.M {
+		return bson.M{
+			"achievementType": a.AchievementType,
+			"title":           a.Title,
+			"description":     a.Description,
+			"details":         a.Details,
+			"tags":            a.Tags,
+			"points":          a.Points,
+			"updatedAt":       a.UpdatedAt,
+		}
 	}
 
-	var mongoID string
-	err := r.pgDB.QueryRow(query, status, now, verBy, now, note, id).Scan(&mongoID)
+	// 1. Update MongoDB
+	_, err = r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": editable(content)})
 	if err != nil {
-		return err
+		return errors.New("failed to update achievement content: " + err.Error())
 	}
 
-	// 2. Update di MongoDB (Hanya jika Verified, kita simpan Poinnya)
-	if status == "verified" && mongoID != "" {
-		objID, err := primitive.ObjectIDFromHex(mongoID)
-		if err == nil {
-			// Update field "points" di dokumen MongoDB
-			_, err = r.mongoColl.UpdateOne(
-				context.Background(),
-				bson.M{"_id": objID},
-				bson.M{"$set": bson.M{"points": points}},
-			)
-			if err != nil {
-				return errors.New("failed to update points in mongo: " + err.Error())
-			}
+	// 2. Update PostgreSQL + simpan versi konten
+	contentVersion, err := r.updateContentTx(ctx, ref, previous, content, now)
+	if err != nil {
+		// KOMPENSASI (ROLLBACK MANUAL): kembalikan isi dokumen Mongo
+		_, _ = r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": editable(previous)})
+		if err == ErrAchievementNotEditable || err == ErrVersionMismatch {
+			return err
 		}
+		return errors.New("failed to update reference in postgres: " + err.Error())
 	}
 
+	ref.Version++
+	ref.ContentVersion = contentVersion
 	return nil
 }
 
+// updateContentTx menjalankan bagian PostgreSQL dari UpdateContent: update
+// reference (hanya jika status masih bisa diedit dan version masih sama dengan
+// yang dibaca) lalu salinan konten baru, dalam satu transaksi. Pengingat
+// kedaluwarsa dikirim ulang jika masa berlaku sertifikat berubah.
+func (r *AchievementRepository) updateContentTx(ctx context.Context, ref *model.AchievementReference, previous, content *model.Achievement, now time.Time) (int, error) {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE achievement_references SET title = $1, updated_at = $2, version = version + 1,
+			suggested_points = $4, rubric_version = $5,
+			expiry_reminded_at = CASE WHEN valid_until IS DISTINCT FROM $6 THEN NULL ELSE expiry_reminded_at END,
+			valid_until = $6, tags = $8
+		WHERE id = $3 AND status IN ('draft', 'rejected') AND ($7::int = 0 OR version = $7)`,
+		content.Title, now, ref.ID, ref.SuggestedPoints, ref.RubricVersion, content.Details.ValidUntil, ref.Version,
+		pq.Array(model.NormalizeTags(content.Tags)),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return 0, r.editConflict(ctx, ref)
+	}
+
+	version, err := saveContentVersion(ctx, tx, ref.ID, previous, content, model.ContentEdited, now)
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// editConflict menjelaskan kenapa UpdateContent tidak mengenai baris: version
+// sudah dinaikkan request lain (ErrVersionMismatch) atau status tidak lagi
+// bisa diedit (ErrAchievementNotEditable)
+func (r *AchievementRepository) editConflict(ctx context.Context, ref *model.AchievementReference) error {
+	var version int
+	err := r.pgDB.QueryRowContext(ctx, "SELECT version FROM achievement_references WHERE id = $1", ref.ID).Scan(&version)
+	if err == nil && ref.Version != 0 && version != ref.Version {
+		return ErrVersionMismatch
+	}
+	return ErrAchievementNotEditable
+}
+
 // --- SOFT DELETE ---
-func (r *AchievementRepository) Delete(ctx context.Context, id string) error {
+func (r *AchievementRepository) Delete(ctx context.Context, id string, actorID string) error {
 	var mongoID string
 	var status string
 
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// 1. Get achievement info
-	err := r.pgDB.QueryRow("SELECT mongo_achievement_id, status FROM achievement_references WHERE id = $1", id).Scan(&mongoID, &status)
+	err = tx.QueryRowContext(ctx, "SELECT mongo_achievement_id, status FROM achievement_references WHERE id = $1 FOR UPDATE", id).Scan(&mongoID, &status)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return errors.New("achievement not found")
@@ -354,19 +792,25 @@ func (r *AchievementRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	// 2. Check precondition: only draft can be deleted
-	if status != "draft" {
-		return errors.New("cannot delete submitted or verified achievement")
+	if !model.CanTransition(status, model.StatusDeleted) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, status, model.StatusDeleted)
 	}
 
-	// 3. Soft delete in PostgreSQL (update status to 'deleted')
+	// 3. Soft delete in PostgreSQL (update status to 'deleted') + riwayat
 	now := time.Now()
-	_, err = r.pgDB.Exec(
-		"UPDATE achievement_references SET status = $1, updated_at = $2 WHERE id = $3",
+	_, err = tx.ExecContext(ctx,
+		"UPDATE achievement_references SET status = $1, updated_at = $2, deleted_at = $2, version = version + 1 WHERE id = $3",
 		"deleted", now, id,
 	)
 	if err != nil {
 		return errors.New("failed to soft delete reference: " + err.Error())
 	}
+	if err := insertStatusHistory(ctx, tx, id, status, "deleted", actorID, "", 0, now); err != nil {
+		return errors.New("failed to record history: " + err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 
 	// 4. Soft delete in MongoDB (add deleted flag and timestamp)
 	if mongoID != "" {
@@ -393,345 +837,3 @@ func (r *AchievementRepository) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
-
-// --- STATISTICS (FR-011) ---
-
-type StatsResult struct {
-	TotalPerType   map[string]int `json:"totalPerType"`
-	TotalPerLevel  map[string]int `json:"totalPerLevel"`
-	TotalPerPeriod map[string]int `json:"totalPerPeriod"`
-	TopStudents    []TopStudent   `json:"topStudents"`
-	Summary        StatsSummary   `json:"summary"`
-}
-
-type StatsSummary struct {
-	TotalAchievements int `json:"totalAchievements"`
-	TotalVerified     int `json:"totalVerified"`
-	TotalPending      int `json:"totalPending"`
-	TotalRejected     int `json:"totalRejected"`
-	TotalPoints       int `json:"totalPoints"`
-}
-
-type TopStudent struct {
-	Name        string `json:"name"`
-	Program     string `json:"programStudy"`
-	TotalPoints int    `json:"totalPoints"`
-}
-
-// GetStatistics generates overall stats
-func (r *AchievementRepository) GetStatistics(ctx context.Context) (*StatsResult, error) {
-	result := &StatsResult{
-		TotalPerType:   make(map[string]int),
-		TotalPerLevel:  make(map[string]int),
-		TotalPerPeriod: make(map[string]int),
-		TopStudents:    []TopStudent{},
-		Summary:        StatsSummary{},
-	}
-
-	// 1. Total Per Type (Aggregation MongoDB)
-	pipeline := mongo.Pipeline{
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$achievementType"},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-	}
-	cursor, err := r.mongoColl.Aggregate(ctx, pipeline)
-	if err == nil {
-		var typeStats []struct {
-			ID    string `bson:"_id"`
-			Count int    `bson:"count"`
-		}
-		if err = cursor.All(ctx, &typeStats); err == nil {
-			for _, s := range typeStats {
-				result.TotalPerType[s.ID] = s.Count
-			}
-		}
-	}
-
-	// 2. Total Per Level (Aggregation MongoDB)
-	pipelineLevel := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{{Key: "achievementType", Value: "competition"}}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$details.competitionLevel"},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-	}
-	cursorLevel, err := r.mongoColl.Aggregate(ctx, pipelineLevel)
-	if err == nil {
-		var levelStats []struct {
-			ID    string `bson:"_id"`
-			Count int    `bson:"count"`
-		}
-		if err = cursorLevel.All(ctx, &levelStats); err == nil {
-			for _, s := range levelStats {
-				key := s.ID
-				if key == "" {
-					key = "unknown"
-				}
-				result.TotalPerLevel[key] = s.Count
-			}
-		}
-	}
-
-	// 3. Top Students (Points from Mongo, Name from Postgres)
-	// We aggregate studentID and their total points from Mongo first
-	pipelineTop := mongo.Pipeline{
-		// Only fetch achievements that have points (assuming > 0)
-		{{Key: "$match", Value: bson.D{{Key: "points", Value: bson.D{{Key: "$gt", Value: 0}}}}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$studentId"}, // Group by StudentID (UUID String)
-			{Key: "totalPoints", Value: bson.D{{Key: "$sum", Value: "$points"}}},
-		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "totalPoints", Value: -1}}}}, // Sort Descending
-		{{Key: "$limit", Value: 5}},                                      // Top 5
-	}
-
-	cursorTop, err := r.mongoColl.Aggregate(ctx, pipelineTop)
-	if err == nil {
-		var topList []struct {
-			StudentID   string `bson:"_id"`
-			TotalPoints int    `bson:"totalPoints"`
-		}
-
-		if err = cursorTop.All(ctx, &topList); err == nil {
-			// Loop aggregation results and get student name from Postgres
-			for _, t := range topList {
-				var name, program string
-
-				// Query to students & users table
-				// Ensure studentID is valid in Postgres
-				queryUser := `
-					SELECT u.full_name, s.program_study 
-					FROM students s 
-					JOIN users u ON s.user_id = u.id 
-					WHERE s.id = $1`
-
-				err := r.pgDB.QueryRow(queryUser, t.StudentID).Scan(&name, &program)
-				if err != nil {
-					// If user not found in Postgres (e.g. zombie data in Mongo), skip or set "Unknown"
-					// fmt.Printf("Warning: Student ID %s not found in Postgres\n", t.StudentID)
-					continue
-				}
-
-				result.TopStudents = append(result.TopStudents, TopStudent{
-					Name:        name,
-					Program:     program,
-					TotalPoints: t.TotalPoints,
-				})
-			}
-		}
-	}
-
-	// 4. Total Per Period (Last 6 months)
-	pipelinePeriod := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{
-			{Key: "createdAt", Value: bson.D{
-				{Key: "$gte", Value: time.Now().AddDate(0, -6, 0)}, // Last 6 months
-			}},
-		}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "year", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
-				{Key: "month", Value: bson.D{{Key: "$month", Value: "$createdAt"}}},
-			}},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
-	}
-
-	cursorPeriod, err := r.mongoColl.Aggregate(ctx, pipelinePeriod)
-	if err == nil {
-		var periodStats []struct {
-			ID struct {
-				Year  int `bson:"year"`
-				Month int `bson:"month"`
-			} `bson:"_id"`
-			Count int `bson:"count"`
-		}
-		if err = cursorPeriod.All(ctx, &periodStats); err == nil {
-			for _, s := range periodStats {
-				key := fmt.Sprintf("%d-%02d", s.ID.Year, s.ID.Month)
-				result.TotalPerPeriod[key] = s.Count
-			}
-		}
-	}
-
-	// 5. Summary Statistics (from PostgreSQL for accurate counts)
-	var totalAchievements, totalVerified, totalPending, totalRejected int
-
-	// Total achievements (exclude deleted)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE status != 'deleted'").Scan(&totalAchievements)
-
-	// By status
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE status = 'verified'").Scan(&totalVerified)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE status = 'submitted'").Scan(&totalPending)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE status = 'rejected'").Scan(&totalRejected)
-
-	// Total points (from MongoDB)
-	var totalPoints int
-	pipelinePoints := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{{Key: "points", Value: bson.D{{Key: "$gt", Value: 0}}}}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: nil},
-			{Key: "totalPoints", Value: bson.D{{Key: "$sum", Value: "$points"}}},
-		}}},
-	}
-
-	cursorPoints, err := r.mongoColl.Aggregate(ctx, pipelinePoints)
-	if err == nil {
-		var pointsResult []struct {
-			TotalPoints int `bson:"totalPoints"`
-		}
-		if err = cursorPoints.All(ctx, &pointsResult); err == nil && len(pointsResult) > 0 {
-			totalPoints = pointsResult[0].TotalPoints
-		}
-	}
-
-	result.Summary = StatsSummary{
-		TotalAchievements: totalAchievements,
-		TotalVerified:     totalVerified,
-		TotalPending:      totalPending,
-		TotalRejected:     totalRejected,
-		TotalPoints:       totalPoints,
-	}
-
-	return result, nil
-}
-
-// GetStudentStatistics (Personal Stats)
-func (r *AchievementRepository) GetStudentStatistics(ctx context.Context, studentID string) (*StatsResult, error) {
-	result := &StatsResult{
-		TotalPerType:   make(map[string]int),
-		TotalPerLevel:  make(map[string]int),
-		TotalPerPeriod: make(map[string]int),
-		TopStudents:    []TopStudent{}, // Empty for individual stats
-		Summary:        StatsSummary{},
-	}
-
-	// 1. Total Per Type
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{{Key: "studentId", Value: studentID}}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$achievementType"},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-	}
-
-	cursor, err := r.mongoColl.Aggregate(ctx, pipeline)
-	if err == nil {
-		var stats []struct {
-			ID    string `bson:"_id"`
-			Count int    `bson:"count"`
-		}
-		cursor.All(ctx, &stats)
-		for _, s := range stats {
-			result.TotalPerType[s.ID] = s.Count
-		}
-	}
-
-	// 2. Total Per Level (Competition only)
-	pipelineLevel := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{
-			{Key: "studentId", Value: studentID},
-			{Key: "achievementType", Value: "competition"},
-		}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$details.competitionLevel"},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-	}
-
-	cursorLevel, err := r.mongoColl.Aggregate(ctx, pipelineLevel)
-	if err == nil {
-		var levelStats []struct {
-			ID    string `bson:"_id"`
-			Count int    `bson:"count"`
-		}
-		if err = cursorLevel.All(ctx, &levelStats); err == nil {
-			for _, s := range levelStats {
-				key := s.ID
-				if key == "" {
-					key = "unknown"
-				}
-				result.TotalPerLevel[key] = s.Count
-			}
-		}
-	}
-
-	// 3. Total Per Period (Last 12 months for individual)
-	pipelinePeriod := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{
-			{Key: "studentId", Value: studentID},
-			{Key: "createdAt", Value: bson.D{
-				{Key: "$gte", Value: time.Now().AddDate(-1, 0, 0)}, // Last 12 months
-			}},
-		}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "year", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
-				{Key: "month", Value: bson.D{{Key: "$month", Value: "$createdAt"}}},
-			}},
-			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		}}},
-		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
-	}
-
-	cursorPeriod, err := r.mongoColl.Aggregate(ctx, pipelinePeriod)
-	if err == nil {
-		var periodStats []struct {
-			ID struct {
-				Year  int `bson:"year"`
-				Month int `bson:"month"`
-			} `bson:"_id"`
-			Count int `bson:"count"`
-		}
-		if err = cursorPeriod.All(ctx, &periodStats); err == nil {
-			for _, s := range periodStats {
-				key := fmt.Sprintf("%d-%02d", s.ID.Year, s.ID.Month)
-				result.TotalPerPeriod[key] = s.Count
-			}
-		}
-	}
-
-	// 4. Summary Statistics for this student
-	var totalAchievements, totalVerified, totalPending, totalRejected int
-
-	// Get counts from PostgreSQL
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE student_id = $1 AND status != 'deleted'", studentID).Scan(&totalAchievements)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE student_id = $1 AND status = 'verified'", studentID).Scan(&totalVerified)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE student_id = $1 AND status = 'submitted'", studentID).Scan(&totalPending)
-	r.pgDB.QueryRow("SELECT COUNT(*) FROM achievement_references WHERE student_id = $1 AND status = 'rejected'", studentID).Scan(&totalRejected)
-
-	// Total points for this student
-	var totalPoints int
-	pipelinePoints := mongo.Pipeline{
-		{{Key: "$match", Value: bson.D{
-			{Key: "studentId", Value: studentID},
-			{Key: "points", Value: bson.D{{Key: "$gt", Value: 0}}},
-		}}},
-		{{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: nil},
-			{Key: "totalPoints", Value: bson.D{{Key: "$sum", Value: "$points"}}},
-		}}},
-	}
-
-	cursorPoints, err := r.mongoColl.Aggregate(ctx, pipelinePoints)
-	if err == nil {
-		var pointsResult []struct {
-			TotalPoints int `bson:"totalPoints"`
-		}
-		if err = cursorPoints.All(ctx, &pointsResult); err == nil && len(pointsResult) > 0 {
-			totalPoints = pointsResult[0].TotalPoints
-		}
-	}
-
-	result.Summary = StatsSummary{
-		TotalAchievements: totalAchievements,
-		TotalVerified:     totalVerified,
-		TotalPending:      totalPending,
-		TotalRejected:     totalRejected,
-		TotalPoints:       totalPoints,
-	}
-
-	return result, nil
-}
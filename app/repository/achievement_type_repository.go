@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// --- ACHIEVEMENT TYPE REGISTRY ---
+
+// FindAchievementTypes mengambil semua tipe prestasi (hanya yang aktif jika activeOnly)
+func (r *AchievementRepository) FindAchievementTypes(ctx context.Context, activeOnly bool) ([]model.AchievementType, error) {
+	query := `
+		SELECT key, name, description, schema, is_system, is_active, team_verification, evidence_requirements, evidence_required_since, created_at, updated_at
+		FROM achievement_types
+		WHERE ($1 = FALSE OR is_active = TRUE)
+		ORDER BY is_system DESC, name ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []model.AchievementType{}
+	for rows.Next() {
+		t, err := scanAchievementType(rows)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, *t)
+	}
+	return types, rows.Err()
+}
+
+// FindAchievementType mengambil satu tipe berdasarkan key (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindAchievementType(ctx context.Context, key string) (*model.AchievementType, error) {
+	row := r.pgDB.QueryRowContext(ctx, `
+		SELECT key, name, description, schema, is_system, is_active, team_verification, evidence_requirements, evidence_required_since, created_at, updated_at
+		FROM achievement_types
+		WHERE key = $1`, key)
+
+	return scanAchievementType(row)
+}
+
+// CreateAchievementType menyimpan tipe baru buatan Admin
+func (r *AchievementRepository) CreateAchievementType(ctx context.Context, t *model.AchievementType) error {
+	schema, err := json.Marshal(t.Schema)
+	if err != nil {
+		return err
+	}
+	evidence, err := json.Marshal(evidenceOrEmpty(t.EvidenceRequirements))
+	if err != nil {
+		return err
+	}
+
+	err = r.pgDB.QueryRowContext(ctx, `
+		INSERT INTO achievement_types (key, name, description, schema, is_active, team_verification, evidence_requirements, evidence_required_since)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING is_system, evidence_required_since, created_at, updated_at`,
+		t.Key, t.Name, t.Description, schema, t.IsActive, t.TeamVerification, evidence,
+	).Scan(&t.IsSystem, &t.EvidenceRequiredSince, &t.CreatedAt, &t.UpdatedAt)
+	return translatePgError(err)
+}
+
+// UpdateAchievementType mengganti nama, deskripsi, schema, status aktif,
+// kebijakan verifikasi tim, dan bukti wajib.
+// Prestasi lama tidak divalidasi ulang; schema baru berlaku saat prestasi diedit,
+// bukti wajib yang berubah hanya berlaku untuk prestasi yang dibuat sesudahnya.
+func (r *AchievementRepository) UpdateAchievementType(ctx context.Context, t *model.AchievementType) error {
+	schema, err := json.Marshal(t.Schema)
+	if err != nil {
+		return err
+	}
+	evidence, err := json.Marshal(evidenceOrEmpty(t.EvidenceRequirements))
+	if err != nil {
+		return err
+	}
+
+	err = r.pgDB.QueryRowContext(ctx, `
+		UPDATE achievement_types
+		SET name = $1, description = $2, schema = $3, is_active = $4, team_verification = $6,
+			evidence_required_since = CASE WHEN evidence_requirements IS DISTINCT FROM $7::jsonb THEN NOW() ELSE evidence_required_since END,
+			evidence_requirements = $7, updated_at = NOW()
+		WHERE key = $5
+		RETURNING is_system, evidence_required_since, created_at, updated_at`,
+		t.Name, t.Description, schema, t.IsActive, t.Key, t.TeamVerification, evidence,
+	).Scan(&t.IsSystem, &t.EvidenceRequiredSince, &t.CreatedAt, &t.UpdatedAt)
+	return translatePgError(err)
+}
+
+func scanAchievementType(row interface{ Scan(...interface{}) error }) (*model.AchievementType, error) {
+	var t model.AchievementType
+	var schema, evidence []byte
+	var requiredSince sql.NullTime
+	if err := row.Scan(&t.Key, &t.Name, &t.Description, &schema, &t.IsSystem, &t.IsActive, &t.TeamVerification, &evidence, &requiredSince, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if requiredSince.Valid {
+		t.EvidenceRequiredSince = &requiredSince.Time
+	}
+	if err := json.Unmarshal(schema, &t.Schema); err != nil {
+		return nil, fmt.Errorf("invalid schema for achievement type %s: %w", t.Key, err)
+	}
+	if err := json.Unmarshal(evidence, &t.EvidenceRequirements); err != nil {
+		return nil, fmt.Errorf("invalid evidence requirements for achievement type %s: %w", t.Key, err)
+	}
+	t.EvidenceRequirements = evidenceOrEmpty(t.EvidenceRequirements)
+	return &t, nil
+}
+
+// evidenceOrEmpty menjaga bukti wajib tersimpan & terkirim sebagai [] (bukan null)
+func evidenceOrEmpty(requirements []model.EvidenceRequirement) []model.EvidenceRequirement {
+	if requirements == nil {
+		return []model.EvidenceRequirement{}
+	}
+	return requirements
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// --- CERTIFICATION EXPIRY ---
+
+// FindExpiringCertifications mengambil sertifikat terverifikasi yang habis masa
+// berlakunya dalam CertificationExpiryWindow dan belum diingatkan
+func (r *AchievementRepository) FindExpiringCertifications(ctx context.Context, now time.Time) ([]model.CertificationExpiry, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT ar.id, ar.title, ar.valid_until, u.full_name, s.student_id
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		WHERE ar.status = 'verified' AND ar.expiry_reminded_at IS NULL
+		  AND ar.valid_until > $1 AND ar.valid_until <= $2
+		ORDER BY ar.valid_until ASC`,
+		now, now.Add(model.CertificationExpiryWindow),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expiring := []model.CertificationExpiry{}
+	for rows.Next() {
+		var e model.CertificationExpiry
+		var studentName, nim string
+		if err := rows.Scan(&e.AchievementRefID, &e.Title, &e.ValidUntil, &studentName, &nim); err != nil {
+			return nil, err
+		}
+		e.Student = studentName + " (" + nim + ")"
+		expiring = append(expiring, e)
+	}
+	return expiring, rows.Err()
+}
+
+// MarkExpiryReminded menandai pengingat kedaluwarsa sudah dikirim. false jika
+// sudah ditandai proses lain.
+func (r *AchievementRepository) MarkExpiryReminded(ctx context.Context, refID string, at time.Time) (bool, error) {
+	res, err := r.pgDB.ExecContext(ctx,
+		`UPDATE achievement_references SET expiry_reminded_at = $2
+		WHERE id = $1 AND expiry_reminded_at IS NULL`,
+		refID, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// SyncValidUntil menyalin details.validUntil dari MongoDB ke Postgres untuk
+// reference yang belum sinkron (data sebelum kolom valid_until ada)
+func (r *AchievementRepository) SyncValidUntil(ctx context.Context) (int, error) {
+	cursor, err := r.mongoColl.Find(ctx,
+		bson.M{"details.validUntil": bson.M{"$exists": true}, "isDeleted": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"details.validUntil": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	synced := 0
+	for cursor.Next(ctx) {
+		var doc model.Achievement
+		if err := cursor.Decode(&doc); err != nil {
+			return synced, err
+		}
+		res, err := r.pgDB.ExecContext(ctx,
+			`UPDATE achievement_references SET valid_until = $2
+			WHERE mongo_achievement_id = $1 AND valid_until IS DISTINCT FROM $2`,
+			doc.ID.Hex(), doc.Details.ValidUntil,
+		)
+		if err != nil {
+			return synced, err
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			synced++
+		}
+	}
+	return synced, cursor.Err()
+}
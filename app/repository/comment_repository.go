@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/lib/pq"
+)
+
+// --- ACHIEVEMENT COMMENTS ---
+
+// CreateComment menyimpan komentar beserta daftar user yang di-mention
+func (r *AchievementRepository) CreateComment(ctx context.Context, comment *model.AchievementComment) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO achievement_comments (achievement_ref_id, parent_id, author_id, body, anchor_field, anchor_attachment_url, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		comment.AchievementRefID, comment.ParentID, comment.AuthorID, comment.Body,
+		comment.AnchorField, comment.AnchorAttachmentURL, comment.CreatedAt,
+	).Scan(&comment.ID)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	if err := insertMentions(ctx, tx, comment.ID, comment.Mentions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateCommentBody mengganti isi komentar (mention ikut diganti)
+func (r *AchievementRepository) UpdateCommentBody(ctx context.Context, comment *model.AchievementComment, at time.Time) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE achievement_comments SET body = $1, edited_at = $2 WHERE id = $3`,
+		comment.Body, at, comment.ID,
+	)
+	if err != nil {
+		return translatePgError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM achievement_comment_mentions WHERE comment_id = $1`, comment.ID); err != nil {
+		return err
+	}
+	if err := insertMentions(ctx, tx, comment.ID, comment.Mentions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	comment.EditedAt = &at
+	return nil
+}
+
+func insertMentions(ctx context.Context, tx *sql.Tx, commentID string, users []model.User) error {
+	for _, u := range users {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO achievement_comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			commentID, u.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCommentResolved menandai komentar akar selesai / dibuka kembali
+func (r *AchievementRepository) SetCommentResolved(ctx context.Context, id string, resolved bool, actorID string, at time.Time) error {
+	var by, resolvedAt interface{} = nil, nil
+	if resolved {
+		by, resolvedAt = actorID, at
+	}
+	res, err := r.pgDB.ExecContext(ctx,
+		`UPDATE achievement_comments SET is_resolved = $1, resolved_by = $2, resolved_at = $3
+		WHERE id = $4 AND parent_id IS NULL`,
+		resolved, by, resolvedAt, id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const commentColumns = `
+		SELECT c.id, c.achievement_ref_id, c.parent_id, c.author_id, u.username, u.full_name,
+			c.body, c.anchor_field, c.anchor_attachment_url,
+			c.is_resolved, c.resolved_by, c.resolved_at, c.edited_at, c.created_at
+		FROM achievement_comments c
+		JOIN users u ON u.id = c.author_id`
+
+// FindComment mengambil satu komentar (tanpa mention)
+func (r *AchievementRepository) FindComment(ctx context.Context, id string) (*model.AchievementComment, error) {
+	comments, err := r.queryComments(ctx, commentColumns+` WHERE c.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &comments[0], nil
+}
+
+// FindComments mengambil semua komentar prestasi (urut waktu) beserta mention
+func (r *AchievementRepository) FindComments(ctx context.Context, refID string) ([]model.AchievementComment, error) {
+	comments, err := r.queryComments(ctx, commentColumns+` WHERE c.achievement_ref_id = $1 ORDER BY c.created_at ASC`, refID)
+	if err != nil || len(comments) == 0 {
+		return comments, err
+	}
+
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT m.comment_id, u.id, u.username, u.full_name
+		FROM achievement_comment_mentions m
+		JOIN achievement_comments c ON c.id = m.comment_id
+		JOIN users u ON u.id = m.user_id
+		WHERE c.achievement_ref_id = $1
+		ORDER BY u.username`, refID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(comments))
+	for i := range comments {
+		index[comments[i].ID] = i
+	}
+	for rows.Next() {
+		var commentID string
+		var u model.User
+		if err := rows.Scan(&commentID, &u.ID, &u.Username, &u.FullName); err != nil {
+			return nil, err
+		}
+		if i, ok := index[commentID]; ok {
+			comments[i].Mentions = append(comments[i].Mentions, u)
+		}
+	}
+	return comments, rows.Err()
+}
+
+func (r *AchievementRepository) queryComments(ctx context.Context, query string, arg interface{}) ([]model.AchievementComment, error) {
+	rows, err := r.pgDB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]model.AchievementComment, 0)
+	for rows.Next() {
+		var c model.AchievementComment
+		var author model.User
+		var parentID, anchorField, anchorURL, resolvedBy sql.NullString
+		var resolvedAt, editedAt sql.NullTime
+		err := rows.Scan(
+			&c.ID, &c.AchievementRefID, &parentID, &c.AuthorID, &author.Username, &author.FullName,
+			&c.Body, &anchorField, &anchorURL,
+			&c.IsResolved, &resolvedBy, &resolvedAt, &editedAt, &c.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		author.ID = c.AuthorID
+		c.Author = &author
+		c.ParentID = nullStringPtr(parentID)
+		c.AnchorField = nullStringPtr(anchorField)
+		c.AnchorAttachmentURL = nullStringPtr(anchorURL)
+		c.ResolvedBy = nullStringPtr(resolvedBy)
+		if resolvedAt.Valid {
+			c.ResolvedAt = &resolvedAt.Time
+		}
+		if editedAt.Valid {
+			c.EditedAt = &editedAt.Time
+		}
+		c.Mentions = []model.User{}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// FindDiscussionParticipants mengembalikan user aktif dengan username di
+// daftar yang boleh ikut diskusi prestasi: pemilik, dosen wali, pemutus tahap,
+// pemegang permission salah satu tahap (termasuk eskalasi), dan Admin.
+func (r *AchievementRepository) FindDiscussionParticipants(ctx context.Context, refID string, usernames []string) ([]model.User, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT u.id, u.username, u.full_name
+		FROM users u
+		WHERE u.username = ANY($2) AND u.is_active AND (
+			u.id IN (
+				SELECT s.user_id FROM achievement_references ar
+				JOIN students s ON s.id = ar.student_id
+				WHERE ar.id = $1
+				UNION
+				SELECT l.user_id FROM achievement_references ar
+				JOIN students s ON s.id = ar.student_id
+				JOIN lecturers l ON l.id = s.advisor_id
+				WHERE ar.id = $1
+				UNION
+				SELECT s.user_id FROM achievement_team_members tm
+				JOIN students s ON s.id = tm.student_id
+				WHERE tm.achievement_ref_id = $1
+				UNION
+				SELECT l.user_id FROM achievement_team_members tm
+				JOIN students s ON s.id = tm.student_id
+				JOIN lecturers l ON l.id = s.advisor_id
+				WHERE tm.achievement_ref_id = $1 AND tm.status = 'confirmed'
+				UNION
+				SELECT st.decided_by FROM achievement_verification_stages st
+				WHERE st.achievement_ref_id = $1 AND st.decided_by IS NOT NULL
+				UNION
+				SELECT st.escalated_to FROM achievement_verification_stages st
+				WHERE st.achievement_ref_id = $1 AND st.escalated_to IS NOT NULL
+			)
+			OR EXISTS (
+				SELECT 1 FROM role_permissions rp
+				JOIN permissions p ON p.id = rp.permission_id
+				WHERE rp.role_id = u.role_id AND (
+					p.name = 'user:manage' OR p.name IN (
+						SELECT st.permission FROM achievement_verification_stages st WHERE st.achievement_ref_id = $1
+						UNION
+						SELECT st.escalated_permission FROM achievement_verification_stages st
+						WHERE st.achievement_ref_id = $1 AND st.escalated_permission IS NOT NULL
+					)
+				)
+			)
+		)
+		ORDER BY u.username`,
+		refID, pq.Array(usernames),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		var u model.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.FullName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// --- CONTENT VERSIONS ---
+
+// saveContentVersion menyimpan salinan dokumen sebagai versi konten berikutnya
+// (dipanggil di dalam transaksi) dan mengembalikan nomor versinya. Untuk data
+// lama yang belum punya salinan, isi sebelumnya disimpan dulu sebagai versi
+// 'imported' agar perubahan pertama tetap bisa dibandingkan.
+func saveContentVersion(ctx context.Context, tx *sql.Tx, refID string, previous, content *model.Achievement, change string, at time.Time) (int, error) {
+	var current int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT content_version FROM achievement_references WHERE id = $1 FOR UPDATE", refID,
+	).Scan(&current); err != nil {
+		return 0, err
+	}
+
+	insert := func(version int, doc *model.Achievement, change string) error {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO achievement_content_versions (achievement_ref_id, version, change, content, created_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			refID, version, change, raw, at,
+		)
+		return err
+	}
+
+	if current == 0 && previous != nil {
+		current++
+		if err := insert(current, previous, model.ContentImported); err != nil {
+			return 0, err
+		}
+	}
+	current++
+	if err := insert(current, content, change); err != nil {
+		return 0, err
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE achievement_references SET content_version = $2 WHERE id = $1", refID, current)
+	return current, err
+}
+
+// FindContentVersions mengambil daftar versi konten prestasi (terbaru dulu,
+// tanpa isi dokumen)
+func (r *AchievementRepository) FindContentVersions(ctx context.Context, refID string) ([]model.AchievementContentVersion, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT id, achievement_ref_id, version, change, created_at
+		FROM achievement_content_versions
+		WHERE achievement_ref_id = $1
+		ORDER BY version DESC`, refID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []model.AchievementContentVersion{}
+	for rows.Next() {
+		var v model.AchievementContentVersion
+		if err := rows.Scan(&v.ID, &v.AchievementRefID, &v.Version, &v.Change, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// FindContentVersion mengambil satu versi beserta isi dokumennya
+// (sql.ErrNoRows jika versi tidak ada)
+func (r *AchievementRepository) FindContentVersion(ctx context.Context, refID string, version int) (*model.AchievementContentVersion, error) {
+	var v model.AchievementContentVersion
+	var raw []byte
+	err := r.pgDB.QueryRowContext(ctx, `
+		SELECT id, achievement_ref_id, version, change, created_at, content
+		FROM achievement_content_versions
+		WHERE achievement_ref_id = $1 AND version = $2`, refID, version,
+	).Scan(&v.ID, &v.AchievementRefID, &v.Version, &v.Change, &v.CreatedAt, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Content = &model.Achievement{}
+	if err := json.Unmarshal(raw, v.Content); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// LastRejectedContentVersion mengembalikan versi konten yang terakhir ditolak
+// (0 jika belum pernah ditolak atau penolakan terjadi sebelum ada versioning)
+func (r *AchievementRepository) LastRejectedContentVersion(ctx context.Context, refID string) (int, error) {
+	var version sql.NullInt64
+	err := r.pgDB.QueryRowContext(ctx, `
+		SELECT content_version FROM achievement_status_history
+		WHERE achievement_ref_id = $1 AND to_status = 'rejected'
+		ORDER BY created_at DESC LIMIT 1`, refID,
+	).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/lib/pq"
+)
+
+// --- DUPLICATE DETECTION ---
+
+// SaveFingerprint menyimpan (atau mengganti) sidik jari details prestasi
+func (r *AchievementRepository) SaveFingerprint(ctx context.Context, fp model.Fingerprint) error {
+	_, err := r.pgDB.ExecContext(ctx, `
+		INSERT INTO achievement_fingerprints (achievement_ref_id, achievement_type, name, organizer, certification_number, event_date, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (achievement_ref_id) DO UPDATE
+		SET achievement_type = EXCLUDED.achievement_type, name = EXCLUDED.name, organizer = EXCLUDED.organizer,
+			certification_number = EXCLUDED.certification_number, event_date = EXCLUDED.event_date, updated_at = NOW()`,
+		fp.AchievementRefID, fp.AchievementType, fp.Name, fp.Organizer, fp.CertificationNumber, fp.EventDate,
+	)
+	return translatePgError(err)
+}
+
+// FindDuplicateCandidates mengambil sidik jari prestasi lain (belum dihapus)
+// dengan nomor sertifikat atau tanggal kegiatan yang sama; skor dihitung di model
+func (r *AchievementRepository) FindDuplicateCandidates(ctx context.Context, fp model.Fingerprint) ([]model.Fingerprint, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT f.achievement_ref_id, f.achievement_type, f.name, f.organizer, f.certification_number, f.event_date
+		FROM achievement_fingerprints f
+		JOIN achievement_references ar ON ar.id = f.achievement_ref_id
+		WHERE f.achievement_ref_id <> $1 AND ar.status != 'deleted'
+		  AND ((f.certification_number <> '' AND f.certification_number = $2) OR f.event_date = $3)`,
+		fp.AchievementRefID, fp.CertificationNumber, fp.EventDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []model.Fingerprint{}
+	for rows.Next() {
+		var c model.Fingerprint
+		var eventDate sql.NullTime
+		if err := rows.Scan(&c.AchievementRefID, &c.AchievementType, &c.Name, &c.Organizer, &c.CertificationNumber, &eventDate); err != nil {
+			return nil, err
+		}
+		if eventDate.Valid {
+			date := eventDate.Time.UTC()
+			c.EventDate = &date
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// SaveDuplicateFlags menyimpan dugaan duplikat milik refID. Flag lama dengan
+// alasan di replaceReasons dihapus lebih dulu (details dinilai ulang setiap edit).
+func (r *AchievementRepository) SaveDuplicateFlags(ctx context.Context, refID string, flags []model.DuplicateFlag, replaceReasons []string) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(replaceReasons) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM achievement_duplicate_flags WHERE achievement_ref_id = $1 AND reason = ANY($2)`,
+			refID, pq.Array(replaceReasons),
+		); err != nil {
+			return err
+		}
+	}
+
+	for i := range flags {
+		f := &flags[i]
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO achievement_duplicate_flags (achievement_ref_id, matched_ref_id, reason, score, detail)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (achievement_ref_id, matched_ref_id, reason) DO UPDATE
+			SET score = EXCLUDED.score, detail = EXCLUDED.detail
+			RETURNING id, created_at`,
+			refID, f.MatchedRefID, f.Reason, f.Score, f.Detail,
+		).Scan(&f.ID, &f.CreatedAt)
+		if err != nil {
+			return translatePgError(err)
+		}
+		f.AchievementRefID = refID
+	}
+	return tx.Commit()
+}
+
+// SaveAttachmentHash mencatat SHA-256 lampiran lalu mengembalikan prestasi
+// lain (belum dihapus) yang memakai file identik
+func (r *AchievementRepository) SaveAttachmentHash(ctx context.Context, refID string, attachment model.AchievementAttachment, sha string) ([]model.DuplicateFlag, error) {
+	_, err := r.pgDB.ExecContext(ctx, `
+		INSERT INTO achievement_attachment_hashes (achievement_ref_id, file_url, file_name, sha256, uploaded_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (achievement_ref_id, file_url) DO UPDATE SET sha256 = EXCLUDED.sha256`,
+		refID, attachment.FileURL, attachment.FileName, sha, attachment.UploadedAt,
+	)
+	if err != nil {
+		return nil, translatePgError(err)
+	}
+
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT DISTINCT ON (h.achievement_ref_id) h.achievement_ref_id, h.file_name
+		FROM achievement_attachment_hashes h
+		JOIN achievement_references ar ON ar.id = h.achievement_ref_id
+		WHERE h.sha256 = $2 AND h.achievement_ref_id <> $1 AND ar.status != 'deleted'
+		ORDER BY h.achievement_ref_id, h.uploaded_at`,
+		refID, sha,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []model.DuplicateFlag{}
+	for rows.Next() {
+		f := model.DuplicateFlag{Reason: model.DuplicateAttachmentHash, Score: 1}
+		var fileName string
+		if err := rows.Scan(&f.MatchedRefID, &fileName); err != nil {
+			return nil, err
+		}
+		f.Detail = "Identical attachment: " + attachment.FileName + " = " + fileName
+		matches = append(matches, f)
+	}
+	return matches, rows.Err()
+}
+
+// FindDuplicateFlags mengambil dugaan duplikat dari kedua arah; Matched berisi
+// prestasi pasangan
+func (r *AchievementRepository) FindDuplicateFlags(ctx context.Context, refID string) ([]model.DuplicateFlag, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT f.id, f.achievement_ref_id, f.matched_ref_id, f.reason, f.score, f.detail, f.created_at,
+			other.id, other.title, other.status, s.student_id, u.full_name
+		FROM achievement_duplicate_flags f
+		JOIN achievement_references other
+			ON other.id = CASE WHEN f.achievement_ref_id = $1 THEN f.matched_ref_id ELSE f.achievement_ref_id END
+		JOIN students s ON s.id = other.student_id
+		JOIN users u ON u.id = s.user_id
+		WHERE f.achievement_ref_id = $1 OR f.matched_ref_id = $1
+		ORDER BY f.score DESC, f.created_at DESC`, refID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := []model.DuplicateFlag{}
+	for rows.Next() {
+		var f model.DuplicateFlag
+		m := &model.DuplicateMatch{}
+		if err := rows.Scan(
+			&f.ID, &f.AchievementRefID, &f.MatchedRefID, &f.Reason, &f.Score, &f.Detail, &f.CreatedAt,
+			&m.ID, &m.Title, &m.Status, &m.StudentID, &m.StudentName,
+		); err != nil {
+			return nil, err
+		}
+		f.MatchedRefID = m.ID
+		m.Link = "/api/v1/achievements/" + m.ID
+		f.Matched = m
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// GetDuplicateReport merangkum pola mencurigakan: lampiran identik antar
+// mahasiswa, nomor sertifikat yang dipakai ulang, dan mahasiswa yang paling
+// sering ditandai
+func (r *AchievementRepository) GetDuplicateReport(ctx context.Context, limit int) (*model.DuplicateReport, error) {
+	report := &model.DuplicateReport{
+		FlagsByReason:        map[string]int{},
+		SharedAttachments:    []model.SharedAttachment{},
+		ReusedCertifications: []model.ReusedCertification{},
+		MostFlaggedStudents:  []model.FlaggedStudent{},
+	}
+
+	rows, err := r.pgDB.QueryContext(ctx, `SELECT reason, COUNT(*) FROM achievement_duplicate_flags GROUP BY reason`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var reason string
+		var count int
+		if err := rows.Scan(&reason, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.FlagsByReason[reason] = count
+	}
+	rows.Close()
+
+	rows, err = r.pgDB.QueryContext(ctx, `
+		SELECT h.sha256, ARRAY_AGG(DISTINCT h.file_name), COUNT(DISTINCT ar.student_id), ARRAY_AGG(DISTINCT ar.id::text)
+		FROM achievement_attachment_hashes h
+		JOIN achievement_references ar ON ar.id = h.achievement_ref_id
+		WHERE ar.status != 'deleted'
+		GROUP BY h.sha256
+		HAVING COUNT(DISTINCT ar.student_id) > 1
+		ORDER BY COUNT(DISTINCT ar.student_id) DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var a model.SharedAttachment
+		if err := rows.Scan(&a.SHA256, pq.Array(&a.FileNames), &a.StudentCount, pq.Array(&a.AchievementIDs)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.SharedAttachments = append(report.SharedAttachments, a)
+	}
+	rows.Close()
+
+	rows, err = r.pgDB.QueryContext(ctx, `
+		SELECT f.certification_number, COUNT(DISTINCT ar.student_id), ARRAY_AGG(ar.id::text)
+		FROM achievement_fingerprints f
+		JOIN achievement_references ar ON ar.id = f.achievement_ref_id
+		WHERE f.certification_number <> '' AND ar.status != 'deleted'
+		GROUP BY f.certification_number
+		HAVING COUNT(*) > 1
+		ORDER BY COUNT(*) DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var cert model.ReusedCertification
+		if err := rows.Scan(&cert.CertificationNumber, &cert.StudentCount, pq.Array(&cert.AchievementIDs)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.ReusedCertifications = append(report.ReusedCertifications, cert)
+	}
+	rows.Close()
+
+	rows, err = r.pgDB.QueryContext(ctx, `
+		SELECT s.id, s.student_id, u.full_name, COUNT(*) AS flags
+		FROM achievement_duplicate_flags f
+		JOIN achievement_references ar ON ar.id = f.achievement_ref_id
+		JOIN students s ON s.id = ar.student_id
+		JOIN users u ON u.id = s.user_id
+		GROUP BY s.id, s.student_id, u.full_name
+		ORDER BY flags DESC, u.full_name ASC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var st model.FlaggedStudent
+		if err := rows.Scan(&st.StudentID, &st.NIM, &st.FullName, &st.FlagCount); err != nil {
+			return nil, err
+		}
+		report.MostFlaggedStudents = append(report.MostFlaggedStudents, st)
+	}
+	return report, rows.Err()
+}
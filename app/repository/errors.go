@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Error domain untuk pelanggaran constraint database. Service cukup mengecek
+// dengan errors.Is / errors.As tanpa perlu tahu kode error Postgres.
+var (
+	ErrDuplicate           = errors.New("duplicate value")
+	ErrInvalidReference    = errors.New("referenced record does not exist")
+	ErrConstraintViolation = errors.New("value violates constraint")
+)
+
+// ErrVersionMismatch dikembalikan jika baris sudah diubah request lain sejak
+// dibaca client (version/ETag yang dikirim lewat If-Match sudah usang)
+var ErrVersionMismatch = errors.New("resource was modified by another request")
+
+// ConstraintError membawa nama field (versi JSON) yang melanggar constraint
+type ConstraintError struct {
+	Kind       error  // Salah satu dari ErrDuplicate, ErrInvalidReference, ErrConstraintViolation
+	Field      string // Nama field sesuai request JSON, misal "email" atau "studentId"
+	Constraint string // Nama constraint di database (untuk log, bukan untuk client)
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Field + ": " + e.Kind.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
+
+// Kode SQLSTATE Postgres yang dipetakan
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+)
+
+// Kolom database -> nama field JSON (per tabel bila nama kolom ambigu)
+var columnFields = map[string]string{
+	"students.student_id":   "studentId",  // NIM
+	"lecturers.lecturer_id": "lecturerId", // NIP
+	"email":                 "email",
+	"username":              "username",
+	"full_name":             "fullName",
+	"role_id":               "roleId",
+	"user_id":               "userId",
+	"advisor_id":            "advisorId",
+	"lecturer_id":           "advisorId",
+	"student_id":            "studentId",
+}
+
+var keyDetailPattern = regexp.MustCompile(`Key \(([^)]+)\)=`)
+
+// translatePgError mengubah *pq.Error menjadi *ConstraintError. Error lain
+// dikembalikan apa adanya.
+func translatePgError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch string(pqErr.Code) {
+	case pgUniqueViolation:
+		kind = ErrDuplicate
+	case pgForeignKeyViolation:
+		kind = ErrInvalidReference
+	case pgCheckViolation, pgNotNullViolation:
+		kind = ErrConstraintViolation
+	default:
+		return err
+	}
+
+	return &ConstraintError{
+		Kind:       kind,
+		Field:      fieldFromPgError(pqErr),
+		Constraint: pqErr.Constraint,
+	}
+}
+
+func fieldFromPgError(e *pq.Error) string {
+	column := e.Column
+	if column == "" {
+		// Unique & FK: "Key (email)=(x) already exists."
+		if m := keyDetailPattern.FindStringSubmatch(e.Detail); m != nil {
+			column = strings.TrimSpace(strings.Split(m[1], ",")[0])
+		}
+	}
+	if column == "" && e.Constraint != "" {
+		// Check constraint default: <table>_<column>_check
+		column = strings.TrimSuffix(strings.TrimPrefix(e.Constraint, e.Table+"_"), "_check")
+	}
+
+	if f, ok := columnFields[e.Table+"."+column]; ok {
+		return f
+	}
+	if f, ok := columnFields[column]; ok {
+		return f
+	}
+	return column
+}
+
+// BatchError menandai change yang membatalkan satu transaksi batch
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve mencatat key untuk request yang akan diproses. Jika key sudah ada
+// (dan belum kedaluwarsa), record lamanya dikembalikan dan request tidak boleh
+// diproses ulang; nil berarti key berhasil dipesan.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userID, key, method, path, requestHash string, now time.Time) (*model.IdempotencyRecord, error) {
+	// Key kedaluwarsa boleh dipakai lagi
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND created_at < $3`,
+		userID, key, now.Add(-model.IdempotencyKeyTTL),
+	); err != nil {
+		return nil, err
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, method, path, request_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, key) DO NOTHING`,
+		userID, key, method, path, requestHash, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		return nil, nil
+	}
+
+	rec := model.IdempotencyRecord{UserID: userID, Key: key}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	var completedAt sql.NullTime
+	err = r.db.QueryRowContext(ctx,
+		`SELECT method, path, request_hash, status_code, content_type, response_body, created_at, completed_at
+		FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		userID, key,
+	).Scan(&rec.Method, &rec.Path, &rec.RequestHash, &statusCode, &contentType, &rec.ResponseBody, &rec.CreatedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		rec.StatusCode = &code
+	}
+	rec.ContentType = contentType.String
+	if completedAt.Valid {
+		rec.CompletedAt = &completedAt.Time
+	}
+	return &rec, nil
+}
+
+// Complete menyimpan snapshot response untuk diputar ulang
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5, completed_at = $6
+		WHERE user_id = $1 AND key = $2`,
+		userID, key, statusCode, contentType, body, at,
+	)
+	return err
+}
+
+// Release melepas key yang belum selesai (mis. request gagal dengan 5xx)
+// sehingga klien bisa mengulang dengan key yang sama
+func (r *IdempotencyRepository) Release(ctx context.Context, userID, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND completed_at IS NULL`,
+		userID, key,
+	)
+	return err
+}
+
+// DeleteExpired menghapus key yang lebih tua dari IdempotencyKeyTTL
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < $1`,
+		now.Add(-model.IdempotencyKeyTTL),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
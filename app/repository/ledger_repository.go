@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --- POINTS LEDGER ---
+
+// ErrAlreadyReversed dikembalikan jika entri yang akan dibatalkan sudah pernah dibatalkan
+var ErrAlreadyReversed = errors.New("ledger entry has already been reversed")
+
+// insertLedgerEntry menambah entri di dalam transaksi. student_id diambil dari
+// achievement_references agar entri selalu konsisten dengan pemilik prestasi.
+func insertLedgerEntry(ctx context.Context, tx *sql.Tx, e *model.PointsLedgerEntry) error {
+	// StudentID kosong = pemilik prestasi (ketua untuk prestasi tim)
+	var studentID interface{} = nil
+	if e.StudentID != "" {
+		studentID = e.StudentID
+	}
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO points_ledger (student_id, achievement_ref_id, entry_type, points, reason, actor_id, reverses_entry_id, rubric_version, created_at)
+		SELECT COALESCE($9::uuid, student_id), id, $2, $3, $4, $5, $6, $7, $8 FROM achievement_references WHERE id = $1
+		RETURNING id, student_id`,
+		e.AchievementRefID, e.EntryType, e.Points, e.Reason, e.ActorID, e.ReversesEntryID, e.RubricVersion, e.CreatedAt, studentID,
+	).Scan(&e.ID, &e.StudentID)
+	return translatePgError(err)
+}
+
+// AddLedgerEntry mencatat adjustment/expiration/reversal lalu menyamakan field
+// points di dokumen Mongo dengan saldo prestasi. Untuk reversal, entri asal
+// dikunci agar tidak bisa dibatalkan dua kali.
+func (r *AchievementRepository) AddLedgerEntry(ctx context.Context, e *model.PointsLedgerEntry) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if e.ReversesEntryID != nil {
+		// Reversal selalu mengimbangi persis entri asal pada prestasi yang sama
+		var original model.PointsLedgerEntry
+		var reversed bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT achievement_ref_id, student_id, entry_type, points,
+				EXISTS (SELECT 1 FROM points_ledger WHERE reverses_entry_id = $1)
+			FROM points_ledger WHERE id = $1 FOR UPDATE`, *e.ReversesEntryID,
+		).Scan(&original.AchievementRefID, &original.StudentID, &original.EntryType, &original.Points, &reversed)
+		if err != nil {
+			return err
+		}
+		if reversed || original.EntryType == model.LedgerReversal {
+			return ErrAlreadyReversed
+		}
+		e.AchievementRefID = original.AchievementRefID
+		e.StudentID = original.StudentID
+		e.EntryType = model.LedgerReversal
+		e.Points = -original.Points
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	if err := insertLedgerEntry(ctx, tx, e); err != nil {
+		return err
+	}
+
+	return r.commitLedger(ctx, tx, e.AchievementRefID)
+}
+
+// AddLedgerEntries mencatat beberapa entri untuk satu prestasi (mis. expiration
+// per anggota tim) dalam satu transaksi: semua tercatat atau tidak sama sekali
+func (r *AchievementRepository) AddLedgerEntries(ctx context.Context, achievementRefID string, entries []model.PointsLedgerEntry) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range entries {
+		entries[i].AchievementRefID = achievementRefID
+		if entries[i].CreatedAt.IsZero() {
+			entries[i].CreatedAt = now
+		}
+		if err := insertLedgerEntry(ctx, tx, &entries[i]); err != nil {
+			return err
+		}
+	}
+
+	return r.commitLedger(ctx, tx, achievementRefID)
+}
+
+// commitLedger menghitung saldo prestasi setelah entri baru, commit tx, lalu
+// menyamakan field points di dokumen Mongo
+func (r *AchievementRepository) commitLedger(ctx context.Context, tx *sql.Tx, achievementRefID string) error {
+	var mongoID string
+	var balance int
+	err := tx.QueryRowContext(ctx, `
+		SELECT ar.mongo_achievement_id, COALESCE(SUM(l.points), 0)
+		FROM achievement_references ar
+		LEFT JOIN points_ledger l ON l.achievement_ref_id = ar.id
+		WHERE ar.id = $1
+		GROUP BY ar.mongo_achievement_id`, achievementRefID,
+	).Scan(&mongoID, &balance)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Field points di Mongo hanya cerminan saldo untuk tampilan; sumber kebenaran tetap ledger
+	return r.setMongoPoints(ctx, mongoID, balance)
+}
+
+// markMongoRevoked menandai dokumen agar dikecualikan dari statistik MongoDB
+func (r *AchievementRepository) markMongoRevoked(ctx context.Context, mongoID string, at time.Time) error {
+	objID, err := primitive.ObjectIDFromHex(mongoID)
+	if err != nil {
+		return nil
+	}
+	_, err = r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"points":    0,
+		"isRevoked": true,
+		"revokedAt": at,
+		"updatedAt": at,
+	}})
+	if err != nil {
+		return errors.New("failed to mark achievement revoked in mongo: " + err.Error())
+	}
+	return nil
+}
+
+func (r *AchievementRepository) setMongoPoints(ctx context.Context, mongoID string, points int) error {
+	objID, err := primitive.ObjectIDFromHex(mongoID)
+	if err != nil {
+		return nil
+	}
+	if _, err := r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"points": points}}); err != nil {
+		return errors.New("failed to update points in mongo: " + err.Error())
+	}
+	return nil
+}
+
+// reverseAllLedgerEntries mencatat reversal untuk setiap entri prestasi yang
+// belum dibatalkan, sehingga saldo prestasi menjadi 0
+func reverseAllLedgerEntries(ctx context.Context, tx *sql.Tx, refID string, actor interface{}, reason string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO points_ledger (student_id, achievement_ref_id, entry_type, points, reason, actor_id, reverses_entry_id, rubric_version, created_at)
+		SELECT l.student_id, l.achievement_ref_id, 'reversal', -l.points, $2, $3, l.id, l.rubric_version, $4
+		FROM points_ledger l
+		WHERE l.achievement_ref_id = $1 AND l.entry_type <> 'reversal' AND l.points <> 0
+		  AND NOT EXISTS (SELECT 1 FROM points_ledger rev WHERE rev.reverses_entry_id = l.id)`,
+		refID, reason, actor, at,
+	)
+	return translatePgError(err)
+}
+
+// FindLedgerEntry mengambil satu entri (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindLedgerEntry(ctx context.Context, id string) (*model.PointsLedgerEntry, error) {
+	entries, err := r.queryLedger(ctx, "l.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &entries[0], nil
+}
+
+// FindLedgerByAchievement mengambil semua entri satu prestasi, terlama lebih dulu
+func (r *AchievementRepository) FindLedgerByAchievement(ctx context.Context, refID string) ([]model.PointsLedgerEntry, error) {
+	return r.queryLedger(ctx, "l.achievement_ref_id = $1", refID)
+}
+
+// FindLedgerByStudent mengambil semua entri milik satu mahasiswa
+func (r *AchievementRepository) FindLedgerByStudent(ctx context.Context, studentID string) ([]model.PointsLedgerEntry, error) {
+	return r.queryLedger(ctx, "l.student_id = $1", studentID)
+}
+
+func (r *AchievementRepository) queryLedger(ctx context.Context, condition string, arg interface{}) ([]model.PointsLedgerEntry, error) {
+	query := `
+		SELECT
+			l.id, l.student_id, l.achievement_ref_id, l.entry_type, l.points, l.reason,
+			l.actor_id, u.full_name, l.reverses_entry_id, rev.id, l.rubric_version, l.created_at
+		FROM points_ledger l
+		LEFT JOIN users u ON l.actor_id = u.id
+		LEFT JOIN points_ledger rev ON rev.reverses_entry_id = l.id
+		WHERE ` + condition + `
+		ORDER BY l.created_at ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.PointsLedgerEntry{}
+	for rows.Next() {
+		var e model.PointsLedgerEntry
+		var actorID, actorName, reverses, reversedBy sql.NullString
+		var rubricVersion sql.NullInt64
+		err := rows.Scan(
+			&e.ID, &e.StudentID, &e.AchievementRefID, &e.EntryType, &e.Points, &e.Reason,
+			&actorID, &actorName, &reverses, &reversedBy, &rubricVersion, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			e.ActorID = &actorID.String
+			e.Actor = &model.User{ID: actorID.String, FullName: actorName.String}
+		}
+		e.ReversesEntryID = nullStringPtr(reverses)
+		e.ReversedBy = nullStringPtr(reversedBy)
+		if rubricVersion.Valid {
+			v := int(rubricVersion.Int64)
+			e.RubricVersion = &v
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetLeaderboard mengurutkan mahasiswa berdasarkan saldo poin di ledger.
+// programStudy kosong = semua program studi.
+func (r *AchievementRepository) GetLeaderboard(ctx context.Context, limit int, programStudy string, filter StatsFilter) ([]model.PointsBalance, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT s.id, s.student_id, u.full_name, s.program_study, SUM(l.points) AS total
+		FROM points_ledger l
+		JOIN achievement_references ar ON l.achievement_ref_id = ar.id
+		JOIN students s ON l.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		WHERE ($2 = '' OR s.program_study = $2) AND `+filter.notExpired("ar.valid_until", 3)+`
+		GROUP BY s.id, s.student_id, u.full_name, s.program_study
+		HAVING SUM(l.points) > 0
+		ORDER BY total DESC, u.full_name ASC
+		LIMIT $1`, limit, programStudy, filter.ExcludeExpired, filter.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	board := []model.PointsBalance{}
+	for rows.Next() {
+		var b model.PointsBalance
+		if err := rows.Scan(&b.StudentID, &b.NIM, &b.FullName, &b.ProgramStudy, &b.TotalPoints); err != nil {
+			return nil, err
+		}
+		board = append(board, b)
+	}
+	return board, rows.Err()
+}
+
+// StudentPoints menjumlahkan saldo ledger. studentID kosong = seluruh mahasiswa.
+func (r *AchievementRepository) StudentPoints(ctx context.Context, studentID string, filter StatsFilter) (int, error) {
+	var total int
+	err := r.pgDB.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(l.points), 0) FROM points_ledger l
+		JOIN achievement_references ar ON l.achievement_ref_id = ar.id
+		WHERE ($1 = '' OR l.student_id::text = $1) AND `+filter.notExpired("ar.valid_until", 2),
+		studentID, filter.ExcludeExpired, filter.Now,
+	).Scan(&total)
+	return total, err
+}
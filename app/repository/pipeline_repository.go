@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// AdvanceStage menyetujui tahap aktif yang bukan tahap terakhir lalu memindahkan
+// pengajuan ke tahap berikutnya. Status tetap 'submitted' dan poin belum
+// dihitung; compare-and-set sama seperti UpdateStatus.
+func (r *AchievementRepository) AdvanceStage(ctx context.Context, change model.StatusChange, nextStage string) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	change.NextStage = nextStage
+	if err := advanceStageTx(ctx, tx, change, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// advanceStageTx menjalankan AdvanceStage di dalam tx (tahap tujuan di change.NextStage)
+func advanceStageTx(ctx context.Context, tx *sql.Tx, change model.StatusChange, now time.Time) error {
+	nextStage := change.NextStage
+
+	var round int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE achievement_references
+		SET current_stage = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND status = 'submitted' AND current_stage = $4 AND ($5::int = 0 OR version = $5)
+		RETURNING verification_round`,
+		nextStage, now, change.ID, change.Stage, change.ExpectedVersion,
+	).Scan(&round)
+	if err == sql.ErrNoRows {
+		var current sql.NullString
+		var version int
+		if err := tx.QueryRowContext(ctx, "SELECT current_stage, version FROM achievement_references WHERE id = $1", change.ID).Scan(&current, &version); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: expected stage %s (version %d), found %s (version %d)",
+			ErrStatusConflict, change.Stage, change.ExpectedVersion, current.String, version)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := decideStage(ctx, tx, change, round, model.StageApproved, now); err != nil {
+		return err
+	}
+
+	// Tahap berikutnya mulai menunggu keputusan
+	_, err = tx.ExecContext(ctx,
+		`UPDATE achievement_verification_stages SET started_at = $1
+		WHERE achievement_ref_id = $2 AND round = $3 AND stage_key = $4`,
+		now, change.ID, round, nextStage,
+	)
+	return err
+}
+
+// insertVerificationStages menyalin pipeline ke putaran pengajuan baru
+func insertVerificationStages(ctx context.Context, tx *sql.Tx, refID string, round int, pipeline []model.PipelineStage, at time.Time) error {
+	for i, stage := range pipeline {
+		var startedAt interface{} = nil
+		if i == 0 {
+			startedAt = at
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO achievement_verification_stages
+				(achievement_ref_id, round, stage_order, stage_key, name, permission, status, started_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7)`,
+			refID, round, stage.Order, stage.Key, stage.Name, stage.Permission, startedAt,
+		)
+		if err != nil {
+			return translatePgError(err)
+		}
+	}
+	return nil
+}
+
+// decideStage mencatat keputusan pada tahap aktif (change.Stage)
+func decideStage(ctx context.Context, tx *sql.Tx, change model.StatusChange, round int, decision string, at time.Time) error {
+	var actor, points interface{}
+	if change.ActorID != "" {
+		actor = change.ActorID
+	}
+	if decision == model.StageApproved && change.Points != 0 {
+		points = change.Points
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE achievement_verification_stages
+		SET status = $1, decided_by = $2, decided_at = $3, note = $4, points = $5,
+			content_version = (SELECT NULLIF(content_version, 0) FROM achievement_references WHERE id = $6)
+		WHERE achievement_ref_id = $6 AND round = $7 AND stage_key = $8 AND status = 'pending'`,
+		decision, actor, at, change.Note, points, change.ID, round, change.Stage,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: stage %s is not pending", ErrStatusConflict, change.Stage)
+	}
+	return nil
+}
+
+// FindStages mengambil tahap verifikasi satu putaran pengajuan (urut tahap)
+func (r *AchievementRepository) FindStages(ctx context.Context, refID string, round int) ([]model.VerificationStage, error) {
+	query := `
+		SELECT 
+			vs.id, vs.achievement_ref_id, vs.round, vs.stage_order, vs.stage_key, vs.name, vs.permission,
+			vs.status, vs.started_at, vs.decided_by, vs.decided_at, vs.note, vs.points,
+			u.full_name, vs.reminded_at, vs.escalated_at, vs.escalated_permission, vs.escalated_to,
+			vs.content_version
+		FROM achievement_verification_stages vs
+		LEFT JOIN users u ON vs.decided_by = u.id
+		WHERE vs.achievement_ref_id = $1 AND vs.round = $2
+		ORDER BY vs.stage_order ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, refID, round)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stages := []model.VerificationStage{}
+	for rows.Next() {
+		var st model.VerificationStage
+		var startedAt, decidedAt, remindedAt, escalatedAt sql.NullTime
+		var decidedBy, deciderName, escalatedPermission, escalatedTo sql.NullString
+		var points, contentVersion sql.NullInt64
+
+		err := rows.Scan(
+			&st.ID, &st.AchievementRefID, &st.Round, &st.Order, &st.Key, &st.Name, &st.Permission,
+			&st.Status, &startedAt, &decidedBy, &decidedAt, &st.Note, &points,
+			&deciderName, &remindedAt, &escalatedAt, &escalatedPermission, &escalatedTo,
+			&contentVersion,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if startedAt.Valid {
+			st.StartedAt = &startedAt.Time
+		}
+		if decidedAt.Valid {
+			st.DecidedAt = &decidedAt.Time
+		}
+		if decidedBy.Valid {
+			str := decidedBy.String
+			st.DecidedBy = &str
+			st.Decider = &model.User{ID: str, FullName: deciderName.String}
+		}
+		if points.Valid {
+			p := int(points.Int64)
+			st.Points = &p
+		}
+		if remindedAt.Valid {
+			st.RemindedAt = &remindedAt.Time
+		}
+		if escalatedAt.Valid {
+			st.EscalatedAt = &escalatedAt.Time
+		}
+		st.EscalatedPermission = nullStringPtr(escalatedPermission)
+		st.EscalatedTo = nullStringPtr(escalatedTo)
+		st.ContentVersion = nullIntPtr(contentVersion)
+		stages = append(stages, st)
+	}
+
+	return stages, rows.Err()
+}
+
+// FindPipeline memilih konfigurasi tahap paling spesifik untuk tipe & tingkat:
+// (tipe, tingkat) > (tipe, semua tingkat) > ('*', semua tingkat). Jika tidak
+// ada konfigurasi sama sekali, dipakai model.DefaultPipeline.
+func (r *AchievementRepository) FindPipeline(ctx context.Context, achievementType string, competitionLevel string) ([]model.PipelineStage, error) {
+	query := `
+		SELECT id, achievement_type, competition_level, stage_order, stage_key, name, permission
+		FROM verification_pipeline_stages
+		WHERE (achievement_type, COALESCE(competition_level, '')) = (
+			SELECT achievement_type, COALESCE(competition_level, '')
+			FROM verification_pipeline_stages
+			WHERE achievement_type IN ($1, '*')
+			  AND (competition_level IS NULL OR competition_level = $2)
+			ORDER BY (achievement_type = $1) DESC, (competition_level IS NOT NULL) DESC
+			LIMIT 1
+		)
+		ORDER BY stage_order ASC`
+
+	stages, err := r.scanPipelineStages(ctx, query, achievementType, competitionLevel)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return model.DefaultPipeline(), nil
+	}
+	return stages, nil
+}
+
+// FindAllPipelines mengambil seluruh konfigurasi tahap (untuk halaman admin)
+func (r *AchievementRepository) FindAllPipelines(ctx context.Context) ([]model.PipelineStage, error) {
+	query := `
+		SELECT id, achievement_type, competition_level, stage_order, stage_key, name, permission
+		FROM verification_pipeline_stages
+		ORDER BY achievement_type ASC, COALESCE(competition_level, '') ASC, stage_order ASC`
+
+	return r.scanPipelineStages(ctx, query)
+}
+
+// ReplacePipeline mengganti seluruh tahap untuk satu kombinasi tipe & tingkat.
+// stages kosong berarti konfigurasi dihapus (kembali ke konfigurasi yang lebih umum).
+func (r *AchievementRepository) ReplacePipeline(ctx context.Context, achievementType string, competitionLevel *string, stages []model.PipelineStage) ([]model.PipelineStage, error) {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM verification_pipeline_stages
+		WHERE achievement_type = $1 AND COALESCE(competition_level, '') = COALESCE($2, '')`,
+		achievementType, competitionLevel,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := make([]model.PipelineStage, 0, len(stages))
+	for _, stage := range stages {
+		stage.AchievementType = achievementType
+		stage.CompetitionLevel = competitionLevel
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO verification_pipeline_stages (achievement_type, competition_level, stage_order, stage_key, name, permission)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			achievementType, competitionLevel, stage.Order, stage.Key, stage.Name, stage.Permission,
+		).Scan(&stage.ID)
+		if err != nil {
+			return nil, translatePgError(err)
+		}
+		saved = append(saved, stage)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (r *AchievementRepository) scanPipelineStages(ctx context.Context, query string, args ...interface{}) ([]model.PipelineStage, error) {
+	rows, err := r.pgDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stages := []model.PipelineStage{}
+	for rows.Next() {
+		var st model.PipelineStage
+		var level sql.NullString
+		if err := rows.Scan(&st.ID, &st.AchievementType, &level, &st.Order, &st.Key, &st.Name, &st.Permission); err != nil {
+			return nil, err
+		}
+		if level.Valid {
+			str := level.String
+			st.CompetitionLevel = &str
+		}
+		stages = append(stages, st)
+	}
+	return stages, rows.Err()
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// --- POINT RUBRICS ---
+
+const rubricColumns = `id, version, name, description, is_active, created_by, created_at`
+
+// FindRubrics mengambil semua versi rubrik (tanpa aturan), terbaru lebih dulu
+func (r *AchievementRepository) FindRubrics(ctx context.Context) ([]model.PointRubric, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `SELECT `+rubricColumns+` FROM point_rubrics ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rubrics := []model.PointRubric{}
+	for rows.Next() {
+		rubric, err := scanRubric(rows)
+		if err != nil {
+			return nil, err
+		}
+		rubrics = append(rubrics, *rubric)
+	}
+	return rubrics, rows.Err()
+}
+
+// FindActiveRubric mengambil rubrik aktif beserta aturannya (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindActiveRubric(ctx context.Context) (*model.PointRubric, error) {
+	rubric, err := scanRubric(r.pgDB.QueryRowContext(ctx, `SELECT `+rubricColumns+` FROM point_rubrics WHERE is_active`))
+	if err != nil {
+		return nil, err
+	}
+	rubric.Rules, err = r.findRubricRules(ctx, rubric.ID)
+	return rubric, err
+}
+
+// FindRubric mengambil satu versi rubrik beserta aturannya (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindRubric(ctx context.Context, version int) (*model.PointRubric, error) {
+	rubric, err := scanRubric(r.pgDB.QueryRowContext(ctx, `SELECT `+rubricColumns+` FROM point_rubrics WHERE version = $1`, version))
+	if err != nil {
+		return nil, err
+	}
+	rubric.Rules, err = r.findRubricRules(ctx, rubric.ID)
+	return rubric, err
+}
+
+// CreateRubric menyimpan rubrik sebagai versi berikutnya. Jika rubric.IsActive,
+// rubrik lain dinonaktifkan dalam transaksi yang sama.
+func (r *AchievementRepository) CreateRubric(ctx context.Context, rubric *model.PointRubric) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Kunci tabel agar dua Admin tidak mendapat nomor versi yang sama
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE point_rubrics IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	if rubric.IsActive {
+		if _, err := tx.ExecContext(ctx, `UPDATE point_rubrics SET is_active = FALSE WHERE is_active`); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO point_rubrics (version, name, description, is_active, created_by)
+		SELECT COALESCE(MAX(version), 0) + 1, $1, $2, $3, $4 FROM point_rubrics
+		RETURNING id, version, created_at`,
+		rubric.Name, rubric.Description, rubric.IsActive, rubric.CreatedBy,
+	).Scan(&rubric.ID, &rubric.Version, &rubric.CreatedAt)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	for i := range rubric.Rules {
+		rule := &rubric.Rules[i]
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO point_rubric_rules (rubric_id, achievement_type, competition_level, rank, medal_type, publication_type, indexing, position, points, description, team_points)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id`,
+			rubric.ID, rule.AchievementType, rule.CompetitionLevel, rule.Rank, rule.MedalType,
+			rule.PublicationType, rule.Indexing, rule.Position, rule.Points, rule.Description, rule.TeamPoints,
+		).Scan(&rule.ID)
+		if err != nil {
+			return translatePgError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ActivateRubric menjadikan versi tertentu sebagai rubrik aktif (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) ActivateRubric(ctx context.Context, version int) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE point_rubrics SET is_active = FALSE WHERE is_active AND version != $1`, version); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE point_rubrics SET is_active = TRUE WHERE version = $1`, version)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *AchievementRepository) findRubricRules(ctx context.Context, rubricID string) ([]model.PointRule, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT id, achievement_type, competition_level, rank, medal_type, publication_type, indexing, position, points, description, team_points
+		FROM point_rubric_rules
+		WHERE rubric_id = $1
+		ORDER BY achievement_type ASC, points DESC`, rubricID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []model.PointRule{}
+	for rows.Next() {
+		var rule model.PointRule
+		var level, medal, pubType, indexing, position sql.NullString
+		var rank sql.NullInt64
+		if err := rows.Scan(&rule.ID, &rule.AchievementType, &level, &rank, &medal, &pubType, &indexing, &position, &rule.Points, &rule.Description, &rule.TeamPoints); err != nil {
+			return nil, err
+		}
+		rule.CompetitionLevel = nullStringPtr(level)
+		rule.MedalType = nullStringPtr(medal)
+		rule.PublicationType = nullStringPtr(pubType)
+		rule.Indexing = nullStringPtr(indexing)
+		rule.Position = nullStringPtr(position)
+		if rank.Valid {
+			n := int(rank.Int64)
+			rule.Rank = &n
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanRubric(row interface{ Scan(...interface{}) error }) (*model.PointRubric, error) {
+	var rubric model.PointRubric
+	var createdBy sql.NullString
+	if err := row.Scan(&rubric.ID, &rubric.Version, &rubric.Name, &rubric.Description, &rubric.IsActive, &createdBy, &rubric.CreatedAt); err != nil {
+		return nil, err
+	}
+	rubric.CreatedBy = nullStringPtr(createdBy)
+	return &rubric, nil
+}
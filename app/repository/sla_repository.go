@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// --- VERIFICATION SLA ---
+
+// FindSLAs mengambil konfigurasi SLA semua tahap
+func (r *AchievementRepository) FindSLAs(ctx context.Context) ([]model.VerificationSLA, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT stage_key, due_days, escalate_after_days, escalation_permission, delegate_user_id, updated_at
+		FROM verification_slas
+		ORDER BY stage_key ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slas := []model.VerificationSLA{}
+	for rows.Next() {
+		var sla model.VerificationSLA
+		var permission, delegate sql.NullString
+		if err := rows.Scan(&sla.StageKey, &sla.DueDays, &sla.EscalateAfterDays, &permission, &delegate, &sla.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sla.EscalationPermission = nullStringPtr(permission)
+		sla.DelegateUserID = nullStringPtr(delegate)
+		slas = append(slas, sla)
+	}
+	return slas, rows.Err()
+}
+
+// SaveSLA membuat atau mengganti SLA satu tahap. Hanya berlaku untuk
+// pengecekan berikutnya; jejak pengingat/eskalasi yang sudah ada tetap.
+func (r *AchievementRepository) SaveSLA(ctx context.Context, sla *model.VerificationSLA) error {
+	err := r.pgDB.QueryRowContext(ctx, `
+		INSERT INTO verification_slas (stage_key, due_days, escalate_after_days, escalation_permission, delegate_user_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (stage_key) DO UPDATE SET
+			due_days = EXCLUDED.due_days,
+			escalate_after_days = EXCLUDED.escalate_after_days,
+			escalation_permission = EXCLUDED.escalation_permission,
+			delegate_user_id = EXCLUDED.delegate_user_id,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`,
+		sla.StageKey, sla.DueDays, sla.EscalateAfterDays, sla.EscalationPermission, sla.DelegateUserID,
+	).Scan(&sla.UpdatedAt)
+	return translatePgError(err)
+}
+
+// DeleteSLA menghapus SLA satu tahap (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) DeleteSLA(ctx context.Context, stageKey string) error {
+	res, err := r.pgDB.ExecContext(ctx, "DELETE FROM verification_slas WHERE stage_key = $1", stageKey)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FindSLABreaches mengambil tahap aktif yang sudah lewat jatuh tempo pada waktu
+// now dan belum dieskalasi (dipakai job SLA)
+func (r *AchievementRepository) FindSLABreaches(ctx context.Context, now time.Time) ([]model.SLABreach, error) {
+	query := `
+		SELECT
+			vs.id, vs.achievement_ref_id, vs.round, vs.stage_order, vs.stage_key, vs.name, vs.permission,
+			vs.started_at, vs.reminded_at,
+			sla.due_days, sla.escalate_after_days, sla.escalation_permission, sla.delegate_user_id,
+			ar.title, u.full_name, s.student_id, COALESCE(lu.full_name, '')
+		FROM achievement_verification_stages vs
+		JOIN achievement_references ar
+			ON ar.id = vs.achievement_ref_id AND ar.verification_round = vs.round AND ar.current_stage = vs.stage_key
+		JOIN verification_slas sla ON sla.stage_key = vs.stage_key
+		JOIN students s ON ar.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		LEFT JOIN lecturers l ON vs.stage_key = 'advisor' AND l.id = COALESCE(ar.assigned_verifier_id, s.advisor_id)
+		LEFT JOIN users lu ON l.user_id = lu.id
+		WHERE ar.status = 'submitted' AND vs.status = 'pending'
+		  AND vs.escalated_at IS NULL AND vs.started_at IS NOT NULL
+		  AND vs.started_at + make_interval(days => sla.due_days) <= $1
+		ORDER BY vs.started_at ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breaches := []model.SLABreach{}
+	for rows.Next() {
+		var b model.SLABreach
+		var startedAt time.Time
+		var remindedAt sql.NullTime
+		var permission, delegate sql.NullString
+		var studentName, nim string
+		err := rows.Scan(
+			&b.Stage.ID, &b.Stage.AchievementRefID, &b.Stage.Round, &b.Stage.Order, &b.Stage.Key, &b.Stage.Name, &b.Stage.Permission,
+			&startedAt, &remindedAt,
+			&b.SLA.DueDays, &b.SLA.EscalateAfterDays, &permission, &delegate,
+			&b.Title, &studentName, &nim, &b.AdvisorName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		b.Stage.Status = model.StagePending
+		b.Stage.StartedAt = &startedAt
+		if remindedAt.Valid {
+			b.Stage.RemindedAt = &remindedAt.Time
+		}
+		b.SLA.StageKey = b.Stage.Key
+		b.SLA.EscalationPermission = nullStringPtr(permission)
+		b.SLA.DelegateUserID = nullStringPtr(delegate)
+		b.Student = studentName + " (" + nim + ")"
+		breaches = append(breaches, b)
+	}
+	return breaches, rows.Err()
+}
+
+// MarkStageReminded mencatat pengingat. false jika tahap sudah diputuskan atau
+// pengingat sudah pernah dikirim (mis. job berjalan bersamaan).
+func (r *AchievementRepository) MarkStageReminded(ctx context.Context, stageID string, at time.Time) (bool, error) {
+	res, err := r.pgDB.ExecContext(ctx,
+		`UPDATE achievement_verification_stages SET reminded_at = $2
+		WHERE id = $1 AND status = 'pending' AND reminded_at IS NULL`,
+		stageID, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// EscalateStage membuka tahap untuk target eskalasi SLA. false jika tahap sudah
+// diputuskan atau sudah dieskalasi.
+func (r *AchievementRepository) EscalateStage(ctx context.Context, stageID string, sla model.VerificationSLA, at time.Time) (bool, error) {
+	res, err := r.pgDB.ExecContext(ctx,
+		`UPDATE achievement_verification_stages
+		SET escalated_at = $2, escalated_permission = $3, escalated_to = $4, reminded_at = COALESCE(reminded_at, $2)
+		WHERE id = $1 AND status = 'pending' AND escalated_at IS NULL`,
+		stageID, at, sla.EscalationPermission, sla.DelegateUserID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// --- STATISTICS (FR-011) ---
+
+type StatsResult struct {
+	TotalPerType   map[string]int `json:"totalPerType"`
+	TotalPerLevel  map[string]int `json:"totalPerLevel"`
+	TotalPerPeriod map[string]int `json:"totalPerPeriod"`
+	TotalPerTag    map[string]int `json:"totalPerTag"` // Tag induk ikut menghitung prestasi bertag turunannya
+	TopStudents    []TopStudent   `json:"topStudents"`
+	Summary        StatsSummary   `json:"summary"`
+}
+
+type StatsSummary struct {
+	TotalAchievements int `json:"totalAchievements"`
+	TotalVerified     int `json:"totalVerified"`
+	TotalPending      int `json:"totalPending"`
+	TotalRejected     int `json:"totalRejected"`
+	TotalPoints       int `json:"totalPoints"`
+}
+
+type TopStudent struct {
+	Name        string `json:"name"`
+	Program     string `json:"programStudy"`
+	TotalPoints int    `json:"totalPoints"`
+}
+
+// notRevoked dipakai di setiap $match statistik: prestasi yang dicabut tidak dihitung
+var notRevoked = bson.D{{Key: "isRevoked", Value: bson.D{{Key: "$ne", Value: true}}}}
+
+// StatsFilter mengatur prestasi yang ikut dihitung di statistik & total poin
+type StatsFilter struct {
+	// Kecualikan prestasi yang sertifikatnya sudah kedaluwarsa pada Now
+	ExcludeExpired bool
+	Now            time.Time
+}
+
+// match menyusun $match statistik MongoDB dari kondisi tambahan
+func (f StatsFilter) match(conds ...bson.E) bson.D {
+	match := append(bson.D{}, conds...)
+	match = append(match, notRevoked[0])
+	if f.ExcludeExpired {
+		match = append(match, bson.E{Key: "details.validUntil", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$lte", Value: f.Now}}}}})
+	}
+	return match
+}
+
+// notExpired adalah kondisi SQL dengan placeholder $n (ExcludeExpired) dan
+// $n+1 (Now); prestasi tanpa masa berlaku selalu dihitung
+func (f StatsFilter) notExpired(column string, n int) string {
+	return fmt.Sprintf("NOT ($%d AND COALESCE(%s <= $%d, false))", n, column, n+1)
+}
+
+// ownedBy mencocokkan dokumen milik mahasiswa, termasuk prestasi tim tempat ia
+// menjadi anggota yang sudah konfirmasi
+func ownedBy(studentID string) bson.E {
+	return bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: "studentId", Value: studentID}},
+		bson.D{{Key: "teamMemberIds", Value: studentID}},
+	}}
+}
+
+// statusSummary menghitung prestasi per status untuk ringkasan statistik.
+// where memakai kolom achievement_references tanpa alias; TotalPoints diisi pemanggil.
+func (r *AchievementRepository) statusSummary(ctx context.Context, where string, args ...interface{}) (StatsSummary, error) {
+	var summary StatsSummary
+	counts := []struct {
+		status string
+		dest   *int
+	}{
+		{"status NOT IN ('deleted', 'revoked')", &summary.TotalAchievements},
+		{"status = 'verified'", &summary.TotalVerified},
+		{"status = 'submitted'", &summary.TotalPending},
+		{"status = 'rejected'", &summary.TotalRejected},
+	}
+	for _, c := range counts {
+		err := r.pgDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM achievement_references WHERE "+where+" AND "+c.status, args...).Scan(c.dest)
+		if err != nil {
+			return StatsSummary{}, err
+		}
+	}
+	return summary, nil
+}
+
+// GetStatistics generates overall stats
+func (r *AchievementRepository) GetStatistics(ctx context.Context, filter StatsFilter) (*StatsResult, error) {
+	result := &StatsResult{
+		TotalPerType:   make(map[string]int),
+		TotalPerLevel:  make(map[string]int),
+		TotalPerPeriod: make(map[string]int),
+		TotalPerTag:    make(map[string]int),
+		TopStudents:    []TopStudent{},
+		Summary:        StatsSummary{},
+	}
+
+	// 1. Total Per Type (Aggregation MongoDB)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match()}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$achievementType"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+	cursor, err := r.mongoColl.Aggregate(ctx, pipeline)
+	if err == nil {
+		var typeStats []struct {
+			ID    string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err = cursor.All(ctx, &typeStats); err == nil {
+			for _, s := range typeStats {
+				result.TotalPerType[s.ID] = s.Count
+			}
+		}
+	}
+
+	// 2. Total Per Level (Aggregation MongoDB)
+	pipelineLevel := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match(bson.E{Key: "achievementType", Value: "competition"})}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$details.competitionLevel"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+	cursorLevel, err := r.mongoColl.Aggregate(ctx, pipelineLevel)
+	if err == nil {
+		var levelStats []struct {
+			ID    string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err = cursorLevel.All(ctx, &levelStats); err == nil {
+			for _, s := range levelStats {
+				key := s.ID
+				if key == "" {
+					key = "unknown"
+				}
+				result.TotalPerLevel[key] = s.Count
+			}
+		}
+	}
+
+	// 3. Top Students (saldo points_ledger)
+	if board, err := r.GetLeaderboard(ctx, 5, "", filter); err == nil {
+		for _, b := range board {
+			result.TopStudents = append(result.TopStudents, TopStudent{
+				Name:        b.FullName,
+				Program:     b.ProgramStudy,
+				TotalPoints: b.TotalPoints,
+			})
+		}
+	}
+
+	// 4. Total Per Period (Last 6 months)
+	pipelinePeriod := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match(
+			bson.E{Key: "createdAt", Value: bson.D{
+				{Key: "$gte", Value: time.Now().AddDate(0, -6, 0)}, // Last 6 months
+			}},
+		)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "year", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
+				{Key: "month", Value: bson.D{{Key: "$month", Value: "$createdAt"}}},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursorPeriod, err := r.mongoColl.Aggregate(ctx, pipelinePeriod)
+	if err == nil {
+		var periodStats []struct {
+			ID struct {
+				Year  int `bson:"year"`
+				Month int `bson:"month"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		if err = cursorPeriod.All(ctx, &periodStats); err == nil {
+			for _, s := range periodStats {
+				key := fmt.Sprintf("%d-%02d", s.ID.Year, s.ID.Month)
+				result.TotalPerPeriod[key] = s.Count
+			}
+		}
+	}
+
+	// 5. Total Per Tag (PostgreSQL, roll-up ke tag induk)
+	tagTotals, err := r.tagCounts(ctx, filter.notExpired("ar.valid_until", 1), filter.ExcludeExpired, filter.Now)
+	if err != nil {
+		return nil, err
+	}
+	result.TotalPerTag = tagTotals
+
+	// 6. Summary Statistics (from PostgreSQL for accurate counts)
+	summary, err := r.statusSummary(ctx, filter.notExpired("valid_until", 1), filter.ExcludeExpired, filter.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Total points (saldo points_ledger)
+	if summary.TotalPoints, err = r.StudentPoints(ctx, "", filter); err != nil {
+		return nil, err
+	}
+	result.Summary = summary
+
+	return result, nil
+}
+
+// GetStudentStatistics (Personal Stats)
+func (r *AchievementRepository) GetStudentStatistics(ctx context.Context, studentID string, filter StatsFilter) (*StatsResult, error) {
+	result := &StatsResult{
+		TotalPerType:   make(map[string]int),
+		TotalPerLevel:  make(map[string]int),
+		TotalPerPeriod: make(map[string]int),
+		TotalPerTag:    make(map[string]int),
+		TopStudents:    []TopStudent{}, // Empty for individual stats
+		Summary:        StatsSummary{},
+	}
+
+	// 1. Total Per Type
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match(ownedBy(studentID))}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$achievementType"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.mongoColl.Aggregate(ctx, pipeline)
+	if err == nil {
+		var stats []struct {
+			ID    string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		cursor.All(ctx, &stats)
+		for _, s := range stats {
+			result.TotalPerType[s.ID] = s.Count
+		}
+	}
+
+	// 2. Total Per Level (Competition only)
+	pipelineLevel := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match(
+			ownedBy(studentID),
+			bson.E{Key: "achievementType", Value: "competition"},
+		)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$details.competitionLevel"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursorLevel, err := r.mongoColl.Aggregate(ctx, pipelineLevel)
+	if err == nil {
+		var levelStats []struct {
+			ID    string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err = cursorLevel.All(ctx, &levelStats); err == nil {
+			for _, s := range levelStats {
+				key := s.ID
+				if key == "" {
+					key = "unknown"
+				}
+				result.TotalPerLevel[key] = s.Count
+			}
+		}
+	}
+
+	// 3. Total Per Period (Last 12 months for individual)
+	pipelinePeriod := mongo.Pipeline{
+		{{Key: "$match", Value: filter.match(
+			ownedBy(studentID),
+			bson.E{Key: "createdAt", Value: bson.D{
+				{Key: "$gte", Value: time.Now().AddDate(-1, 0, 0)}, // Last 12 months
+			}},
+		)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "year", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
+				{Key: "month", Value: bson.D{{Key: "$month", Value: "$createdAt"}}},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursorPeriod, err := r.mongoColl.Aggregate(ctx, pipelinePeriod)
+	if err == nil {
+		var periodStats []struct {
+			ID struct {
+				Year  int `bson:"year"`
+				Month int `bson:"month"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		if err = cursorPeriod.All(ctx, &periodStats); err == nil {
+			for _, s := range periodStats {
+				key := fmt.Sprintf("%d-%02d", s.ID.Year, s.ID.Month)
+				result.TotalPerPeriod[key] = s.Count
+			}
+		}
+	}
+
+	// 4. Summary Statistics for this student
+	// Get counts from PostgreSQL (termasuk prestasi tim yang sudah dikonfirmasi)
+	owned := "(student_id = $1 OR id IN (SELECT achievement_ref_id FROM achievement_team_members WHERE student_id = $1 AND status = 'confirmed'))" +
+		" AND " + filter.notExpired("valid_until", 2)
+	summary, err := r.statusSummary(ctx, owned, studentID, filter.ExcludeExpired, filter.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Total points for this student (saldo points_ledger)
+	if summary.TotalPoints, err = r.StudentPoints(ctx, studentID, filter); err != nil {
+		return nil, err
+	}
+
+	// 5. Total Per Tag (roll-up ke tag induk)
+	ownedTagged := "(ar.student_id = $1 OR ar.id IN (SELECT achievement_ref_id FROM achievement_team_members WHERE student_id = $1 AND status = 'confirmed'))" +
+		" AND " + filter.notExpired("ar.valid_until", 2)
+	tagTotals, err := r.tagCounts(ctx, ownedTagged, studentID, filter.ExcludeExpired, filter.Now)
+	if err != nil {
+		return nil, err
+	}
+	result.TotalPerTag = tagTotals
+	result.Summary = summary
+
+	return result, nil
+}
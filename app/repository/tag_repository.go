@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// --- TAG TAXONOMY ---
+
+const tagColumns = `t.key, t.name, t.parent_key, t.description, t.is_active, t.created_at, t.updated_at,
+			COALESCE(ARRAY_AGG(syn.synonym ORDER BY syn.synonym) FILTER (WHERE syn.synonym IS NOT NULL), '{}')`
+
+// tagSubtreeSQL adalah daftar tag (sebagai text[]) yang cocok dengan filter tag
+// $n: tag kanonik dari $n (atau sinonimnya), seluruh turunannya, serta sinonim
+// dari semuanya. Tag di luar kosakata tetap cocok dengan dirinya sendiri.
+const tagSubtreeSQL = `ARRAY(
+			WITH RECURSIVE subtree AS (
+				SELECT key FROM tags WHERE key = COALESCE((SELECT tag_key FROM tag_synonyms WHERE synonym = $%[1]d::text), $%[1]d::text)
+				UNION
+				SELECT t.key FROM tags t JOIN subtree ON t.parent_key = subtree.key
+			)
+			SELECT key::text FROM subtree
+			UNION SELECT synonym::text FROM tag_synonyms WHERE tag_key IN (SELECT key FROM subtree)
+			UNION SELECT $%[1]d::text
+		)`
+
+// FindTags mengambil kosakata tag beserta sinonimnya (hanya yang aktif jika activeOnly)
+func (r *AchievementRepository) FindTags(ctx context.Context, activeOnly bool) ([]model.Tag, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags t
+		LEFT JOIN tag_synonyms syn ON syn.tag_key = t.key
+		WHERE ($1 = FALSE OR t.is_active = TRUE)
+		GROUP BY t.key
+		ORDER BY t.key ASC`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *t)
+	}
+	return tags, rows.Err()
+}
+
+// FindTag mengambil satu tag berdasarkan key (sql.ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindTag(ctx context.Context, key string) (*model.Tag, error) {
+	row := r.pgDB.QueryRowContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags t
+		LEFT JOIN tag_synonyms syn ON syn.tag_key = t.key
+		WHERE t.key = $1
+		GROUP BY t.key`, key)
+
+	return scanTag(row)
+}
+
+// CreateTag menyimpan tag baru beserta sinonimnya dalam satu transaksi
+func (r *AchievementRepository) CreateTag(ctx context.Context, t *model.Tag) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tags (key, name, parent_key, description, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`,
+		t.Key, t.Name, t.ParentKey, t.Description, t.IsActive,
+	).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	if err := insertTagSynonyms(ctx, tx, t.Key, t.Synonyms); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateTag mengganti nama, induk, deskripsi, status aktif, dan seluruh
+// sinonim tag. Prestasi yang sudah memakai sinonim lama tetap cocok saat
+// difilter karena sinonim di-resolve ulang saat query.
+func (r *AchievementRepository) UpdateTag(ctx context.Context, t *model.Tag) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE tags
+		SET name = $1, parent_key = $2, description = $3, is_active = $4, updated_at = NOW()
+		WHERE key = $5
+		RETURNING created_at, updated_at`,
+		t.Name, t.ParentKey, t.Description, t.IsActive, t.Key,
+	).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return translatePgError(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tag_synonyms WHERE tag_key = $1", t.Key); err != nil {
+		return err
+	}
+	if err := insertTagSynonyms(ctx, tx, t.Key, t.Synonyms); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertTagSynonyms(ctx context.Context, tx *sql.Tx, key string, synonyms []string) error {
+	if len(synonyms) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO tag_synonyms (synonym, tag_key) SELECT UNNEST($2::text[]), $1`,
+		key, pq.Array(synonyms),
+	)
+	return translatePgError(err)
+}
+
+// ResolveTagSynonyms mengembalikan pemetaan sinonim -> tag kanonik untuk
+// nama yang terdaftar sebagai sinonim (nama lain tidak ada di map)
+func (r *AchievementRepository) ResolveTagSynonyms(ctx context.Context, names []string) (map[string]string, error) {
+	resolved := make(map[string]string)
+	if len(names) == 0 {
+		return resolved, nil
+	}
+
+	rows, err := r.pgDB.QueryContext(ctx,
+		"SELECT synonym, tag_key FROM tag_synonyms WHERE synonym = ANY($1)", pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var synonym, key string
+		if err := rows.Scan(&synonym, &key); err != nil {
+			return nil, err
+		}
+		resolved[synonym] = key
+	}
+	return resolved, rows.Err()
+}
+
+// FindExistingTagKeys mengembalikan key di antara keys yang sudah terdaftar sebagai tag
+func (r *AchievementRepository) FindExistingTagKeys(ctx context.Context, keys []string) ([]string, error) {
+	existing := []string{}
+	if len(keys) == 0 {
+		return existing, nil
+	}
+
+	rows, err := r.pgDB.QueryContext(ctx, "SELECT key FROM tags WHERE key = ANY($1) ORDER BY key", pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		existing = append(existing, key)
+	}
+	return existing, rows.Err()
+}
+
+// IsTagDescendant mengecek apakah candidate adalah key itu sendiri atau
+// turunannya (dipakai agar induk baru tidak membentuk siklus)
+func (r *AchievementRepository) IsTagDescendant(ctx context.Context, key, candidate string) (bool, error) {
+	var found bool
+	err := r.pgDB.QueryRowContext(ctx, `
+		WITH RECURSIVE subtree AS (
+			SELECT key FROM tags WHERE key = $1
+			UNION
+			SELECT t.key FROM tags t JOIN subtree ON t.parent_key = subtree.key
+		)
+		SELECT EXISTS (SELECT 1 FROM subtree WHERE key = $2)`,
+		key, candidate,
+	).Scan(&found)
+	return found, err
+}
+
+// SuggestTags mencari tag aktif untuk autocomplete: prefix key atau sinonim,
+// atau bagian dari nama. Key yang sama persis didahulukan, lalu yang paling
+// sering dipakai.
+func (r *AchievementRepository) SuggestTags(ctx context.Context, query string, limit int) ([]model.TagSuggestion, error) {
+	prefix := model.NormalizeTag(query)
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT t.key, t.name, t.parent_key, COALESCE(MIN(syn.synonym), ''),
+			(SELECT COUNT(*) FROM achievement_references ar
+				WHERE t.key = ANY(ar.tags) AND ar.status NOT IN ('deleted', 'revoked')) AS usage
+		FROM tags t
+		LEFT JOIN tag_synonyms syn ON syn.tag_key = t.key AND $1::text <> '' AND syn.synonym LIKE $1::text || '%'
+		WHERE t.is_active = TRUE
+		  AND (($1 <> '' AND t.key LIKE $1 || '%') OR LOWER(t.name) LIKE $2 OR syn.synonym IS NOT NULL)
+		GROUP BY t.key
+		ORDER BY (t.key = $1) DESC, usage DESC, t.key ASC
+		LIMIT $3`,
+		prefix, "%"+strings.ToLower(strings.TrimSpace(query))+"%", limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.TagSuggestion{}
+	for rows.Next() {
+		var s model.TagSuggestion
+		var parentKey sql.NullString
+		if err := rows.Scan(&s.Key, &s.Name, &parentKey, &s.MatchedSynonym, &s.Usage); err != nil {
+			return nil, err
+		}
+		s.ParentKey = nullStringPtr(parentKey)
+		if s.MatchedSynonym != "" && strings.HasPrefix(s.Key, prefix) {
+			s.MatchedSynonym = ""
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// SyncTags menyalin tags (dinormalisasi) dari MongoDB ke Postgres untuk
+// reference yang belum sinkron (data sebelum kolom tags ada)
+func (r *AchievementRepository) SyncTags(ctx context.Context) (int, error) {
+	cursor, err := r.mongoColl.Find(ctx,
+		bson.M{"tags.0": bson.M{"$exists": true}, "isDeleted": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"tags": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	synced := 0
+	for cursor.Next(ctx) {
+		var doc model.Achievement
+		if err := cursor.Decode(&doc); err != nil {
+			return synced, err
+		}
+		res, err := r.pgDB.ExecContext(ctx,
+			`UPDATE achievement_references SET tags = $2
+			WHERE mongo_achievement_id = $1 AND tags = '{}'`,
+			doc.ID.Hex(), pq.Array(model.NormalizeTags(doc.Tags)),
+		)
+		if err != nil {
+			return synced, err
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			synced++
+		}
+	}
+	return synced, cursor.Err()
+}
+
+func scanTag(row interface{ Scan(...interface{}) error }) (*model.Tag, error) {
+	var t model.Tag
+	var parentKey sql.NullString
+	if err := row.Scan(&t.Key, &t.Name, &parentKey, &t.Description, &t.IsActive, &t.CreatedAt, &t.UpdatedAt, pq.Array(&t.Synonyms)); err != nil {
+		return nil, err
+	}
+	t.ParentKey = nullStringPtr(parentKey)
+	return &t, nil
+}
+
+// tagCounts menghitung prestasi (selain deleted & revoked) per tag kanonik.
+// Sinonim di-resolve saat query, dan setiap prestasi juga dihitung sekali
+// untuk setiap tag leluhurnya. where memakai alias ar.
+func (r *AchievementRepository) tagCounts(ctx context.Context, where string, args ...interface{}) (map[string]int, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		WITH RECURSIVE ancestors AS (
+			SELECT key, key AS ancestor FROM tags
+			UNION
+			SELECT a.key, t.parent_key FROM ancestors a JOIN tags t ON t.key = a.ancestor
+			WHERE t.parent_key IS NOT NULL
+		)
+		SELECT COALESCE(a.ancestor, canonical.tag), COUNT(DISTINCT ar.id)
+		FROM achievement_references ar
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(syn.tag_key, raw.tag) AS tag
+			FROM UNNEST(ar.tags) AS raw(tag)
+			LEFT JOIN tag_synonyms syn ON syn.synonym = raw.tag
+		) canonical
+		LEFT JOIN ancestors a ON a.key = canonical.tag
+		WHERE ar.status NOT IN ('deleted', 'revoked') AND `+where+`
+		GROUP BY 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, err
+		}
+		counts[tag] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// --- TEAM ACHIEVEMENTS ---
+
+// FindTeam mengambil anggota tim (ketua lebih dulu) beserta NIM, nama, dan dosen wali
+func (r *AchievementRepository) FindTeam(ctx context.Context, refID string) ([]model.TeamMember, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT tm.student_id, tm.role, tm.status, tm.responded_at, tm.advisor_approved_by, tm.advisor_approved_at,
+			s.student_id, s.user_id, s.advisor_id, u.full_name
+		FROM achievement_team_members tm
+		JOIN students s ON tm.student_id = s.id
+		JOIN users u ON s.user_id = u.id
+		WHERE tm.achievement_ref_id = $1
+		ORDER BY (tm.role = 'leader') DESC, tm.created_at ASC`, refID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	team := []model.TeamMember{}
+	for rows.Next() {
+		m := model.TeamMember{AchievementRefID: refID, Student: &model.Student{User: &model.User{}}}
+		var respondedAt, approvedAt sql.NullTime
+		var approvedBy, advisorID sql.NullString
+		if err := rows.Scan(
+			&m.StudentID, &m.Role, &m.Status, &respondedAt, &approvedBy, &approvedAt,
+			&m.Student.StudentID, &m.Student.UserID, &advisorID, &m.Student.User.FullName,
+		); err != nil {
+			return nil, err
+		}
+		m.Student.ID = m.StudentID
+		m.Student.User.ID = m.Student.UserID
+		m.Student.AdvisorID = nullStringPtr(advisorID)
+		m.AdvisorApprovedBy = nullStringPtr(approvedBy)
+		if respondedAt.Valid {
+			m.RespondedAt = &respondedAt.Time
+		}
+		if approvedAt.Valid {
+			m.AdvisorApprovedAt = &approvedAt.Time
+		}
+		team = append(team, m)
+	}
+	return team, rows.Err()
+}
+
+// ReplaceTeam mengganti daftar anggota tim. Anggota yang sudah ada tetap dengan
+// statusnya, anggota baru menunggu konfirmasi (pending). Daftar kosong
+// mengubah prestasi kembali menjadi prestasi individu.
+func (r *AchievementRepository) ReplaceTeam(ctx context.Context, refID string, leaderID string, memberIDs []string) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM achievement_team_members
+		WHERE achievement_ref_id = $1 AND role = 'member' AND NOT (student_id = ANY($2))`,
+		refID, pq.Array(memberIDs),
+	); err != nil {
+		return err
+	}
+
+	if len(memberIDs) == 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM achievement_team_members WHERE achievement_ref_id = $1`, refID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO achievement_team_members (achievement_ref_id, student_id, role, status, responded_at)
+			VALUES ($1, $2, 'leader', 'confirmed', NOW())
+			ON CONFLICT (achievement_ref_id, student_id) DO NOTHING`, refID, leaderID,
+		); err != nil {
+			return translatePgError(err)
+		}
+		for _, id := range memberIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO achievement_team_members (achievement_ref_id, student_id, role, status)
+				VALUES ($1, $2, 'member', 'pending')
+				ON CONFLICT (achievement_ref_id, student_id) DO NOTHING`, refID, id,
+			); err != nil {
+				return translatePgError(err)
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE achievement_references SET is_team = $2, updated_at = NOW(), version = version + 1 WHERE id = $1`,
+		refID, len(memberIDs) > 0,
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return r.syncMongoTeam(ctx, refID)
+}
+
+// RespondTeamInvite menyimpan konfirmasi/penolakan anggota (sql.ErrNoRows jika
+// mahasiswa bukan anggota tim)
+func (r *AchievementRepository) RespondTeamInvite(ctx context.Context, refID string, studentID string, status string, at time.Time) error {
+	res, err := r.pgDB.ExecContext(ctx, `
+		UPDATE achievement_team_members
+		SET status = $3, responded_at = $4
+		WHERE achievement_ref_id = $1 AND student_id = $2 AND role = 'member'`,
+		refID, studentID, status, at,
+	)
+	if err != nil {
+		return translatePgError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return r.syncMongoTeam(ctx, refID)
+}
+
+// ApproveTeamMembers mencatat persetujuan dosen wali untuk anggota tim yang
+// diberikan (kebijakan per_member) dan mengembalikan jumlah anggota yang masih
+// menunggu persetujuan
+func (r *AchievementRepository) ApproveTeamMembers(ctx context.Context, refID string, studentIDs []string, actorID string, at time.Time) (int, error) {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE achievement_team_members
+		SET advisor_approved_by = $3, advisor_approved_at = $4
+		WHERE achievement_ref_id = $1 AND student_id = ANY($2)
+		  AND status = 'confirmed' AND advisor_approved_at IS NULL`,
+		refID, pq.Array(studentIDs), actorID, at,
+	); err != nil {
+		return 0, err
+	}
+
+	var remaining int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM achievement_team_members
+		WHERE achievement_ref_id = $1 AND status = 'confirmed' AND advisor_approved_at IS NULL`,
+		refID,
+	).Scan(&remaining)
+	if err != nil {
+		return 0, err
+	}
+	return remaining, tx.Commit()
+}
+
+// resetTeamApprovals menyimpan kebijakan verifikasi tim saat submit dan
+// menghapus persetujuan dosen wali dari putaran sebelumnya
+func resetTeamApprovals(ctx context.Context, tx *sql.Tx, refID string, policy string) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE achievement_references SET team_verification = $2 WHERE id = $1`, refID, policy,
+	); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE achievement_team_members
+		SET advisor_approved_by = NULL, advisor_approved_at = NULL
+		WHERE achievement_ref_id = $1`, refID,
+	)
+	return err
+}
+
+// syncMongoTeam menyalin anggota yang sudah konfirmasi ke dokumen Mongo agar
+// statistik mahasiswa ikut menghitung prestasi tim
+func (r *AchievementRepository) syncMongoTeam(ctx context.Context, refID string) error {
+	var mongoID string
+	var memberIDs []string
+	err := r.pgDB.QueryRowContext(ctx, `
+		SELECT ar.mongo_achievement_id,
+			ARRAY(SELECT tm.student_id::text FROM achievement_team_members tm
+				WHERE tm.achievement_ref_id = ar.id AND tm.role = 'member' AND tm.status = 'confirmed'
+				ORDER BY tm.created_at)
+		FROM achievement_references ar WHERE ar.id = $1`, refID,
+	).Scan(&mongoID, pq.Array(&memberIDs))
+	if err != nil {
+		return err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(mongoID)
+	if err != nil {
+		return nil
+	}
+	if _, err := r.mongoColl.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"teamMemberIds": memberIDs}}); err != nil {
+		return errors.New("failed to update team members in mongo: " + err.Error())
+	}
+	return nil
+}
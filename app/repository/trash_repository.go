@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// --- TRASH (RESTORE & PURGE) ---
+
+// FindTrash mengembalikan draft milik mahasiswa yang sudah dihapus tetapi
+// masih bisa dipulihkan (terbaru dulu)
+func (r *AchievementRepository) FindTrash(ctx context.Context, studentID string) ([]model.AchievementReference, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT id, student_id, mongo_achievement_id, title, status, created_at, updated_at, deleted_at
+		FROM achievement_references
+		WHERE student_id = $1 AND status = 'deleted' AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []model.AchievementReference{}
+	for rows.Next() {
+		ref, err := scanTrashRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, *ref)
+	}
+	return refs, rows.Err()
+}
+
+// FindDeleted memuat prestasi yang ada di tempat sampah (ErrNoRows jika tidak ada)
+func (r *AchievementRepository) FindDeleted(ctx context.Context, id string) (*model.AchievementReference, error) {
+	return scanTrashRow(r.pgDB.QueryRowContext(ctx, `
+		SELECT id, student_id, mongo_achievement_id, title, status, created_at, updated_at, deleted_at
+		FROM achievement_references
+		WHERE id = $1 AND status = 'deleted'`, id))
+}
+
+// FindExpiredTrash mengembalikan ID prestasi yang dihapus sebelum cutoff
+func (r *AchievementRepository) FindExpiredTrash(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := r.pgDB.QueryContext(ctx, `
+		SELECT id FROM achievement_references
+		WHERE status = 'deleted' AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2`, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanTrashRow(row interface{ Scan(...any) error }) (*model.AchievementReference, error) {
+	var ref model.AchievementReference
+	var deletedAt sql.NullTime
+	if err := row.Scan(&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Title, &ref.Status,
+		&ref.CreatedAt, &ref.UpdatedAt, &deletedAt); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		purgeAt := deletedAt.Time.Add(model.TrashRetention)
+		ref.DeletedAt, ref.PurgeAt = &deletedAt.Time, &purgeAt
+	}
+	return &ref, nil
+}
+
+// Restore mengembalikan prestasi dari tempat sampah menjadi draft
+func (r *AchievementRepository) Restore(ctx context.Context, id string, actorID string) error {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var mongoID, status string
+	err = tx.QueryRowContext(ctx, "SELECT mongo_achievement_id, status FROM achievement_references WHERE id = $1 FOR UPDATE", id).Scan(&mongoID, &status)
+	if err != nil {
+		return err
+	}
+	if status != model.StatusDeleted || !model.CanTransition(status, model.StatusDraft) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, status, model.StatusDraft)
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE achievement_references SET status = $1, updated_at = $2, deleted_at = NULL, version = version + 1 WHERE id = $3",
+		model.StatusDraft, now, id,
+	); err != nil {
+		return errors.New("failed to restore reference: " + err.Error())
+	}
+	if err := insertStatusHistory(ctx, tx, id, status, model.StatusDraft, actorID, "", 0, now); err != nil {
+		return errors.New("failed to record history: " + err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if objID, err := primitive.ObjectIDFromHex(mongoID); err == nil {
+		_, err = r.mongoColl.UpdateOne(ctx,
+			bson.M{"_id": objID},
+			bson.M{
+				"$set":   bson.M{"updatedAt": now},
+				"$unset": bson.M{"isDeleted": "", "deletedAt": ""},
+			},
+		)
+		if err != nil {
+			return errors.New("failed to restore achievement: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Purge menghapus permanen prestasi di tempat sampah: baris Postgres beserta
+// tabel turunannya, lalu dokumen MongoDB. Mengembalikan URL lampiran yang
+// tidak lagi dipakai dokumen lain sehingga filenya aman dihapus.
+func (r *AchievementRepository) Purge(ctx context.Context, id string) ([]string, error) {
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var mongoID, status string
+	err = tx.QueryRowContext(ctx, "SELECT mongo_achievement_id, status FROM achievement_references WHERE id = $1 FOR UPDATE", id).Scan(&mongoID, &status)
+	if err != nil {
+		return nil, err
+	}
+	if status != model.StatusDeleted {
+		return nil, fmt.Errorf("%w: only deleted achievements can be purged (status %s)", ErrInvalidTransition, status)
+	}
+
+	// Riwayat status, tahap verifikasi & versi konten ikut terhapus lewat ON DELETE CASCADE
+	purges := []string{
+		"DELETE FROM achievement_duplicate_flags WHERE achievement_ref_id = $1 OR matched_ref_id = $1",
+		"DELETE FROM achievement_attachment_hashes WHERE achievement_ref_id = $1",
+		"DELETE FROM achievement_fingerprints WHERE achievement_ref_id = $1",
+		"DELETE FROM achievement_team_members WHERE achievement_ref_id = $1",
+		"DELETE FROM achievement_comments WHERE achievement_ref_id = $1",
+		"DELETE FROM achievement_references WHERE id = $1",
+	}
+	for _, q := range purges {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(mongoID)
+	if err != nil {
+		return nil, nil
+	}
+	var doc model.Achievement
+	if err := r.mongoColl.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	orphaned := []string{}
+	for _, a := range doc.Attachments {
+		n, err := r.mongoColl.CountDocuments(ctx, bson.M{"attachments.fileUrl": a.FileURL})
+		if err != nil {
+			return orphaned, err
+		}
+		if n == 0 {
+			orphaned = append(orphaned, a.FileURL)
+		}
+	}
+	return orphaned, nil
+}
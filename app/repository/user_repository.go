@@ -3,8 +3,11 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/lib/pq"
 )
 
 type UserRepository struct {
@@ -44,7 +47,7 @@ func (r *UserRepository) Create(user *model.User) error {
 		user.UpdatedAt,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
-	return err
+	return translatePgError(err)
 }
 
 // Cari User by Email (Login) - JOIN Role agar tahu dia Admin/Mhs
@@ -80,7 +83,7 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 func (r *UserRepository) FindByID(id string) (*model.User, error) {
 	query := `
 		SELECT 
-			u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at,
+			u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at, u.version,
 			r.id, r.name, r.description
 		FROM users u
 		JOIN roles r ON u.role_id = r.id
@@ -90,7 +93,7 @@ func (r *UserRepository) FindByID(id string) (*model.User, error) {
 	user.Role = &model.Role{}
 
 	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FullName, &user.RoleID, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FullName, &user.RoleID, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Version,
 		&user.Role.ID, &user.Role.Name, &user.Role.Description,
 	)
 
@@ -108,7 +111,7 @@ func (r *UserRepository) FindByID(id string) (*model.User, error) {
 func (r *UserRepository) FindAll() ([]model.User, error) {
 	query := `
 		SELECT 
-			u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at,
+			u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at, u.version,
 			r.id, r.name, r.description
 		FROM users u
 		JOIN roles r ON u.role_id = r.id
@@ -126,7 +129,7 @@ func (r *UserRepository) FindAll() ([]model.User, error) {
 		user.Role = &model.Role{} // Init pointer role
 
 		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FullName, &user.RoleID, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+			&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.FullName, &user.RoleID, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Version,
 			&user.Role.ID, &user.Role.Name, &user.Role.Description,
 		)
 		if err != nil {
@@ -233,14 +236,24 @@ func (r *UserRepository) FindLecturerByUserID(userID string) (*model.Lecturer, e
 }
 
 // UPDATE USER (General Info)
+// Compare-and-set: jika user.Version diisi, update hanya mengenai baris dengan
+// version yang sama (ErrVersionMismatch jika sudah diubah request lain).
+// Version di struct dinaikkan setelah update berhasil.
 func (r *UserRepository) Update(user *model.User) error {
 	query := `
 		UPDATE users 
-		SET full_name = $1, username = $2, email = $3, is_active = $4, updated_at = $5
-		WHERE id = $6`
+		SET full_name = $1, username = $2, email = $3, is_active = $4, updated_at = $5, version = version + 1
+		WHERE id = $6 AND ($7::int = 0 OR version = $7)`
 	
-	_, err := r.db.Exec(query, user.FullName, user.Username, user.Email, user.IsActive, time.Now(), user.ID)
-	return err
+	res, err := r.db.Exec(query, user.FullName, user.Username, user.Email, user.IsActive, time.Now(), user.ID, user.Version)
+	if err != nil {
+		return translatePgError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrVersionMismatch
+	}
+	user.Version++
+	return nil
 }
 
 // DELETE USER
@@ -260,9 +273,20 @@ func (r *UserRepository) Delete(id string) error {
 }
 
 // ASSIGN ROLE (Update Role ID)
-func (r *UserRepository) UpdateRole(userID, roleID string) error {
-	_, err := r.db.Exec("UPDATE users SET role_id = $1, updated_at = $2 WHERE id = $3", roleID, time.Now(), userID)
-	return err
+// Sama seperti Update, hanya berhasil jika version masih sama dengan yang dibaca
+// (ErrVersionMismatch jika sudah diubah request lain).
+func (r *UserRepository) UpdateRole(userID, roleID string, version int) error {
+	res, err := r.db.Exec(
+		"UPDATE users SET role_id = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND ($4::int = 0 OR version = $4)",
+		roleID, time.Now(), userID, version,
+	)
+	if err != nil {
+		return translatePgError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
 }
 
 // --- AKADEMIK PROFIL (Student & Lecturer) ---
@@ -278,12 +302,12 @@ func (r *UserRepository) SaveStudent(s *model.Student) error {
 		// Update
 		query := `UPDATE students SET student_id = $1, program_study = $2, academic_year = $3 WHERE user_id = $4`
 		_, err := r.db.Exec(query, s.StudentID, s.ProgramStudy, s.AcademicYear, s.UserID)
-		return err
+		return translatePgError(err)
 	} else {
 		// Insert
 		query := `INSERT INTO students (user_id, student_id, program_study, academic_year) VALUES ($1, $2, $3, $4)`
 		_, err := r.db.Exec(query, s.UserID, s.StudentID, s.ProgramStudy, s.AcademicYear)
-		return err
+		return translatePgError(err)
 	}
 }
 
@@ -295,20 +319,240 @@ func (r *UserRepository) SaveLecturer(l *model.Lecturer) error {
 	if exists {
 		query := `UPDATE lecturers SET lecturer_id = $1, department = $2 WHERE user_id = $3`
 		_, err := r.db.Exec(query, l.LecturerID, l.Department, l.UserID)
-		return err
+		return translatePgError(err)
 	} else {
 		query := `INSERT INTO lecturers (user_id, lecturer_id, department) VALUES ($1, $2, $3)`
 		_, err := r.db.Exec(query, l.UserID, l.LecturerID, l.Department)
-		return err
+		return translatePgError(err)
 	}
 }
 
+var (
+	ErrStudentNotFound  = errors.New("student profile not found")
+	ErrAdvisorUnchanged = errors.New("student is already assigned to this advisor")
+)
+
+// HandoverPolicyRequiredError dikembalikan AssignAdvisor jika kebijakan serah
+// terima tidak diisi padahal ada prestasi yang menunggu verifikasi
+type HandoverPolicyRequiredError struct {
+	Pending int64
+}
+
+func (e *HandoverPolicyRequiredError) Error() string {
+	return fmt.Sprintf("handover policy is required: %d submitted achievements pending verification", e.Pending)
+}
+
 // ASSIGN ADVISOR (Set Dosen Wali untuk Mahasiswa)
-func (r *UserRepository) AssignAdvisor(studentID string, advisorID string) error {
+// Dijalankan dalam satu transaksi: tutup riwayat lama, buka riwayat baru,
+// update students.advisor_id, lalu terapkan kebijakan serah terima prestasi 'submitted'.
+// Policy kosong hanya boleh jika tidak ada prestasi yang menunggu verifikasi
+// (dihitung di transaksi yang sama, lalu dianggap transfer).
+func (r *UserRepository) AssignAdvisor(studentID string, advisorID string, assignedBy string, policy string) (*model.AdvisorReassignment, error) {
 	// studentID disini adalah UUID primary key tabel students (bukan user_id)
-	query := `UPDATE students SET advisor_id = $1 WHERE id = $2`
-	_, err := r.db.Exec(query, advisorID, studentID)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Ambil dosen wali saat ini (lock baris agar tidak balapan dengan admin lain)
+	var currentAdvisor sql.NullString
+	err = tx.QueryRow("SELECT advisor_id FROM students WHERE id = $1 FOR UPDATE", studentID).Scan(&currentAdvisor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	result := &model.AdvisorReassignment{
+		StudentID:      studentID,
+		NewAdvisorID:   advisorID,
+		HandoverPolicy: policy,
+	}
+	if currentAdvisor.Valid {
+		prev := currentAdvisor.String
+		result.PreviousAdvisorID = &prev
+	}
+
+	if currentAdvisor.Valid && currentAdvisor.String == advisorID {
+		return nil, ErrAdvisorUnchanged
+	}
+
+	if policy == "" {
+		// Kunci prestasi yang menunggu verifikasi agar hitungan tetap berlaku sampai commit.
+		// Prestasi yang ditahan dosen wali sebelumnya tidak ikut diserahterimakan.
+		var pending int64
+		err = tx.QueryRow(
+			`SELECT COUNT(*) FROM (
+				SELECT id FROM achievement_references
+				WHERE student_id = $1 AND status = 'submitted'
+					AND (assigned_verifier_id IS NULL OR assigned_verifier_id = $2)
+				FOR UPDATE
+			) pending`,
+			studentID, currentAdvisor,
+		).Scan(&pending)
+		if err != nil {
+			return nil, err
+		}
+		if pending > 0 {
+			return nil, &HandoverPolicyRequiredError{Pending: pending}
+		}
+		policy = model.HandoverTransfer
+		result.HandoverPolicy = policy
+	}
+
+	now := time.Now()
+
+	// 2. Tutup penugasan yang masih aktif
+	_, err = tx.Exec(
+		"UPDATE advisor_assignments SET effective_to = $1 WHERE student_id = $2 AND effective_to IS NULL",
+		now, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Catat penugasan baru
+	var assigner interface{} = nil
+	if assignedBy != "" {
+		assigner = assignedBy
+	}
+	_, err = tx.Exec(
+		`INSERT INTO advisor_assignments (student_id, lecturer_id, effective_from, assigned_by, handover_policy, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		studentID, advisorID, now, assigner, policy, now,
+	)
+	if err != nil {
+		return nil, translatePgError(err)
+	}
+
+	// 4. Update dosen wali aktif
+	if _, err = tx.Exec("UPDATE students SET advisor_id = $1 WHERE id = $2", advisorID, studentID); err != nil {
+		return nil, err
+	}
+
+	// 5. Serah terima prestasi yang sedang menunggu verifikasi
+	var res sql.Result
+	if policy == model.HandoverKeep && currentAdvisor.Valid {
+		// Tahan di dosen wali lama (kecuali yang sudah ditahan sebelumnya)
+		res, err = tx.Exec(
+			`UPDATE achievement_references SET assigned_verifier_id = $1, updated_at = $2
+			WHERE student_id = $3 AND status = 'submitted' AND assigned_verifier_id IS NULL`,
+			currentAdvisor.String, now, studentID,
+		)
+	} else {
+		// Pindahkan ke dosen wali baru; yang ditahan dosen wali sebelumnya tetap di sana
+		res, err = tx.Exec(
+			`UPDATE achievement_references SET assigned_verifier_id = NULL, updated_at = $1
+			WHERE student_id = $2 AND status = 'submitted'
+				AND (assigned_verifier_id IS NULL OR assigned_verifier_id = $3)`,
+			now, studentID, currentAdvisor,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.PendingAffected, _ = res.RowsAffected()
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindAdvisorHistory mengambil riwayat dosen wali seorang mahasiswa (urut dari yang terlama).
+// Mengembalikan ErrStudentNotFound jika mahasiswa tidak terdaftar.
+func (r *UserRepository) FindAdvisorHistory(studentID string) ([]model.AdvisorAssignment, error) {
+	query := `
+		SELECT 
+			aa.id, aa.student_id, aa.lecturer_id, aa.effective_from, aa.effective_to,
+			aa.assigned_by, aa.handover_policy, aa.created_at,
+			l.lecturer_id, l.department, u.full_name
+		FROM advisor_assignments aa
+		JOIN lecturers l ON aa.lecturer_id = l.id
+		JOIN users u ON l.user_id = u.id
+		WHERE aa.student_id = $1
+		ORDER BY aa.effective_from ASC`
+
+	rows, err := r.db.Query(query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.AdvisorAssignment{}
+	for rows.Next() {
+		var a model.AdvisorAssignment
+		a.Lecturer = &model.Lecturer{User: &model.User{}}
+
+		var effTo sql.NullTime
+		var assignedBy sql.NullString
+
+		err := rows.Scan(
+			&a.ID, &a.StudentID, &a.LecturerID, &a.EffectiveFrom, &effTo,
+			&assignedBy, &a.HandoverPolicy, &a.CreatedAt,
+			&a.Lecturer.LecturerID, &a.Lecturer.Department, &a.Lecturer.User.FullName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Lecturer.ID = a.LecturerID
+		if effTo.Valid {
+			a.EffectiveTo = &effTo.Time
+		}
+		if assignedBy.Valid {
+			str := assignedBy.String
+			a.AssignedBy = &str
+		}
+		history = append(history, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Riwayat kosong: bedakan mahasiswa tanpa dosen wali dari ID yang tidak ada
+	if len(history) == 0 {
+		var exists bool
+		if err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM students WHERE id = $1)", studentID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrStudentNotFound
+		}
+	}
+
+	return history, nil
+}
+
+// FindLecturerByID mencari dosen berdasarkan ID tabel lecturers (bukan user_id)
+func (r *UserRepository) FindLecturerByID(id string) (*model.Lecturer, error) {
+	query := `
+		SELECT 
+			l.id, l.user_id, l.lecturer_id, l.department, l.created_at,
+			u.id, u.username, u.full_name, u.email
+		FROM lecturers l
+		JOIN users u ON l.user_id = u.id
+		WHERE l.id = $1`
+
+	var l model.Lecturer
+	l.User = &model.User{}
+
+	err := r.db.QueryRow(query, id).Scan(
+		&l.ID, &l.UserID, &l.LecturerID, &l.Department, &l.CreatedAt,
+		&l.User.ID, &l.User.Username, &l.User.FullName, &l.User.Email,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("lecturer profile not found")
+		}
+		return nil, err
+	}
+
+	return &l, nil
 }
 
 // FindAllStudents mengambil list mahasiswa untuk admin
@@ -332,6 +576,39 @@ func (r *UserRepository) FindAllStudents() ([]model.Student, error) {
 	return students, nil
 }
 
+// FindStudentsByNIM mengambil mahasiswa berdasarkan NIM (untuk anggota prestasi tim).
+// NIM yang tidak terdaftar tidak ikut dikembalikan.
+func (r *UserRepository) FindStudentsByNIM(nims []string) ([]model.Student, error) {
+	query := `
+		SELECT s.id, s.user_id, s.student_id, s.program_study, s.academic_year, s.advisor_id, u.full_name
+		FROM students s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.student_id = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(nims))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := []model.Student{}
+	for rows.Next() {
+		var s model.Student
+		var advisorID sql.NullString
+		s.User = &model.User{}
+		if err := rows.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisorID, &s.User.FullName); err != nil {
+			return nil, err
+		}
+		if advisorID.Valid {
+			idStr := advisorID.String
+			s.AdvisorID = &idStr
+		}
+		s.User.ID = s.UserID
+		students = append(students, s)
+	}
+	return students, rows.Err()
+}
+
 // FindAllLecturers mengambil list dosen
 func (r *UserRepository) FindAllLecturers() ([]model.Lecturer, error) {
 	query := `
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// insertStatusHistory menambah satu baris riwayat transisi (dipanggil di dalam transaksi)
+func insertStatusHistory(ctx context.Context, tx *sql.Tx, refID, fromStatus, toStatus, actorID, note string, points int, at time.Time) error {
+	var actor, pts interface{}
+	if actorID != "" {
+		actor = actorID
+	}
+	if points != 0 {
+		pts = points
+	}
+	// Versi konten saat transisi diambil dari reference (NULL untuk data lama)
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO achievement_status_history (achievement_ref_id, from_status, to_status, actor_id, note, points, created_at, content_version)
+		SELECT $1, $2, $3, $4, $5, $6, $7, NULLIF(ar.content_version, 0) FROM achievement_references ar WHERE ar.id = $1`,
+		refID, fromStatus, toStatus, actor, note, pts, at,
+	)
+	return err
+}
+
+// FindHistory mengambil riwayat status prestasi (urut kronologis) beserta nama aktor
+func (r *AchievementRepository) FindHistory(ctx context.Context, refID string) ([]model.AchievementStatusHistory, error) {
+	query := `
+		SELECT 
+			h.id, h.achievement_ref_id, h.from_status, h.to_status, h.actor_id, h.note, h.points, h.created_at,
+			u.full_name, r.name, h.content_version
+		FROM achievement_status_history h
+		LEFT JOIN users u ON h.actor_id = u.id
+		LEFT JOIN roles r ON u.role_id = r.id
+		WHERE h.achievement_ref_id = $1
+		ORDER BY h.created_at ASC, h.id ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, refID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.AchievementStatusHistory{}
+	for rows.Next() {
+		var h model.AchievementStatusHistory
+		var fromStatus, actorID, actorName, actorRole sql.NullString
+		var points, contentVersion sql.NullInt64
+
+		err := rows.Scan(
+			&h.ID, &h.AchievementRefID, &fromStatus, &h.ToStatus, &actorID, &h.Note, &points, &h.CreatedAt,
+			&actorName, &actorRole, &contentVersion,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if fromStatus.Valid {
+			str := fromStatus.String
+			h.FromStatus = &str
+		}
+		if actorID.Valid {
+			str := actorID.String
+			h.ActorID = &str
+			h.Actor = &model.User{ID: str, FullName: actorName.String}
+			if actorRole.Valid {
+				h.Actor.Role = &model.Role{Name: actorRole.String}
+			}
+		}
+		if points.Valid {
+			p := int(points.Int64)
+			h.Points = &p
+		}
+		h.ContentVersion = nullIntPtr(contentVersion)
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
+
+// ErrInvalidTransition dikembalikan jika perpindahan status tidak ada di model.AchievementActions
+var ErrInvalidTransition = errors.New("illegal achievement status transition")
+
+// ErrStatusConflict dikembalikan jika status/version sudah diubah request lain
+// sejak dibaca (mis. dua dosen memutuskan prestasi yang sama bersamaan)
+var ErrStatusConflict = errors.New("achievement status was changed by another request")
+
+// --- UPDATE STATUS (VERIFIKASI DOSEN & UPDATE POIN) ---
+// Compare-and-set: UPDATE hanya mengenai baris jika status (dan version, bila
+// diisi) masih sesuai change.ExpectedStatus/ExpectedVersion, sehingga dari dua
+// request yang balapan hanya satu yang menang dan poin tidak diberikan dua kali.
+// Kolom verified_by/verified_at hanya diisi untuk keputusan (verified/rejected);
+// jejak lengkap ada di achievement_status_history. Kembali ke draft
+// (withdraw/revisi) melepas verifikator yang ditahan, sehingga pengajuan ulang
+// diarahkan ke dosen wali aktif.
+//
+// Verifikasi bertahap: submit membuka putaran baru dan menyalin change.Pipeline
+// ke achievement_verification_stages; keputusan (change.Stage) hanya berlaku
+// jika tahap tersebut memang yang sedang berjalan.
+//
+// Pencabutan (revoked) menyimpan change.Note sebagai alasan, mempertahankan
+// jejak verifikasi, dan membatalkan seluruh poin prestasi di ledger.
+func (r *AchievementRepository) UpdateStatus(ctx context.Context, change model.StatusChange) error {
+	// Tolak transisi yang tidak ada di graf status
+	if !model.CanTransition(change.ExpectedStatus, change.Status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, change.ExpectedStatus, change.Status)
+	}
+
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	mongoID, err := updateStatusTx(ctx, tx, change, now)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return r.syncMongoStatus(ctx, change, mongoID, now)
+}
+
+// updateStatusTx menjalankan bagian PostgreSQL dari UpdateStatus di dalam tx.
+// Mengembalikan mongo_achievement_id untuk sinkronisasi setelah commit.
+func updateStatusTx(ctx context.Context, tx *sql.Tx, change model.StatusChange, now time.Time) (string, error) {
+	// Tahap pertama pipeline menjadi tahap aktif saat submit
+	var firstStage interface{} = nil
+	if change.Status == model.StatusSubmitted && len(change.Pipeline) > 0 {
+		firstStage = change.Pipeline[0].Key
+	}
+
+	// 1. Update di PostgreSQL (Status, VerifiedBy, RejectionNote) bersyarat status, version & tahap.
+	// submitted_at dicatat saat submit: backfill tahap pipeline dan SLA memakainya
+	// sebagai waktu mulai tahap untuk pengajuan yang belum punya baris tahap.
+	query := `
+		UPDATE achievement_references 
+		SET status = $1, updated_at = $2, version = version + 1,
+			verified_by = CASE WHEN $1 IN ('verified', 'rejected') THEN $3::uuid WHEN $1 = 'revoked' THEN verified_by ELSE NULL END,
+			verified_at = CASE WHEN $1 IN ('verified', 'rejected') THEN $2 WHEN $1 = 'revoked' THEN verified_at ELSE NULL END,
+			rejection_note = CASE WHEN $1 = 'revoked' THEN rejection_note ELSE $4 END,
+			revoked_by = CASE WHEN $1 = 'revoked' THEN $3::uuid END,
+			revoked_at = CASE WHEN $1 = 'revoked' THEN $2 END,
+			revocation_reason = CASE WHEN $1 = 'revoked' THEN $4 END,
+			submitted_at = CASE WHEN $1 = 'submitted' THEN $2 ELSE submitted_at END,
+			assigned_verifier_id = CASE WHEN $1 = 'draft' THEN NULL ELSE assigned_verifier_id END,
+			verification_round = CASE WHEN $1 = 'submitted' THEN verification_round + 1 ELSE verification_round END,
+			current_stage = $8
+		WHERE id = $5 AND status = $6 AND ($7::int = 0 OR version = $7)
+		  AND ($9 = '' OR current_stage = $9)
+		RETURNING mongo_achievement_id, verification_round`
+
+	var actor interface{} = nil
+	if change.ActorID != "" {
+		actor = change.ActorID
+	}
+
+	var mongoID string
+	var round int
+	err := tx.QueryRowContext(ctx, query,
+		change.Status, now, actor, change.Note, change.ID, change.ExpectedStatus, change.ExpectedVersion,
+		firstStage, change.Stage,
+	).Scan(&mongoID, &round)
+	if err == sql.ErrNoRows {
+		// Bedakan "tidak ada" dengan "sudah diubah request lain"
+		var current string
+		var version int
+		if err := tx.QueryRowContext(ctx, "SELECT status, version FROM achievement_references WHERE id = $1", change.ID).Scan(&current, &version); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: expected %s (version %d), found %s (version %d)",
+			ErrStatusConflict, change.ExpectedStatus, change.ExpectedVersion, current, version)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 2. Tahap verifikasi: buka putaran baru, putuskan tahap aktif, atau batalkan sisa tahap
+	switch {
+	case change.Status == model.StatusSubmitted:
+		if err := insertVerificationStages(ctx, tx, change.ID, round, change.Pipeline, now); err != nil {
+			return "", err
+		}
+	case change.Stage != "":
+		decision := model.StageApproved
+		if change.Status == model.StatusRejected {
+			decision = model.StageRejected
+		}
+		if err := decideStage(ctx, tx, change, round, decision, now); err != nil {
+			return "", err
+		}
+	case change.ExpectedStatus == model.StatusSubmitted:
+		// Ditarik sebelum diputuskan
+		_, err := tx.ExecContext(ctx,
+			`UPDATE achievement_verification_stages SET status = 'cancelled'
+			WHERE achievement_ref_id = $1 AND round = $2 AND status = 'pending'`,
+			change.ID, round,
+		)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Hasil rubrik: saran saat submit, final (beserta alasan override) saat verify
+	if change.Calculation != nil {
+		var reason interface{} = nil
+		if change.OverrideReason != "" {
+			reason = change.OverrideReason
+		}
+		_, err := tx.ExecContext(ctx,
+			`UPDATE achievement_references SET suggested_points = $1, rubric_version = $2, points_override_reason = $3
+			WHERE id = $4`,
+			change.Calculation.Points, change.Calculation.RubricVersion, reason, change.ID,
+		)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Prestasi tim: simpan kebijakan verifikasi & ulangi persetujuan dosen wali anggota
+	if change.Status == model.StatusSubmitted && change.TeamVerification != "" {
+		if err := resetTeamApprovals(ctx, tx, change.ID, change.TeamVerification); err != nil {
+			return "", err
+		}
+	}
+
+	// Poin final dicatat sebagai award di ledger (satu per prestasi, atau satu
+	// per anggota untuk prestasi tim)
+	if change.Status == model.StatusVerified {
+		awards := change.Awards
+		if len(awards) == 0 {
+			awards = []model.MemberPoints{{Points: change.Points}}
+		}
+		for _, a := range awards {
+			award := model.PointsLedgerEntry{
+				StudentID:        a.StudentID,
+				AchievementRefID: change.ID,
+				EntryType:        model.LedgerAward,
+				Points:           a.Points,
+				Reason:           change.OverrideReason,
+				CreatedAt:        now,
+			}
+			if change.ActorID != "" {
+				award.ActorID = &change.ActorID
+			}
+			if change.Calculation != nil {
+				award.RubricVersion = &change.Calculation.RubricVersion
+			}
+			if err := insertLedgerEntry(ctx, tx, &award); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Pencabutan membatalkan semua entri ledger yang masih berlaku
+	if change.Status == model.StatusRevoked {
+		if err := reverseAllLedgerEntries(ctx, tx, change.ID, actor, change.Note, now); err != nil {
+			return "", err
+		}
+	}
+
+	// 3. Catat riwayat transisi
+	if err := insertStatusHistory(ctx, tx, change.ID, change.ExpectedStatus, change.Status, change.ActorID, change.Note, change.Points, now); err != nil {
+		return "", err
+	}
+
+	return mongoID, nil
+}
+
+// syncMongoStatus memperbarui dokumen MongoDB setelah transisi di-commit
+// (hanya jika Verified, poin ditampilkan di dokumen; saldo resmi di ledger)
+func (r *AchievementRepository) syncMongoStatus(ctx context.Context, change model.StatusChange, mongoID string, now time.Time) error {
+	if change.Status == model.StatusVerified && mongoID != "" {
+		return r.setMongoPoints(ctx, mongoID, change.Points)
+	}
+	if change.Status == model.StatusRevoked && mongoID != "" {
+		return r.markMongoRevoked(ctx, mongoID, now)
+	}
+	return nil
+}
+
+// ApplyStatusChanges menjalankan beberapa keputusan dalam satu transaksi
+// (semua berhasil atau tidak ada yang disimpan). Change dengan NextStage terisi
+// menyetujui tahap lalu lanjut ke tahap itu; selainnya seperti UpdateStatus.
+// Kegagalan dikembalikan sebagai *BatchError berisi indeks change penyebabnya.
+func (r *AchievementRepository) ApplyStatusChanges(ctx context.Context, changes []model.StatusChange) error {
+	for i, change := range changes {
+		if change.NextStage == "" && !model.CanTransition(change.ExpectedStatus, change.Status) {
+			return &BatchError{Index: i, Err: fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, change.ExpectedStatus, change.Status)}
+		}
+	}
+
+	tx, err := r.pgDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	mongoIDs := make([]string, len(changes))
+	for i, change := range changes {
+		if change.NextStage != "" {
+			err = advanceStageTx(ctx, tx, change, now)
+		} else {
+			mongoIDs[i], err = updateStatusTx(ctx, tx, change, now)
+		}
+		if err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i, change := range changes {
+		if change.NextStage != "" {
+			continue
+		}
+		if err := r.syncMongoStatus(ctx, change, mongoIDs[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
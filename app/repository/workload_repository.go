@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// --- LECTURER WORKLOAD (Roster Mahasiswa Bimbingan) ---
+
+// advisorQueueSQL adalah definisi antrian dosen wali ($1 = ID dosen): prestasi
+// 'submitted' pada tahap dosen wali yang ditahan untuk dosen ini atau milik
+// mahasiswa bimbingannya tanpa penahanan. Dipakai roster dan workload agar
+// angkanya tidak saling bertentangan.
+const advisorQueueSQL = `ar.status = 'submitted' AND ar.current_stage = 'advisor'
+		  AND COALESCE(ar.assigned_verifier_id, s.advisor_id) = $1`
+
+type AdviseeSummary struct {
+	StudentID          string `json:"studentId"` // ID tabel students
+	NIM                string `json:"nim"`
+	FullName           string `json:"fullName"`
+	ProgramStudy       string `json:"programStudy"`
+	AcademicYear       string `json:"academicYear"`
+	PendingSubmissions int    `json:"pendingSubmissions"` // Bagian antrian dosen ini (lihat advisorQueueSQL)
+	VerifiedCount      int    `json:"verifiedCount"`
+	TotalPoints        int    `json:"totalPoints"`
+}
+
+type LecturerWorkload struct {
+	TotalAdvisees int `json:"totalAdvisees"`
+	// Jumlah prestasi 'submitted' yang menjadi tanggung jawab dosen ini
+	OpenQueueSize int `json:"openQueueSize"`
+	// Jumlah keputusan (verify/reject) yang sudah dibuat dosen ini
+	TotalDecisions int `json:"totalDecisions"`
+	// Median waktu dari submit sampai keputusan, dalam jam. nil jika belum ada keputusan
+	MedianHoursToDecision *float64 `json:"medianHoursToDecision"`
+}
+
+// GetAdviseeRoster mengambil daftar mahasiswa bimbingan beserta ringkasan prestasinya
+func (r *AchievementRepository) GetAdviseeRoster(ctx context.Context, lecturerID string) ([]AdviseeSummary, error) {
+	query := `
+		SELECT 
+			s.id, s.student_id, u.full_name, s.program_study, s.academic_year,
+			COUNT(ar.id) FILTER (WHERE ` + advisorQueueSQL + `),
+			COUNT(ar.id) FILTER (WHERE ar.status = 'verified')
+		FROM students s
+		JOIN users u ON s.user_id = u.id
+		LEFT JOIN achievement_references ar ON ar.student_id = s.id AND ar.status NOT IN ('deleted', 'revoked')
+		WHERE s.advisor_id = $1
+		GROUP BY s.id, s.student_id, u.full_name, s.program_study, s.academic_year
+		ORDER BY u.full_name ASC`
+
+	rows, err := r.pgDB.QueryContext(ctx, query, lecturerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roster := []AdviseeSummary{}
+	index := make(map[string]int)
+	var studentIDs []string
+
+	for rows.Next() {
+		var a AdviseeSummary
+		err := rows.Scan(
+			&a.StudentID, &a.NIM, &a.FullName, &a.ProgramStudy, &a.AcademicYear,
+			&a.PendingSubmissions, &a.VerifiedCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		index[a.StudentID] = len(roster)
+		studentIDs = append(studentIDs, a.StudentID)
+		roster = append(roster, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(studentIDs) == 0 {
+		return roster, nil
+	}
+
+	// Total poin per mahasiswa (saldo points_ledger)
+	pointRows, err := r.pgDB.QueryContext(ctx, `
+		SELECT l.student_id, SUM(l.points)
+		FROM points_ledger l
+		JOIN students s ON l.student_id = s.id
+		WHERE s.advisor_id = $1
+		GROUP BY l.student_id`, lecturerID)
+	if err != nil {
+		return nil, err
+	}
+	defer pointRows.Close()
+	for pointRows.Next() {
+		var studentID string
+		var total int
+		if err := pointRows.Scan(&studentID, &total); err != nil {
+			return nil, err
+		}
+		if i, ok := index[studentID]; ok {
+			roster[i].TotalPoints = total
+		}
+	}
+	if err := pointRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roster, nil
+}
+
+// GetLecturerWorkload menghitung antrian verifikasi dan kecepatan keputusan dosen
+func (r *AchievementRepository) GetLecturerWorkload(ctx context.Context, lecturerID string, lecturerUserID string) (*LecturerWorkload, error) {
+	w := &LecturerWorkload{}
+
+	err := r.pgDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM students WHERE advisor_id = $1", lecturerID).Scan(&w.TotalAdvisees)
+	if err != nil {
+		return nil, err
+	}
+
+	// Antrian terbuka: prestasi 'submitted' pada tahap dosen wali, milik bimbingan
+	// aktif atau yang ditahan untuk dosen ini
+	queueQuery := `
+		SELECT COUNT(*)
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		WHERE ` + advisorQueueSQL
+	if err := r.pgDB.QueryRowContext(ctx, queueQuery, lecturerID).Scan(&w.OpenQueueSize); err != nil {
+		return nil, err
+	}
+
+	// Median waktu keputusan: dari tahap mulai menunggu sampai diputuskan dosen ini
+	medianQuery := `
+		SELECT 
+			COUNT(*),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM (decided_at - started_at)))
+		FROM achievement_verification_stages
+		WHERE decided_by = $1
+		  AND status IN ('approved', 'rejected')
+		  AND started_at IS NOT NULL AND decided_at IS NOT NULL`
+
+	var medianSeconds sql.NullFloat64
+	if err := r.pgDB.QueryRowContext(ctx, medianQuery, lecturerUserID).Scan(&w.TotalDecisions, &medianSeconds); err != nil {
+		return nil, err
+	}
+	if medianSeconds.Valid {
+		hours := medianSeconds.Float64 / 3600
+		w.MedianHoursToDecision = &hours
+	}
+
+	return w, nil
+}
@@ -1,11 +1,16 @@
 package service
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
 	"github.com/WedhaWS/uasgosmt5/app/repository"
+	"github.com/WedhaWS/uasgosmt5/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -32,8 +37,21 @@ func NewAchievementService(achRepo *repository.AchievementRepository, userRepo *
 func (s *AchievementService) Submit(c *fiber.Ctx) error {
 	// 1. Parse Input
 	var req model.Achievement
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: " + err.Error()})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Validasi details terhadap schema tipe prestasi (registry achievement_types)
+	if errs, err := s.validateDetails(c.Context(), &req, ""); err != nil {
+		return respondError(c, err, "Failed to submit achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	// Tag dinormalisasi ke kosakata (sinonim -> tag kanonik)
+	if errs, err := s.normalizeTags(c.Context(), &req); err != nil {
+		return respondError(c, err, "Failed to submit achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
 	// 2. Ambil User ID dari Token (Middleware)
@@ -56,21 +74,33 @@ func (s *AchievementService) Submit(c *fiber.Ctx) error {
 		Details:         req.Details,     // Field dinamis (Juara, Lomba, dll)
 		Attachments:     req.Attachments, // File bukti
 		Tags:            req.Tags,
-		Points:          req.Points, // [UPDATED] Mengambil poin dari input user (sebelumnya 0)
+		Points:          0, // Poin final diberikan saat verifikasi, bukan dari input mahasiswa
 	}
 
 	// 4. Siapkan Data PostgreSQL (Referensi Status)
 	pgData := model.AchievementReference{
-		StudentID: student.ID,
-		Title:     req.Title, // Disimpan juga di SQL untuk searching/sorting
-		Status:    "draft",   // Status Awal sesuai FR-003
+		StudentID:  student.ID,
+		Title:      req.Title, // Disimpan juga di SQL untuk searching/sorting
+		Status:     "draft",   // Status Awal sesuai FR-003
+		ValidUntil: mongoData.Details.ValidUntil,
+	}
+
+	// Poin saran dari rubrik aktif
+	calc, err := s.suggestPoints(c.Context(), &mongoData)
+	if err != nil {
+		return respondError(c, err, "Failed to submit achievement")
 	}
+	applySuggestion(&pgData, calc)
 
 	// 5. Simpan ke Database (Hybrid Transaction di Repository)
 	if err := s.achRepo.Create(c.Context(), &mongoData, &pgData); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to submit achievement: " + err.Error()})
+		return respondError(c, err, "Failed to submit achievement")
 	}
 
+	// Tandai kemungkinan duplikat untuk verifikator (tidak memblokir)
+	pgData.Student = student
+	s.checkDuplicates(c.Context(), &pgData, &mongoData)
+
 	// 6. Return Success Response
 	return c.Status(201).JSON(model.WebResponse{
 		Code:    201,
@@ -79,8 +109,9 @@ func (s *AchievementService) Submit(c *fiber.Ctx) error {
 		Data: fiber.Map{
 			"referenceId": pgData.ID,                 // ID dari Postgres
 			"mongoId":     pgData.MongoAchievementID, // ID dari Mongo
-			"status":      pgData.Status,
-			"points":      mongoData.Points, // Tampilkan poin yang tersimpan
+			"status":          pgData.Status,
+			"suggestedPoints": pgData.SuggestedPoints, // Poin saran dari rubrik (final saat verifikasi)
+			"rubricVersion":   pgData.RubricVersion,
 		},
 	})
 }
@@ -92,6 +123,7 @@ func (s *AchievementService) GetAll(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
 	var filterStudent, filterAdvisor string
+	actor := achievementActor{UserID: userID, Role: userRole, Permissions: localPermissions(c)}
 
 	// Logic Filter Berdasarkan Role (RBAC Data Level)
 	if userRole == "Mahasiswa" {
@@ -99,20 +131,29 @@ func (s *AchievementService) GetAll(c *fiber.Ctx) error {
 		student, _ := s.userRepo.FindStudentByUserID(userID)
 		if student != nil {
 			filterStudent = student.ID
+			actor.StudentID = student.ID
 		}
 	} else if userRole == "Dosen Wali" {
 		// Dosen Wali HANYA boleh melihat prestasi mahasiswa bimbingannya (FR-006)
 		lecturer, _ := s.userRepo.FindLecturerByUserID(userID)
 		if lecturer != nil {
 			filterAdvisor = lecturer.ID
+			actor.LecturerID = lecturer.ID
 		}
 	}
 	// Admin melihat semua (filter kosong)
 
 	data, total, err := s.achRepo.FindAll(param, filterStudent, filterAdvisor)
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to retrieve achievements")
+	}
+
+	for i := range data {
+		data[i].AvailableActions = availableActions(&data[i], actor)
+		hideDuplicateFlags(&data[i], userRole)
 	}
+	// Indikator kelengkapan bukti untuk draft & rejected
+	s.attachListEvidence(c.Context(), data)
 
 	return s.sendPaginationResponse(c, data, total, param)
 }
@@ -128,7 +169,13 @@ func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
 	}
-	return c.JSON(model.WebResponse{
+	actor := s.resolveActor(c)
+	ref.AvailableActions = availableActions(ref, actor)
+	hideDuplicateFlags(ref, actor.Role)
+	s.attachEvidence(c.Context(), ref, content)
+
+	// ETag dihitung dari body yang dirender agar 304 tidak menyajikan data usang
+	body, err := json.Marshal(model.WebResponse{
 		Code:    200,
 		Status:  "success",
 		Message: "Detail retrieved",
@@ -137,6 +184,15 @@ func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
 			"content": content,
 		},
 	})
+	if err != nil {
+		return respondError(c, err, "Failed to render detail")
+	}
+	if notModifiedTag(c, contentETag(ref.Version, body)) {
+		return c.SendStatus(304)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
 }
 
 // POST /api/v1/achievements/:id/submit (FR-004: Submit untuk Verifikasi)
@@ -150,25 +206,54 @@ func (s *AchievementService) RequestVerification(c *fiber.Ctx) error {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student profile not found"})
 	}
 
-	// 2. Cek Existensi Achievement
-	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
-	if err != nil {
+	// 2-4. Cek existensi, kepemilikan & status lewat state machine (draft -> submitted)
+	ref, content, actor, errResp := s.authorizeAction(c, id, ActionSubmit)
+	if ref == nil {
+		return errResp
+	}
+	if content == nil {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
 	}
-
-	// 3. Validasi Kepemilikan (Harus milik mahasiswa yang login)
-	if ref.StudentID != student.ID {
-		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You do not own this achievement"})
+	// Prestasi tim: semua anggota harus sudah merespons undangan
+	if errs := checkTeamReady(ref); len(errs) > 0 {
+		return validationFailed(c, errs)
 	}
 
-	// 4. Validasi Status (Hanya 'draft' yang bisa disubmit)
-	if ref.Status != "draft" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only draft achievement can be submitted for verification"})
+	// Bukti wajib tipe prestasi (mis. sertifikat PDF & foto, DOI) harus lengkap,
+	// kecuali prestasi dibuat sebelum bukti wajib tipenya ditetapkan
+	achType, err := s.achRepo.FindAchievementType(c.Context(), content.AchievementType)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return respondError(c, err, "Failed to load achievement type")
+	}
+	if achType != nil {
+		if evidence := model.EvaluateEvidence(achType.EvidenceFor(ref.CreatedAt), content); !evidence.Complete {
+			return evidenceMissing(c, evidence)
+		}
 	}
 
-	// 5. Update Status menjadi 'submitted'
-	if err := s.achRepo.UpdateStatus(id, "submitted", "", "", 0); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update status: " + err.Error()})
+	// 5. Update Status menjadi 'submitted' dan buka tahap verifikasi sesuai tipe & tingkat
+	change, err := newStatusChange(actor, ref, ActionSubmit, "", 0)
+	if err != nil {
+		return respondError(c, err, "Failed to update status")
+	}
+	if ref.IsTeam {
+		// Kebijakan verifikasi tim dibekukan saat diajukan
+		change.TeamVerification = model.TeamVerificationLeader
+		if achType != nil {
+			change.TeamVerification = teamVerificationOrDefault(achType.TeamVerification)
+		}
+	}
+	change.Pipeline, err = s.achRepo.FindPipeline(c.Context(), content.AchievementType, content.Details.CompetitionLevel)
+	if err != nil {
+		return respondError(c, err, "Failed to load verification pipeline")
+	}
+	// Hitung ulang poin saran dengan rubrik yang berlaku saat diajukan
+	change.Calculation, err = s.suggestPoints(c.Context(), content)
+	if err != nil {
+		return respondError(c, err, "Failed to calculate points")
+	}
+	if err := s.achRepo.UpdateStatus(c.Context(), change); err != nil {
+		return respondError(c, err, "Failed to update status")
 	}
 
 	// 6. Create Notification untuk Dosen Wali
@@ -196,8 +281,10 @@ func (s *AchievementService) RequestVerification(c *fiber.Ctx) error {
 		Status:  "success",
 		Message: "Prestasi berhasil diajukan untuk verifikasi",
 		Data: fiber.Map{
-			"id":     id,
-			"status": "submitted",
+			"id":          id,
+			"status":      model.StatusSubmitted,
+			"pipeline":    change.Pipeline,
+			"calculation": change.Calculation,
 		},
 	})
 }
@@ -205,55 +292,91 @@ func (s *AchievementService) RequestVerification(c *fiber.Ctx) error {
 // POST /api/v1/achievements/:id/verify (FR-007: Verify Prestasi)
 func (s *AchievementService) Verify(c *fiber.Ctx) error {
 	id := c.Params("id")
+	// Points kosong = pakai hasil rubrik; poin lain wajib disertai justification
 	var req struct {
-		Points int `json:"points"`
-	}
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: " + err.Error()})
+		Points        int    `json:"points" validate:"gte=0"`
+		Justification string `json:"justification" validate:"max=1000"`
 	}
 
-	// Validate points (must be positive)
-	if req.Points <= 0 {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Points must be greater than 0"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	userID := c.Locals("user_id").(string)
 
-	// 1. Pastikan user adalah Dosen Wali
-	lecturer, err := s.userRepo.FindLecturerByUserID(userID)
+	// 1. Pastikan user terdaftar (pemutus tahap bisa dosen wali atau Kemahasiswaan)
+	verifier, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Lecturer profile not found"})
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
 	}
 
-	// 2. Dosen review prestasi detail - Get achievement info
-	ref, content, err := s.achRepo.FindDetail(c.Context(), id)
-	if err != nil {
+	// 2-4. Get achievement info, lalu validasi status ('submitted') dan pemutus tahap aktif via state machine
+	ref, content, actor, errResp := s.authorizeAction(c, id, ActionVerify)
+	if ref == nil {
+		return errResp
+	}
+	if content == nil {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
 	}
 
-	// 3. Validasi precondition: Status harus 'submitted'
-	if ref.Status != "submitted" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only submitted achievements can be verified"})
+	// Poin final dari rubrik aktif; override dosen wajib beralasan
+	calc, err := s.suggestPoints(c.Context(), content)
+	if err != nil {
+		return respondError(c, err, "Failed to calculate points")
 	}
+	points, overrideReason, errs := resolveAwardedPoints(calc, req.Points, req.Justification)
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Prestasi tim (per_member): tahap dosen wali menunggu persetujuan dosen wali setiap anggota
+	if perMemberApproval(ref) {
+		if done, err := s.approveTeamMembers(c, ref, actor, verifier); !done {
+			return err
+		}
+	}
+
+	// 5a. Bukan tahap terakhir: setujui tahap ini, lanjut ke tahap berikutnya (poin belum dihitung)
+	if next := nextStage(ref); next != nil {
+		change, err := newStatusChange(actor, ref, ActionVerify, overrideReason, points)
+		if err != nil {
+			return respondError(c, err, "Failed to approve stage")
+		}
+		if err := s.achRepo.AdvanceStage(c.Context(), change, next.Key); err != nil {
+			return respondError(c, err, "Failed to approve stage")
+		}
 
-	// 4. Validasi ownership: Hanya dosen wali yang bisa verify prestasi mahasiswa bimbingannya
-	if ref.Student.AdvisorID == nil || *ref.Student.AdvisorID != lecturer.ID {
-		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You can only verify achievements of your advisees"})
+		notifyStageApproved(ref, next, verifier)
+
+		return c.JSON(model.WebResponse{
+			Code:    200,
+			Status:  "success",
+			Message: "Tahap " + ref.CurrentStage.Name + " disetujui, menunggu persetujuan " + next.Name,
+			Data: fiber.Map{
+				"id":            id,
+				"status":        model.StatusSubmitted,
+				"approvedStage": ref.CurrentStage.Key,
+				"currentStage":  next.Key,
+				// Versi konten yang disetujui pada tahap ini
+				"contentVersion": ref.ContentVersion,
+			},
+		})
 	}
 
-	// 5. Update status menjadi 'verified' dengan verified_by dan verified_at
-	if err := s.achRepo.UpdateStatus(id, "verified", userID, "", req.Points); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to verify achievement: " + err.Error()})
+	// 5b. Tahap terakhir: update status menjadi 'verified' dengan verified_by dan verified_at
+	change, err := newStatusChange(actor, ref, ActionVerify, overrideReason, points)
+	if err != nil {
+		return respondError(c, err, "Failed to verify achievement")
+	}
+	change.Calculation = calc
+	change.OverrideReason = overrideReason
+	change.Awards = teamAwards(ref, calc, points)
+	if err := s.achRepo.UpdateStatus(c.Context(), change); err != nil {
+		return respondError(c, err, "Failed to verify achievement")
 	}
 
 	// 6. Log verification
-	fmt.Printf("[VERIFICATION] Achievement verified:\n")
-	fmt.Printf("  - Student: %s (%s)\n", ref.Student.User.FullName, ref.Student.StudentID)
-	fmt.Printf("  - Achievement: %s\n", ref.Title)
-	fmt.Printf("  - Points awarded: %d\n", req.Points)
-	fmt.Printf("  - Verified by: %s\n", lecturer.User.FullName)
-	fmt.Printf("  - Time: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	notifyVerified(ref, points, verifier)
 
 	// 7. Return updated status
 	return c.JSON(model.WebResponse{
@@ -261,11 +384,15 @@ func (s *AchievementService) Verify(c *fiber.Ctx) error {
 		Status:  "success",
 		Message: "Prestasi berhasil diverifikasi",
 		Data: fiber.Map{
-			"id":         id,
-			"status":     "verified",
-			"points":     req.Points,
-			"verifiedBy": userID,
-			"verifiedAt": time.Now(),
+			"id":             id,
+			"status":         model.StatusVerified,
+			"points":         points,
+			"calculation":    calc,
+			"overrideReason": overrideReason,
+			"awards":         change.Awards,
+			"verifiedBy":     userID,
+			"verifiedAt":     time.Now(),
+			"contentVersion": ref.ContentVersion,
 			"achievement": fiber.Map{
 				"title":     ref.Title,
 				"type":      content.AchievementType,
@@ -280,59 +407,34 @@ func (s *AchievementService) Verify(c *fiber.Ctx) error {
 func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var req struct {
-		Note string `json:"note"`
-	}
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: " + err.Error()})
+		Note string `json:"note" validate:"required,max=1000"`
 	}
 
-	// Validate rejection note (required and not empty)
-	if req.Note == "" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Rejection note is required"})
-	}
-
-	// Validate note length (reasonable limit)
-	if len(req.Note) > 1000 {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Rejection note too long (max 1000 characters)"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	userID := c.Locals("user_id").(string)
 
-	// 1. Pastikan user adalah Dosen Wali
-	lecturer, err := s.userRepo.FindLecturerByUserID(userID)
+	// 1. Pastikan user terdaftar (pemutus tahap bisa dosen wali atau Kemahasiswaan)
+	verifier, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Lecturer profile not found"})
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
 	}
 
-	// 2. Get achievement info untuk validasi
-	ref, content, err := s.achRepo.FindDetail(c.Context(), id)
-	if err != nil {
-		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
-	}
-
-	// 3. Validasi precondition: Status harus 'submitted'
-	if ref.Status != "submitted" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only submitted achievements can be rejected"})
-	}
-
-	// 4. Validasi ownership: Hanya dosen wali yang bisa reject prestasi mahasiswa bimbingannya
-	if ref.Student.AdvisorID == nil || *ref.Student.AdvisorID != lecturer.ID {
-		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You can only reject achievements of your advisees"})
+	// 2-4. Get achievement info, lalu validasi status ('submitted') dan pemutus tahap aktif via state machine
+	ref, content, actor, errResp := s.authorizeAction(c, id, ActionReject)
+	if ref == nil {
+		return errResp
 	}
 
 	// 5. Update status menjadi 'rejected' dengan rejection_note
-	if err := s.achRepo.UpdateStatus(id, "rejected", userID, req.Note, 0); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to reject achievement: " + err.Error()})
+	if err := s.transition(c, actor, ref, ActionReject, req.Note, 0); err != nil {
+		return respondError(c, err, "Failed to reject achievement")
 	}
 
 	// 6. Create notification untuk mahasiswa
-	fmt.Printf("[REJECTION] Achievement rejected:\n")
-	fmt.Printf("  - Student: %s (%s)\n", ref.Student.User.FullName, ref.Student.StudentID)
-	fmt.Printf("  - Achievement: %s\n", ref.Title)
-	fmt.Printf("  - Rejection reason: %s\n", req.Note)
-	fmt.Printf("  - Rejected by: %s\n", lecturer.User.FullName)
-	fmt.Printf("  - Time: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	notifyRejected(ref, req.Note, verifier)
 
 	// TODO: Implement actual notification service
 	// - Email notification ke mahasiswa
@@ -346,10 +448,12 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 		Message: "Prestasi berhasil ditolak",
 		Data: fiber.Map{
 			"id":            id,
-			"status":        "rejected",
+			"status":        model.StatusRejected,
 			"rejectionNote": req.Note,
 			"rejectedBy":    userID,
 			"rejectedAt":    time.Now(),
+			// Versi yang ditolak: bandingkan dengan pengajuan ulang lewat /versions/diff
+			"contentVersion": ref.ContentVersion,
 			"achievement": fiber.Map{
 				"title":     ref.Title,
 				"type":      content.AchievementType,
@@ -360,6 +464,68 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	})
 }
 
+// POST /api/v1/achievements/:id/revoke (Cabut Prestasi Terverifikasi - Admin)
+// Dipakai jika bukti ternyata tidak sah (mis. sertifikat palsu). Prestasi tetap
+// bisa dilihat dengan keterangan pencabutan; poinnya dibatalkan di ledger.
+func (s *AchievementService) Revoke(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		Reason string `json:"reason" validate:"required,max=1000"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, _, actor, errResp := s.authorizeAction(c, id, ActionRevoke)
+	if ref == nil {
+		return errResp
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return validationFailed(c, []model.FieldError{{Field: "reason", Rule: "required", Message: "reason is required"}})
+	}
+
+	if err := s.transition(c, actor, ref, ActionRevoke, reason, 0); err != nil {
+		return respondError(c, err, "Failed to revoke achievement")
+	}
+
+	// Notifikasi ke mahasiswa dan dosen walinya
+	notify("REVOKED",
+		"To: "+ref.Student.User.FullName+" ("+ref.Student.StudentID+")",
+		"Achievement: "+ref.Title,
+		"Reason: "+reason,
+	)
+	if ref.Student.AdvisorID != nil {
+		advisor := *ref.Student.AdvisorID
+		if lecturer, err := s.userRepo.FindLecturerByID(advisor); err == nil && lecturer.User != nil {
+			advisor = lecturer.User.FullName
+		}
+		notify("REVOKED",
+			"To: "+advisor+" (dosen wali)",
+			"Student: "+ref.Student.User.FullName+" ("+ref.Student.StudentID+")",
+			"Achievement: "+ref.Title,
+			"Reason: "+reason,
+		)
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Prestasi berhasil dicabut",
+		Data: fiber.Map{
+			"id":     id,
+			"status": model.StatusRevoked,
+			"revocation": model.Revocation{
+				RevokedAt: time.Now(),
+				RevokedBy: &actor.UserID,
+				Reason:    reason,
+			},
+		},
+	})
+}
+
 // DELETE /api/v1/achievements/:id (FR-005: Soft Delete Draft)
 func (s *AchievementService) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -371,25 +537,15 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student profile not found"})
 	}
 
-	// 2. Cek existensi dan kepemilikan achievement
-	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
-	if err != nil {
-		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
-	}
-
-	// 3. Validasi kepemilikan
-	if ref.StudentID != student.ID {
-		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You do not own this achievement"})
-	}
-
-	// 4. Validasi status (hanya draft yang bisa dihapus)
-	if ref.Status != "draft" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only draft achievements can be deleted"})
+	// 2-4. Cek existensi, kepemilikan & status (hanya draft yang bisa dihapus)
+	ref, _, _, errResp := s.authorizeAction(c, id, ActionDelete)
+	if ref == nil {
+		return errResp
 	}
 
 	// 5. Soft delete achievement
-	if err := s.achRepo.Delete(c.Context(), id); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete achievement: " + err.Error()})
+	if err := s.achRepo.Delete(c.Context(), id, userID); err != nil {
+		return respondError(c, err, "Failed to delete achievement")
 	}
 
 	// 6. Log deletion
@@ -404,24 +560,280 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 		Message: "Prestasi berhasil dihapus",
 		Data: fiber.Map{
 			"id":     id,
-			"status": "deleted",
+			"status": model.StatusDeleted,
 		},
 	})
 }
 
-// ... (Method stub/placeholder lain seperti Update, GetHistory, UploadAttachment biarkan saja seperti sebelumnya) ...
+// POST /api/v1/achievements/:id/withdraw (Tarik Pengajuan: submitted -> draft)
+func (s *AchievementService) Withdraw(c *fiber.Ctx) error {
+	id := c.Params("id")
 
+	ref, _, actor, errResp := s.authorizeAction(c, id, ActionWithdraw)
+	if ref == nil {
+		return errResp
+	}
+
+	if err := s.transition(c, actor, ref, ActionWithdraw, "", 0); err != nil {
+		return respondError(c, err, "Failed to withdraw achievement")
+	}
+
+	// Beri tahu dosen yang sedang memegang antrian verifikasinya
+	verifierID := ref.AssignedVerifierID
+	if verifierID == nil && ref.Student != nil {
+		verifierID = ref.Student.AdvisorID
+	}
+	if verifierID != nil {
+		notify("WITHDRAWN",
+			"Student: "+ref.Student.User.FullName+" ("+ref.Student.StudentID+")",
+			"Achievement: "+ref.Title,
+			"Lecturer ID: "+*verifierID,
+		)
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Pengajuan prestasi berhasil ditarik",
+		Data: fiber.Map{
+			"id":               id,
+			"status":           model.StatusDraft,
+			"availableActions": availableActions(&model.AchievementReference{StudentID: ref.StudentID, Status: model.StatusDraft}, actor),
+		},
+	})
+}
+
+// POST /api/v1/achievements/:id/revise (Revisi Setelah Ditolak: rejected -> draft)
+func (s *AchievementService) Revise(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ref, _, actor, errResp := s.authorizeAction(c, id, ActionRevise)
+	if ref == nil {
+		return errResp
+	}
+
+	// Catatan penolakan tetap tersimpan di riwayat status
+	if err := s.transition(c, actor, ref, ActionRevise, "", 0); err != nil {
+		return respondError(c, err, "Failed to reopen achievement for revision")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Prestasi dibuka kembali untuk revisi",
+		Data: fiber.Map{
+			"id":               id,
+			"status":           model.StatusDraft,
+			"previousNote":     ref.RejectionNote,
+			"availableActions": availableActions(&model.AchievementReference{StudentID: ref.StudentID, Status: model.StatusDraft}, actor),
+		},
+	})
+}
+
+// PUT /api/v1/achievements/:id (Edit Draft - Ganti seluruh konten)
 func (s *AchievementService) Update(c *fiber.Ctx) error {
-	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "Update Feature Not Implemented"})
+	id := c.Params("id")
+
+	var req model.Achievement
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, current, errResp := s.loadEditableAchievement(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	if errs, err := s.validateDetails(c.Context(), &req, current.AchievementType); err != nil {
+		return respondError(c, err, "Failed to update achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	if errs, err := s.normalizeTags(c.Context(), &req); err != nil {
+		return respondError(c, err, "Failed to update achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Field yang dikelola sistem tidak boleh diganti lewat PUT
+	updated := *current
+	updated.AchievementType = req.AchievementType
+	updated.Title = req.Title
+	updated.Description = req.Description
+	updated.Details = req.Details
+	updated.Tags = req.Tags
+
+	return s.saveAchievementContent(c, ref, current, &updated)
+}
+
+// PATCH /api/v1/achievements/:id (Edit Draft - Merge Patch RFC 7396)
+// Hanya field yang dikirim yang berubah; object bersarang (details, customFields)
+// digabung, dan nilai null menghapus field.
+func (s *AchievementService) Patch(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: body must be a JSON object"})
+	}
+
+	// Tolak field yang dikelola sistem
+	var readonlyErrs []model.FieldError
+	for _, key := range []string{"id", "studentId", "attachments", "points", "isDeleted", "deletedAt", "createdAt", "updatedAt"} {
+		if _, exists := patch[key]; exists {
+			readonlyErrs = append(readonlyErrs, model.FieldError{Field: key, Rule: "readonly", Message: key + " cannot be modified"})
+		}
+	}
+	if len(readonlyErrs) > 0 {
+		return validationFailed(c, readonlyErrs)
+	}
+
+	ref, current, errResp := s.loadEditableAchievement(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	// Terapkan patch di atas representasi JSON dokumen saat ini
+	var doc map[string]interface{}
+	raw, _ := json.Marshal(current)
+	_ = json.Unmarshal(raw, &doc)
+	merged, _ := json.Marshal(utils.MergePatch(doc, patch))
+
+	var updated model.Achievement
+	if err := json.Unmarshal(merged, &updated); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: " + err.Error()})
+	}
+	if errs := utils.ValidateStruct(&updated); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	if errs, err := s.validateDetails(c.Context(), &updated, current.AchievementType); err != nil {
+		return respondError(c, err, "Failed to update achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	if errs, err := s.normalizeTags(c.Context(), &updated); err != nil {
+		return respondError(c, err, "Failed to update achievement")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Pastikan field sistem tetap dari dokumen asli
+	updated.ID = current.ID
+	updated.StudentID = current.StudentID
+	updated.Attachments = current.Attachments
+	updated.Points = current.Points
+	updated.CreatedAt = current.CreatedAt
+
+	return s.saveAchievementContent(c, ref, current, &updated)
+}
+
+// loadEditableAchievement memastikan prestasi ada, milik mahasiswa yang login,
+// masih bisa diedit, dan If-Match cocok dengan version saat ini. Jika tidak,
+// ref bernilai nil dan response error sudah ditulis.
+func (s *AchievementService) loadEditableAchievement(c *fiber.Ctx, id string) (*model.AchievementReference, *model.Achievement, error) {
+	// Hanya draft, atau rejected untuk revisi, yang boleh diedit (aksi 'edit')
+	ref, content, _, errResp := s.authorizeAction(c, id, ActionEdit)
+	if ref == nil {
+		return nil, nil, errResp
+	}
+	if content == nil {
+		return nil, nil, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	// Dua tab/perangkat yang mengedit draft yang sama tidak saling menimpa
+	if ok, err := checkIfMatch(c, ref.Version); !ok {
+		return nil, nil, err
+	}
+
+	return ref, content, nil
+}
+
+func (s *AchievementService) saveAchievementContent(c *fiber.Ctx, ref *model.AchievementReference, previous, updated *model.Achievement) error {
+	// Poin saran mengikuti konten terbaru
+	calc, err := s.suggestPoints(c.Context(), updated)
+	if err != nil {
+		return respondError(c, err, "Failed to update achievement")
+	}
+	applySuggestion(ref, calc)
+
+	if err := s.achRepo.UpdateContent(c.Context(), ref, previous, updated); err != nil {
+		if errors.Is(err, repository.ErrAchievementNotEditable) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Achievement status changed and can no longer be edited"})
+		}
+		return respondError(c, err, "Failed to update achievement")
+	}
+
+	ref.Title = updated.Title
+	ref.UpdatedAt = updated.UpdatedAt
+	ref.ValidUntil = updated.Details.ValidUntil
+	ref.Certification = model.EvaluateCertification(ref.ValidUntil, nil, time.Now())
+
+	// Details berubah: nilai ulang kemungkinan duplikat. Yang mengedit selalu
+	// mahasiswa pemilik, jadi flag tidak ikut di response.
+	s.checkDuplicates(c.Context(), ref, updated)
+	hideDuplicateFlags(ref, "Mahasiswa")
+	s.attachEvidence(c.Context(), ref, updated)
+
+	c.Set(fiber.HeaderETag, etag(ref.Version))
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Prestasi berhasil diperbarui",
+		Data: fiber.Map{
+			"meta":    ref,
+			"content": updated,
+		},
+	})
 }
 
+// GET /api/v1/achievements/:id/history (Riwayat Transisi Status)
 func (s *AchievementService) GetHistory(c *fiber.Ctx) error {
-	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "History Not Implemented"})
+	id := c.Params("id")
+
+	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+
+	if !s.canViewAchievement(c, ref) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You cannot access this achievement"})
+	}
+
+	history, err := s.achRepo.FindHistory(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve history")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "History retrieved",
+		Data: fiber.Map{
+			"achievementId": id,
+			"status":        ref.Status,
+			"history":       history,
+		},
+	})
 }
 
 func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 	achievementID := c.Params("id")
 
+	// Lampiran termasuk isi prestasi: hanya pemilik, saat draft/rejected
+	ref, content, _, errResp := s.authorizeAction(c, achievementID, ActionEdit)
+	if ref == nil {
+		return errResp
+	}
+
+	// Kategori bukti (opsional) harus salah satu kategori bukti wajib tipe prestasi
+	category := strings.ToLower(strings.TrimSpace(c.FormValue("category")))
+	if category != "" && content != nil {
+		if errs, err := s.checkAttachmentCategory(c.Context(), content.AchievementType, category); err != nil {
+			return respondError(c, err, "Failed to upload attachment")
+		} else if len(errs) > 0 {
+			return validationFailed(c, errs)
+		}
+	}
+
 	// Parse multipart form
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -434,14 +846,14 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 	}
 
 	// Validate file type
-	allowedTypes := map[string]bool{
-		"image/jpeg": true, "image/png": true, "image/jpg": true,
-		"application/pdf": true, "application/msword": true,
-		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	if !allowedAttachmentTypes[file.Header.Get("Content-Type")] {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid file type"})
 	}
 
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid file type"})
+	// Hash isi file untuk mendeteksi lampiran yang dipakai ulang
+	sha, err := hashAttachment(file)
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Failed to read uploaded file"})
 	}
 
 	// Generate unique filename
@@ -458,6 +870,7 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		FileName:   file.Filename,
 		FileURL:    fmt.Sprintf("/uploads/%s", filename),
 		FileType:   file.Header.Get("Content-Type"),
+		Category:   category,
 		UploadedAt: time.Now(),
 	}
 
@@ -466,6 +879,17 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update achievement"})
 	}
 
+	// File identik di prestasi lain ditandai untuk verifikator (tidak memblokir)
+	matches, err := s.achRepo.SaveAttachmentHash(c.Context(), achievementID, attachment, sha)
+	if err == nil && len(matches) > 0 {
+		err = s.achRepo.SaveDuplicateFlags(c.Context(), achievementID, matches, nil)
+	}
+	if err != nil {
+		fmt.Printf("[WARNING] Attachment duplicate check failed for achievement %s: %v\n", achievementID, err)
+	} else {
+		notifyDuplicates(ref, ref.Title, matches)
+	}
+
 	return c.JSON(model.WebResponse{
 		Code: 200, Status: "success",
 		Message: "File uploaded successfully",
@@ -489,13 +913,328 @@ func (s *AchievementService) GetAdviseeAchievements(c *fiber.Ctx) error {
 	// 4. Panggil Repo FindAll dengan Filter AdvisorID
 	data, total, err := s.achRepo.FindAll(param, "", lecturer.ID)
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to retrieve advisee achievements")
+	}
+
+	actor := achievementActor{UserID: userID, Role: "Dosen Wali", LecturerID: lecturer.ID, Permissions: localPermissions(c)}
+	for i := range data {
+		data[i].AvailableActions = availableActions(&data[i], actor)
 	}
 
 	// 5. Return Response dengan Pagination
 	return s.sendPaginationResponse(c, data, total, param)
 }
 
+// GET /api/v1/verification/queue (Antrian Tahap Verifikasi)
+// Prestasi yang tahap aktifnya bisa diputuskan user ini: tahap dosen wali untuk
+// mahasiswa bimbingannya, dan tahap lain sesuai permission yang dimiliki.
+func (s *AchievementService) GetVerificationQueue(c *fiber.Ctx) error {
+	actor := s.resolveActor(c)
+	param := s.parsePagination(c)
+
+	data, total, err := s.achRepo.FindStageQueue(param, actor.Permissions, actor.LecturerID, actor.UserID)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve verification queue")
+	}
+
+	for i := range data {
+		data[i].AvailableActions = availableActions(&data[i], actor)
+	}
+
+	return s.sendPaginationResponse(c, data, total, param)
+}
+
+// GET /api/v1/verification/pipelines (Konfigurasi Alur Verifikasi - Admin)
+func (s *AchievementService) GetPipelines(c *fiber.Ctx) error {
+	stages, err := s.achRepo.FindAllPipelines(c.Context())
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve verification pipelines")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verification pipelines retrieved", Data: stages})
+}
+
+// PUT /api/v1/verification/pipelines (Ganti Tahap untuk Satu Tipe & Tingkat - Admin)
+// Pengajuan yang sedang berjalan tetap memakai tahap saat disubmit.
+func (s *AchievementService) UpdatePipeline(c *fiber.Ctx) error {
+	var req struct {
+		AchievementType  string  `json:"achievementType" validate:"required,max=50"`
+		CompetitionLevel *string `json:"competitionLevel" validate:"omitempty,oneof=local regional national international"`
+		Stages           []struct {
+			Key        string `json:"key" validate:"required,max=50"`
+			Name       string `json:"name" validate:"required,max=100"`
+			Permission string `json:"permission" validate:"required,max=100"`
+		} `json:"stages" validate:"max=10"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Urutan tahap mengikuti urutan array; key harus unik dalam satu alur
+	stages := make([]model.PipelineStage, 0, len(req.Stages))
+	seen := make(map[string]bool)
+	for i, st := range req.Stages {
+		if seen[st.Key] {
+			return validationFailed(c, []model.FieldError{{
+				Field: fmt.Sprintf("stages[%d].key", i), Rule: "unique", Message: "stage key must be unique within a pipeline",
+			}})
+		}
+		seen[st.Key] = true
+		stages = append(stages, model.PipelineStage{Order: i + 1, Key: st.Key, Name: st.Name, Permission: st.Permission})
+	}
+
+	// Alur umum ('*' tanpa tingkat) tidak boleh kosong
+	if req.AchievementType == "*" && req.CompetitionLevel == nil && len(stages) == 0 {
+		return validationFailed(c, []model.FieldError{{Field: "stages", Rule: "required", Message: "default pipeline needs at least one stage"}})
+	}
+
+	saved, err := s.achRepo.ReplacePipeline(c.Context(), req.AchievementType, req.CompetitionLevel, stages)
+	if err != nil {
+		return respondError(c, err, "Failed to update verification pipeline")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verification pipeline updated", Data: saved})
+}
+
+// GET /api/v1/achievement-types (Registry Tipe Prestasi + Schema Form)
+// Admin bisa menambahkan ?includeInactive=true untuk melihat tipe nonaktif.
+func (s *AchievementService) GetAchievementTypes(c *fiber.Ctx) error {
+	admin := achievementActor{Permissions: localPermissions(c)}.hasPermission("user:manage")
+	activeOnly := !(admin && c.QueryBool("includeInactive"))
+
+	types, err := s.achRepo.FindAchievementTypes(c.Context(), activeOnly)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve achievement types")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Achievement types retrieved", Data: types})
+}
+
+// GET /api/v1/achievement-types/:key
+func (s *AchievementService) GetAchievementType(c *fiber.Ctx) error {
+	t, err := s.achRepo.FindAchievementType(c.Context(), c.Params("key"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement type not found"})
+		}
+		return respondError(c, err, "Failed to retrieve achievement type")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Achievement type retrieved", Data: t})
+}
+
+// POST /api/v1/achievement-types (Tambah Tipe Prestasi - Admin)
+func (s *AchievementService) CreateAchievementType(c *fiber.Ctx) error {
+	var req struct {
+		Key         string             `json:"key" validate:"required,max=50"`
+		Name        string             `json:"name" validate:"required,max=100"`
+		Description string             `json:"description" validate:"max=1000"`
+		Schema      model.DetailSchema `json:"schema"`
+		IsActive    *bool              `json:"isActive"`
+		// Kebijakan verifikasi prestasi tim; kosong = leader
+		TeamVerification string `json:"teamVerification" validate:"omitempty,oneof=leader per_member"`
+		// Bukti wajib sebelum prestasi bisa diajukan
+		EvidenceRequirements []model.EvidenceRequirement `json:"evidenceRequirements" validate:"max=20"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+	if errs := append(checkDetailSchema(&req.Schema), checkEvidenceRequirements(req.EvidenceRequirements)...); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	t := model.AchievementType{Key: req.Key, Name: req.Name, Description: req.Description, Schema: req.Schema, IsActive: true, TeamVerification: teamVerificationOrDefault(req.TeamVerification), EvidenceRequirements: req.EvidenceRequirements}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+
+	if err := s.achRepo.CreateAchievementType(c.Context(), &t); err != nil {
+		return respondError(c, err, "Failed to create achievement type")
+	}
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Achievement type created", Data: t})
+}
+
+// PUT /api/v1/achievement-types/:key (Ubah Schema / Nonaktifkan - Admin)
+func (s *AchievementService) UpdateAchievementType(c *fiber.Ctx) error {
+	var req struct {
+		Name        string             `json:"name" validate:"required,max=100"`
+		Description string             `json:"description" validate:"max=1000"`
+		Schema      model.DetailSchema `json:"schema"`
+		IsActive    *bool              `json:"isActive"`
+		// Kebijakan verifikasi prestasi tim; kosong = leader
+		TeamVerification string `json:"teamVerification" validate:"omitempty,oneof=leader per_member"`
+		// Bukti wajib sebelum prestasi bisa diajukan
+		EvidenceRequirements []model.EvidenceRequirement `json:"evidenceRequirements" validate:"max=20"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+	if errs := append(checkDetailSchema(&req.Schema), checkEvidenceRequirements(req.EvidenceRequirements)...); len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	t := model.AchievementType{Key: c.Params("key"), Name: req.Name, Description: req.Description, Schema: req.Schema, IsActive: true, TeamVerification: teamVerificationOrDefault(req.TeamVerification), EvidenceRequirements: req.EvidenceRequirements}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+
+	if err := s.achRepo.UpdateAchievementType(c.Context(), &t); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement type not found"})
+		}
+		return respondError(c, err, "Failed to update achievement type")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Achievement type updated", Data: t})
+}
+
+// GET /api/v1/points/rubrics (Daftar Versi Rubrik Poin - Admin)
+func (s *AchievementService) GetRubrics(c *fiber.Ctx) error {
+	rubrics, err := s.achRepo.FindRubrics(c.Context())
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve point rubrics")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Point rubrics retrieved", Data: rubrics})
+}
+
+// GET /api/v1/points/rubrics/:version (Detail Rubrik + Aturan)
+func (s *AchievementService) GetRubric(c *fiber.Ctx) error {
+	version, err := c.ParamsInt("version")
+	if err != nil || version <= 0 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid rubric version"})
+	}
+
+	rubric, err := s.achRepo.FindRubric(c.Context(), version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Point rubric not found"})
+		}
+		return respondError(c, err, "Failed to retrieve point rubric")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Point rubric retrieved", Data: rubric})
+}
+
+// POST /api/v1/points/rubrics (Buat Versi Rubrik Baru - Admin)
+// Rubrik tidak bisa diedit; perubahan aturan selalu membuat versi baru agar
+// poin yang sudah diberikan tetap bisa direproduksi.
+func (s *AchievementService) CreateRubric(c *fiber.Ctx) error {
+	var req struct {
+		Name        string            `json:"name" validate:"required,max=100"`
+		Description string            `json:"description" validate:"max=1000"`
+		Activate    bool              `json:"activate"`
+		Rules       []model.PointRule `json:"rules" validate:"required,max=500"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Setiap aturan harus merujuk tipe prestasi yang terdaftar
+	types, err := s.achRepo.FindAchievementTypes(c.Context(), false)
+	if err != nil {
+		return respondError(c, err, "Failed to create point rubric")
+	}
+	known := make(map[string]bool, len(types))
+	for _, t := range types {
+		known[t.Key] = true
+	}
+	var errs []model.FieldError
+	for i, rule := range req.Rules {
+		if !known[rule.AchievementType] {
+			field := fmt.Sprintf("rules[%d].achievementType", i)
+			errs = append(errs, model.FieldError{Field: field, Rule: "exists", Message: field + " must be a registered achievement type"})
+		}
+		// Poin prestasi tim: default setiap anggota mendapat poin penuh
+		switch rule.TeamPoints {
+		case "":
+			req.Rules[i].TeamPoints = model.TeamPointsDuplicate
+		case model.TeamPointsDuplicate, model.TeamPointsSplit:
+		default:
+			field := fmt.Sprintf("rules[%d].teamPoints", i)
+			errs = append(errs, model.FieldError{Field: field, Rule: "oneof", Message: field + " must be one of: duplicate split"})
+		}
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	userID := c.Locals("user_id").(string)
+	rubric := model.PointRubric{
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    req.Activate,
+		CreatedBy:   &userID,
+		Rules:       req.Rules,
+	}
+	if err := s.achRepo.CreateRubric(c.Context(), &rubric); err != nil {
+		return respondError(c, err, "Failed to create point rubric")
+	}
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Point rubric created", Data: rubric})
+}
+
+// PUT /api/v1/points/rubrics/:version/activate (Aktifkan Versi Rubrik - Admin)
+// Hanya mempengaruhi perhitungan berikutnya; poin lama tetap tercatat dengan versinya.
+func (s *AchievementService) ActivateRubric(c *fiber.Ctx) error {
+	version, err := c.ParamsInt("version")
+	if err != nil || version <= 0 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid rubric version"})
+	}
+
+	if err := s.achRepo.ActivateRubric(c.Context(), version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Point rubric not found"})
+		}
+		return respondError(c, err, "Failed to activate point rubric")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Point rubric activated", Data: fiber.Map{"version": version, "isActive": true}})
+}
+
+// GET /api/v1/lecturers/:id (Profil Dosen + Roster Bimbingan + Beban Kerja)
+func (s *AchievementService) GetLecturerDetail(c *fiber.Ctx) error {
+	lecturerID := c.Params("id") // ID tabel lecturers
+	userRole := c.Locals("role").(string)
+	userID := c.Locals("user_id").(string)
+
+	lecturer, err := s.userRepo.FindLecturerByID(lecturerID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Lecturer not found"})
+	}
+
+	// Roster berisi data mahasiswa lain: hanya Admin atau dosen yang bersangkutan
+	if userRole != "Admin" && lecturer.UserID != userID {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Forbidden"})
+	}
+
+	roster, err := s.achRepo.GetAdviseeRoster(c.Context(), lecturer.ID)
+	if err != nil {
+		return respondError(c, err, "Failed to load advisees")
+	}
+
+	workload, err := s.achRepo.GetLecturerWorkload(c.Context(), lecturer.ID, lecturer.UserID)
+	if err != nil {
+		return respondError(c, err, "Failed to load workload")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Lecturer detail retrieved",
+		Data: fiber.Map{
+			"profile":  lecturer,
+			"advisees": roster,
+			"workload": workload,
+		},
+	})
+}
+
 func (s *AchievementService) GetStudentAchievements(c *fiber.Ctx) error {
 	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "Student Achievement List Not Implemented"})
 }
@@ -518,17 +1257,17 @@ func (s *AchievementService) GetStatistics(c *fiber.Ctx) error {
 		if err != nil {
 			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student profile not found"})
 		}
-		stats, err := s.achRepo.GetStudentStatistics(c.Context(), student.ID)
+		stats, err := s.achRepo.GetStudentStatistics(c.Context(), student.ID, statsFilter(c))
 		if err != nil {
-			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate stats: " + err.Error()})
+			return respondError(c, err, "Failed to generate stats")
 		}
 		return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Your Statistics", Data: stats})
 	}
 
 	// Admin melihat statistik keseluruhan
-	stats, err := s.achRepo.GetStatistics(c.Context())
+	stats, err := s.achRepo.GetStatistics(c.Context(), statsFilter(c))
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate stats: " + err.Error()})
+		return respondError(c, err, "Failed to generate stats")
 	}
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Overall Statistics", Data: stats})
 }
@@ -544,9 +1283,9 @@ func (s *AchievementService) getAdvisorStatistics(c *fiber.Ctx, userID string) e
 	// TODO: Implement advisor-specific statistics
 	// This would aggregate statistics from all advisee students
 	// For now, return overall stats (can be enhanced later)
-	stats, err := s.achRepo.GetStatistics(c.Context())
+	stats, err := s.achRepo.GetStatistics(c.Context(), statsFilter(c))
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate stats: " + err.Error()})
+		return respondError(c, err, "Failed to generate stats")
 	}
 
 	return c.JSON(model.WebResponse{
@@ -582,9 +1321,9 @@ func (s *AchievementService) GetStudentStatistics(c *fiber.Ctx) error {
 		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Forbidden"})
 	}
 
-	stats, err := s.achRepo.GetStudentStatistics(c.Context(), targetStudentID)
+	stats, err := s.achRepo.GetStudentStatistics(c.Context(), targetStudentID, statsFilter(c))
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to generate stats")
 	}
 
 	return c.JSON(model.WebResponse{
@@ -596,6 +1335,44 @@ func (s *AchievementService) GetStudentStatistics(c *fiber.Ctx) error {
 }
 
 // HELPER
+
+// isResponsibleVerifier mengecek apakah dosen berhak memutuskan prestasi ini.
+// Prestasi yang ditahan saat pergantian dosen wali (kebijakan 'keep') tetap
+// menjadi tanggung jawab dosen lama; selain itu mengikuti dosen wali aktif.
+func isResponsibleVerifier(ref *model.AchievementReference, lecturerID string) bool {
+	if ref.AssignedVerifierID != nil {
+		return *ref.AssignedVerifierID == lecturerID
+	}
+	return ref.Student != nil && ref.Student.AdvisorID != nil && *ref.Student.AdvisorID == lecturerID
+}
+
+// canViewAchievement: Admin melihat semua, mahasiswa hanya miliknya (termasuk
+// prestasi tim tempat ia diundang), dosen wali hanya mahasiswa bimbingannya
+// (termasuk anggota tim) atau prestasi yang ditugaskan kepadanya.
+func (s *AchievementService) canViewAchievement(c *fiber.Ctx, ref *model.AchievementReference) bool {
+	userID := c.Locals("user_id").(string)
+	switch c.Locals("role") {
+	case "Admin":
+		return true
+	case "Mahasiswa":
+		student, err := s.userRepo.FindStudentByUserID(userID)
+		return err == nil && (ref.StudentID == student.ID || findTeamMember(ref, student.ID) != nil)
+	case "Dosen Wali":
+		lecturer, err := s.userRepo.FindLecturerByUserID(userID)
+		if err != nil {
+			return false
+		}
+		if isResponsibleVerifier(ref, lecturer.ID) {
+			return true
+		}
+		if advisesTeamMember(ref, lecturer.ID) {
+			return true
+		}
+		return ref.Student != nil && ref.Student.AdvisorID != nil && *ref.Student.AdvisorID == lecturer.ID
+	}
+	return false
+}
+
 func (s *AchievementService) parsePagination(c *fiber.Ctx) model.PaginationParam {
 	return model.PaginationParam{
 		Page:   c.QueryInt("page", 1),
@@ -603,6 +1380,9 @@ func (s *AchievementService) parsePagination(c *fiber.Ctx) model.PaginationParam
 		SortBy: c.Query("sortBy", "created_at"),
 		Order:  c.Query("order", "desc"),
 		Search: c.Query("search", ""),
+		// valid, expiring_soon, expired (nilai lain diabaikan)
+		Certification: c.Query("certification", ""),
+		Tag:           c.Query("tag", ""),
 	}
 }
 
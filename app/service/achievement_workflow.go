@@ -0,0 +1,279 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Aksi terhadap prestasi. Status asal/tujuan tiap aksi didefinisikan sekali
+// di model.AchievementActions; di sini hanya ditambahkan siapa yang boleh
+// menjalankannya.
+const (
+	ActionEdit     = model.ActionEdit
+	ActionSubmit   = model.ActionSubmit
+	ActionWithdraw = model.ActionWithdraw
+	ActionVerify   = model.ActionVerify
+	ActionReject   = model.ActionReject
+	ActionRevise   = model.ActionRevise
+	ActionDelete   = model.ActionDelete
+	ActionRevoke   = model.ActionRevoke
+	ActionRestore  = model.ActionRestore
+)
+
+// Guard: pihak yang boleh menjalankan aksi
+const (
+	guardOwner    = "owner"    // mahasiswa pemilik prestasi
+	guardVerifier = "verifier" // pemutus tahap verifikasi yang sedang berjalan
+	guardAdmin    = "admin"    // pemegang permission user:manage
+)
+
+var actionGuards = map[string]string{
+	ActionEdit:     guardOwner,
+	ActionSubmit:   guardOwner,
+	ActionWithdraw: guardOwner,
+	ActionRevise:   guardOwner,
+	ActionDelete:   guardOwner,
+	ActionVerify:   guardVerifier,
+	ActionReject:   guardVerifier,
+	ActionRevoke:   guardAdmin,
+	ActionRestore:  guardOwner,
+}
+
+type achievementAction struct {
+	model.AchievementAction
+	Guard string
+}
+
+// achievementActions mengikuti urutan model.AchievementActions. Aksi tanpa
+// guard tidak pernah lolos guardPasses.
+var achievementActions = func() []achievementAction {
+	actions := make([]achievementAction, 0, len(model.AchievementActions))
+	for _, a := range model.AchievementActions {
+		actions = append(actions, achievementAction{AchievementAction: a, Guard: actionGuards[a.Name]})
+	}
+	return actions
+}()
+
+// achievementActor adalah identitas user yang login, sudah di-resolve ke profil
+// mahasiswa/dosen agar guard tidak perlu query ulang per prestasi.
+type achievementActor struct {
+	UserID      string
+	Role        string
+	StudentID   string
+	LecturerID  string
+	Permissions []string
+}
+
+func (a achievementActor) hasPermission(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkflowError menjelaskan kenapa sebuah aksi ditolak oleh state machine
+type WorkflowError struct {
+	Code    int
+	Message string
+}
+
+func (e *WorkflowError) Error() string { return e.Message }
+
+func findAction(name string) (achievementAction, bool) {
+	for _, a := range achievementActions {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return achievementAction{}, false
+}
+
+func (a achievementAction) guardPasses(ref *model.AchievementReference, actor achievementActor) bool {
+	switch a.Guard {
+	case guardOwner:
+		return actor.StudentID != "" && ref.StudentID == actor.StudentID
+	case guardVerifier:
+		return canDecideStage(ref, actor)
+	case guardAdmin:
+		return actor.hasPermission("user:manage")
+	}
+	return false
+}
+
+// canDecideStage: setiap tahap punya permission sendiri. Tahap dosen wali juga
+// mensyaratkan dosen yang bertanggung jawab atas mahasiswa tersebut (atau dosen
+// wali anggota untuk prestasi tim dengan kebijakan per_member).
+func canDecideStage(ref *model.AchievementReference, actor achievementActor) bool {
+	// Pengajuan tanpa data tahap diperlakukan sebagai satu tahap dosen wali
+	key, permission := model.StageAdvisor, model.DefaultPipeline()[0].Permission
+	if ref.CurrentStage != nil {
+		key, permission = ref.CurrentStage.Key, ref.CurrentStage.Permission
+	}
+	if ref.CurrentStage != nil && canDecideEscalated(ref.CurrentStage, actor) {
+		return true
+	}
+	if !actor.hasPermission(permission) {
+		return false
+	}
+	if key == model.StageAdvisor {
+		if actor.LecturerID == "" {
+			return false
+		}
+		return isResponsibleVerifier(ref, actor.LecturerID) || (perMemberApproval(ref) && advisesTeamMember(ref, actor.LecturerID))
+	}
+	return true
+}
+
+// canDecideEscalated: tahap yang lewat SLA dan dieskalasi juga boleh diputuskan
+// pemegang permission eskalasi atau user delegasi
+func canDecideEscalated(stage *model.VerificationStage, actor achievementActor) bool {
+	if stage.EscalatedAt == nil {
+		return false
+	}
+	if stage.EscalatedPermission != nil && actor.hasPermission(*stage.EscalatedPermission) {
+		return true
+	}
+	return stage.EscalatedTo != nil && *stage.EscalatedTo == actor.UserID
+}
+
+// checkAction memvalidasi aksi terhadap status & guard. Mengembalikan status
+// tujuan (kosong jika aksi tidak mengubah status).
+func checkAction(ref *model.AchievementReference, actor achievementActor, name string) (string, *WorkflowError) {
+	action, ok := findAction(name)
+	if !ok {
+		return "", &WorkflowError{Code: 400, Message: fmt.Sprintf("Unknown action '%s'", name)}
+	}
+	if !action.guardPasses(ref, actor) {
+		if action.Guard == guardVerifier {
+			return "", &WorkflowError{Code: 403, Message: "Unauthorized: You are not the verifier of this achievement"}
+		}
+		if action.Guard == guardAdmin {
+			return "", &WorkflowError{Code: 403, Message: "Unauthorized: Only administrators can " + name + " achievements"}
+		}
+		return "", &WorkflowError{Code: 403, Message: "Unauthorized: You do not own this achievement"}
+	}
+	if !action.AllowedFrom(ref.Status) {
+		return "", &WorkflowError{Code: 400, Message: fmt.Sprintf("Action '%s' is not allowed while achievement is '%s'", name, ref.Status)}
+	}
+	return action.To, nil
+}
+
+// availableActions mengembalikan aksi yang bisa dijalankan actor pada prestasi ini
+func availableActions(ref *model.AchievementReference, actor achievementActor) []string {
+	actions := []string{}
+	for _, a := range achievementActions {
+		if a.AllowedFrom(ref.Status) && a.guardPasses(ref, actor) {
+			actions = append(actions, a.Name)
+		}
+	}
+	return actions
+}
+
+// resolveActor mengambil profil mahasiswa/dosen dari user yang login
+func (s *AchievementService) resolveActor(c *fiber.Ctx) achievementActor {
+	actor := achievementActor{UserID: c.Locals("user_id").(string), Permissions: localPermissions(c)}
+	actor.Role, _ = c.Locals("role").(string)
+
+	switch actor.Role {
+	case "Mahasiswa":
+		if student, err := s.userRepo.FindStudentByUserID(actor.UserID); err == nil {
+			actor.StudentID = student.ID
+		}
+	case "Dosen Wali":
+		if lecturer, err := s.userRepo.FindLecturerByUserID(actor.UserID); err == nil {
+			actor.LecturerID = lecturer.ID
+		}
+	}
+	return actor
+}
+
+// authorizeAction memuat prestasi lalu menjalankan checkAction. Jika ditolak,
+// ref bernilai nil dan response error sudah ditulis.
+func (s *AchievementService) authorizeAction(c *fiber.Ctx, id string, name string) (*model.AchievementReference, *model.Achievement, achievementActor, error) {
+	actor := s.resolveActor(c)
+
+	ref, content, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return nil, nil, actor, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+
+	if _, werr := checkAction(ref, actor, name); werr != nil {
+		return nil, nil, actor, c.Status(werr.Code).JSON(model.WebResponse{
+			Code:    werr.Code,
+			Status:  "error",
+			Message: werr.Message,
+			Data:    fiber.Map{"status": ref.Status, "availableActions": availableActions(ref, actor)},
+		})
+	}
+
+	return ref, content, actor, nil
+}
+
+// localPermissions membaca permission dari token (bisa []string atau []interface{})
+func localPermissions(c *fiber.Ctx) []string {
+	switch v := c.Locals("permissions").(type) {
+	case []string:
+		return v
+	case []interface{}:
+		perms := make([]string, 0, len(v))
+		for _, item := range v {
+			if p, ok := item.(string); ok {
+				perms = append(perms, p)
+			}
+		}
+		return perms
+	}
+	return nil
+}
+
+// newStatusChange menyiapkan transisi untuk repository. Status dan version yang
+// dibaca saat authorizeAction dipakai sebagai syarat compare-and-set, sehingga
+// request yang kalah balapan mendapat 409. Keputusan verify/reject terikat pada
+// tahap verifikasi yang sedang berjalan.
+func newStatusChange(actor achievementActor, ref *model.AchievementReference, name string, note string, points int) (model.StatusChange, error) {
+	to, werr := checkAction(ref, actor, name)
+	if werr != nil {
+		return model.StatusChange{}, werr
+	}
+	change := model.StatusChange{
+		ID:              ref.ID,
+		ExpectedStatus:  ref.Status,
+		ExpectedVersion: ref.Version,
+		Status:          to,
+		ActorID:         actor.UserID,
+		Note:            note,
+		Points:          points,
+	}
+	if (name == ActionVerify || name == ActionReject) && ref.CurrentStage != nil {
+		change.Stage = ref.CurrentStage.Key
+	}
+	return change, nil
+}
+
+// transition menjalankan aksi yang mengubah status lewat repository
+func (s *AchievementService) transition(c *fiber.Ctx, actor achievementActor, ref *model.AchievementReference, name string, note string, points int) error {
+	change, err := newStatusChange(actor, ref, name, note, points)
+	if err != nil {
+		return err
+	}
+	return s.achRepo.UpdateStatus(c.Context(), change)
+}
+
+// nextStage mengembalikan tahap setelah tahap aktif, atau nil jika tahap aktif
+// adalah tahap terakhir (keputusan berikutnya menjadi verified)
+func nextStage(ref *model.AchievementReference) *model.VerificationStage {
+	if ref.CurrentStage == nil {
+		return nil
+	}
+	for i := range ref.Stages {
+		if ref.Stages[i].Order > ref.CurrentStage.Order && ref.Stages[i].Status == model.StagePending {
+			return &ref.Stages[i]
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/WedhaWS/uasgosmt5/app/model"
 	"github.com/WedhaWS/uasgosmt5/app/repository"
 	"github.com/WedhaWS/uasgosmt5/utils"
@@ -27,12 +30,12 @@ func NewAuthService(userRepo *repository.UserRepository, roleRepo *repository.Ro
 // POST /api/v1/auth/login
 func (s *AuthService) Login(c *fiber.Ctx) error {
 	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	// 1. Cari User
@@ -123,7 +126,7 @@ func (s *AuthService) GetProfile(c *fiber.Ctx) error {
 func (s *AuthService) GetAllUsers(c *fiber.Ctx) error {
 	users, err := s.userRepo.FindAll()
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to retrieve users")
 	}
 
 	return c.JSON(model.WebResponse{
@@ -141,6 +144,9 @@ func (s *AuthService) GetUserDetail(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
 	}
+	if notModified(c, user.Version) {
+		return c.SendStatus(304)
+	}
 
 	user.PasswordHash = ""
 	return c.JSON(model.WebResponse{
@@ -154,15 +160,15 @@ func (s *AuthService) GetUserDetail(c *fiber.Ctx) error {
 // POST /api/v1/users
 func (s *AuthService) CreateUser(c *fiber.Ctx) error {
 	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		FullName string `json:"fullName"`
-		RoleID   string `json:"roleId"`
+		Username string `json:"username" validate:"required,min=3,max=50"`
+		Email    string `json:"email" validate:"required,email,max=100"`
+		Password string `json:"password" validate:"required,min=8,max=72"`
+		FullName string `json:"fullName" validate:"required,max=100"`
+		RoleID   string `json:"roleId" validate:"required,uuid"`
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input data"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	hashedPwd, err := utils.HashPassword(req.Password)
@@ -180,7 +186,7 @@ func (s *AuthService) CreateUser(c *fiber.Ctx) error {
 	}
 
 	if err := s.userRepo.Create(&user); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to create user")
 	}
 
 	user.PasswordHash = ""
@@ -198,14 +204,14 @@ func (s *AuthService) UpdateUser(c *fiber.Ctx) error {
 	
 	// Struct input parsial untuk update
 	var req struct {
-		FullName string `json:"fullName"`
-		Username string `json:"username"`
-		Email    string `json:"email"`
+		FullName string `json:"fullName" validate:"required,max=100"`
+		Username string `json:"username" validate:"required,min=3,max=50"`
+		Email    string `json:"email" validate:"required,email,max=100"`
 		IsActive bool   `json:"isActive"`
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	// 1. Cari user dulu untuk memastikan ada
@@ -214,6 +220,12 @@ func (s *AuthService) UpdateUser(c *fiber.Ctx) error {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
 	}
 
+	// ETag dari GET /users/:id wajib dikirim lewat If-Match agar perubahan
+	// admin lain tidak tertimpa diam-diam
+	if ok, err := checkIfMatch(c, user.Version); !ok {
+		return err
+	}
+
 	// 2. Update field
 	user.FullName = req.FullName
 	user.Username = req.Username
@@ -222,10 +234,11 @@ func (s *AuthService) UpdateUser(c *fiber.Ctx) error {
 
 	// 3. Simpan perubahan
 	if err := s.userRepo.Update(user); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to update user")
 	}
 
 	user.PasswordHash = "" // Hide sensitive data
+	c.Set(fiber.HeaderETag, etag(user.Version))
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User updated successfully", Data: user})
 }
 
@@ -240,7 +253,7 @@ func (s *AuthService) DeleteUser(c *fiber.Ctx) error {
 	}
 
 	if err := s.userRepo.Delete(id); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete user: " + err.Error()})
+		return respondError(c, err, "Failed to delete user")
 	}
 
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User deleted successfully"})
@@ -249,15 +262,28 @@ func (s *AuthService) DeleteUser(c *fiber.Ctx) error {
 // PUT /api/v1/users/:id/role
 func (s *AuthService) UpdateUserRole(c *fiber.Ctx) error {
 	id := c.Params("id")
-	var req struct { RoleID string `json:"roleId"` }
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	var req struct {
+		RoleID string `json:"roleId" validate:"required,uuid"`
+	}
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	// Sama seperti UpdateUser: perubahan role wajib membawa ETag terbaru
+	if ok, err := checkIfMatch(c, user.Version); !ok {
+		return err
 	}
 
-	if err := s.userRepo.UpdateRole(id, req.RoleID); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	if err := s.userRepo.UpdateRole(id, req.RoleID, user.Version); err != nil {
+		return respondError(c, err, "Failed to assign role")
 	}
 
+	c.Set(fiber.HeaderETag, etag(user.Version+1))
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Role assigned successfully"})
 }
 
@@ -268,7 +294,7 @@ func (s *AuthService) UpdateUserRole(c *fiber.Ctx) error {
 func (s *AuthService) GetAllStudents(c *fiber.Ctx) error {
 	students, err := s.userRepo.FindAllStudents()
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to retrieve students")
 	}
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: students})
 }
@@ -276,13 +302,13 @@ func (s *AuthService) GetAllStudents(c *fiber.Ctx) error {
 // POST /api/v1/students (Set Student Profile - Admin Only)
 func (s *AuthService) SetStudentProfile(c *fiber.Ctx) error {
 	var req struct {
-		UserID       string `json:"userId"`
-		StudentID    string `json:"studentId"` // NIM
-		ProgramStudy string `json:"programStudy"`
-		AcademicYear string `json:"academicYear"`
+		UserID       string `json:"userId" validate:"required,uuid"`
+		StudentID    string `json:"studentId" validate:"required,max=20"` // NIM
+		ProgramStudy string `json:"programStudy" validate:"required,max=100"`
+		AcademicYear string `json:"academicYear" validate:"required,max=10"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	student := model.Student{
@@ -293,7 +319,7 @@ func (s *AuthService) SetStudentProfile(c *fiber.Ctx) error {
 	}
 
 	if err := s.userRepo.SaveStudent(&student); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to set profile: " + err.Error()})
+		return respondError(c, err, "Failed to set profile")
 	}
 
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Student profile updated"})
@@ -318,23 +344,88 @@ func (s *AuthService) GetStudentDetail(c *fiber.Ctx) error {
 // PUT /api/v1/students/:id/advisor (Set Advisor)
 func (s *AuthService) UpdateStudentAdvisor(c *fiber.Ctx) error {
 	studentUUID := c.Params("id") // Ini ID tabel Students (UUID Primary Key)
-	var req struct { AdvisorID string `json:"advisorId"` } // ID tabel Lecturers
-	
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	var req struct {
+		AdvisorID      string `json:"advisorId" validate:"required,uuid"`                   // ID tabel Lecturers
+		HandoverPolicy string `json:"handoverPolicy" validate:"omitempty,oneof=transfer keep"` // transfer | keep
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// 1. Pastikan dosen wali baru ada
+	newAdvisor, err := s.userRepo.FindLecturerByID(req.AdvisorID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Advisor not found"})
 	}
 
-	if err := s.userRepo.AssignAdvisor(studentUUID, req.AdvisorID); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	// 2. Simpan pergantian + riwayat + serah terima (satu transaksi). Kebijakan
+	// serah terima wajib eksplisit jika ada prestasi yang menunggu verifikasi.
+	adminID := c.Locals("user_id").(string)
+	result, err := s.userRepo.AssignAdvisor(studentUUID, req.AdvisorID, adminID, req.HandoverPolicy)
+	if err != nil {
+		var policyErr *repository.HandoverPolicyRequiredError
+		if errors.As(err, &policyErr) {
+			return c.Status(400).JSON(model.WebResponse{
+				Code:    400,
+				Status:  "error",
+				Message: "handoverPolicy is required ('transfer' or 'keep') because this student has submitted achievements pending verification",
+				Data:    fiber.Map{"pendingSubmissions": policyErr.Pending},
+			})
+		}
+		if errors.Is(err, repository.ErrStudentNotFound) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+		}
+		if errors.Is(err, repository.ErrAdvisorUnchanged) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: err.Error()})
+		}
+		return respondError(c, err, "Failed to assign advisor")
+	}
+
+	// 3. Notifikasi ke setiap dosen yang terdampak
+	notify("NOTIFICATION",
+		"To: "+newAdvisor.User.FullName+" (dosen wali baru)",
+		"Student ID: "+studentUUID,
+		fmt.Sprintf("Pending submissions: %d (policy: %s)", result.PendingAffected, result.HandoverPolicy),
+	)
+	if result.PreviousAdvisorID != nil {
+		prevName := *result.PreviousAdvisorID
+		if prev, err := s.userRepo.FindLecturerByID(*result.PreviousAdvisorID); err == nil {
+			prevName = prev.User.FullName
+		}
+		msg := "Pending submissions transferred to new advisor"
+		if result.HandoverPolicy == model.HandoverKeep {
+			msg = "Pending submissions remain in your verification queue"
+		}
+		notify("NOTIFICATION",
+			"To: "+prevName+" (dosen wali sebelumnya)",
+			"Student ID: "+studentUUID,
+			fmt.Sprintf("%s: %d", msg, result.PendingAffected),
+		)
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Advisor assigned successfully", Data: result})
+}
+
+// GET /api/v1/students/:id/advisor-history (Riwayat Dosen Wali)
+func (s *AuthService) GetAdvisorHistory(c *fiber.Ctx) error {
+	studentUUID := c.Params("id") // ID tabel Students
+
+	history, err := s.userRepo.FindAdvisorHistory(studentUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStudentNotFound) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+		}
+		return respondError(c, err, "Failed to retrieve advisor history")
 	}
 
-	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Advisor assigned successfully"})
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Advisor history retrieved", Data: history})
 }
 
 func (s *AuthService) GetAllLecturers(c *fiber.Ctx) error {
 	lecturers, err := s.userRepo.FindAllLecturers()
 	if err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		return respondError(c, err, "Failed to retrieve lecturers")
 	}
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: lecturers})
 }
@@ -342,12 +433,12 @@ func (s *AuthService) GetAllLecturers(c *fiber.Ctx) error {
 // POST /api/v1/lecturers (Set Lecturer Profile - Admin Only)
 func (s *AuthService) SetLecturerProfile(c *fiber.Ctx) error {
 	var req struct {
-		UserID     string `json:"userId"`
-		LecturerID string `json:"lecturerId"` // NIP
-		Department string `json:"department"`
+		UserID     string `json:"userId" validate:"required,uuid"`
+		LecturerID string `json:"lecturerId" validate:"required,max=30"` // NIP
+		Department string `json:"department" validate:"required,max=100"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
 	}
 
 	lecturer := model.Lecturer{
@@ -357,7 +448,7 @@ func (s *AuthService) SetLecturerProfile(c *fiber.Ctx) error {
 	}
 
 	if err := s.userRepo.SaveLecturer(&lecturer); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to set profile: " + err.Error()})
+		return respondError(c, err, "Failed to set profile")
 	}
 
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Lecturer profile updated"})
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bulkVerifyItem adalah satu prestasi pada verify massal. Aturan poin sama
+// dengan verify tunggal: 0 = hasil rubrik, selain itu wajib justification.
+type bulkVerifyItem struct {
+	ID            string `json:"id" validate:"required,uuid"`
+	Points        int    `json:"points" validate:"gte=0"`
+	Justification string `json:"justification" validate:"max=1000"`
+}
+
+// bulkDecision adalah item yang lolos pengecekan dan siap disimpan
+type bulkDecision struct {
+	index  int
+	ref    *model.AchievementReference
+	change model.StatusChange
+	next   *model.VerificationStage // terisi jika hanya menyetujui tahap aktif
+}
+
+// POST /api/v1/achievements/bulk/verify (Verifikasi Massal)
+// Setiap item dicek seperti verify tunggal (status, pemutus tahap aktif, poin).
+// Default: item yang gagal tidak membatalkan item lain. allOrNothing: semua
+// keputusan disimpan dalam satu transaksi, atau tidak sama sekali.
+func (s *AchievementService) BulkVerify(c *fiber.Ctx) error {
+	var req struct {
+		Items        []bulkVerifyItem `json:"items" validate:"required,min=1,max=100,dive"`
+		AllOrNothing bool             `json:"allOrNothing"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ids := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		ids[i] = item.ID
+	}
+
+	return s.runBulk(c, ActionVerify, ids, req.AllOrNothing, func(ctx context.Context, actor achievementActor, i int) (*bulkDecision, error) {
+		return s.prepareVerify(ctx, actor, req.Items[i])
+	})
+}
+
+// POST /api/v1/achievements/bulk/reject (Penolakan Massal dengan Catatan Bersama)
+func (s *AchievementService) BulkReject(c *fiber.Ctx) error {
+	var req struct {
+		IDs          []string `json:"ids" validate:"required,min=1,max=100,dive,uuid"`
+		Note         string   `json:"note" validate:"required,max=1000"`
+		AllOrNothing bool     `json:"allOrNothing"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	return s.runBulk(c, ActionReject, req.IDs, req.AllOrNothing, func(ctx context.Context, actor achievementActor, i int) (*bulkDecision, error) {
+		return s.prepareDecision(ctx, actor, req.IDs[i], ActionReject, req.Note, 0)
+	})
+}
+
+// runBulk memeriksa semua item dengan prepare, menyimpan yang lolos, lalu
+// mengembalikan laporan per item (urutan sama dengan request)
+func (s *AchievementService) runBulk(c *fiber.Ctx, name string, ids []string, allOrNothing bool, prepare func(context.Context, achievementActor, int) (*bulkDecision, error)) error {
+	userID := c.Locals("user_id").(string)
+
+	// Pastikan user terdaftar (pemutus tahap bisa dosen wali atau Kemahasiswaan)
+	verifier, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	actor := s.resolveActor(c)
+
+	results := make([]model.BulkItemResult, len(ids))
+	decisions := make([]bulkDecision, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+
+	for i, id := range ids {
+		results[i].ID = id
+		if seen[id] {
+			results[i].Code, results[i].Message = 400, "Duplicate achievement id in request"
+			continue
+		}
+		seen[id] = true
+
+		d, err := prepare(c.Context(), actor, i)
+		if err != nil {
+			results[i].Code, results[i].Message = errorStatus(err, "Failed to prepare decision")
+			continue
+		}
+		d.index = i
+		decisions = append(decisions, *d)
+	}
+
+	if allOrNothing {
+		if len(decisions) < len(ids) {
+			markSkipped(results, decisions)
+			return bulkResponse(c, 422, "No achievements were updated because some items failed", name, allOrNothing, results)
+		}
+
+		changes := make([]model.StatusChange, len(decisions))
+		for i, d := range decisions {
+			changes[i] = d.change
+		}
+		if err := s.achRepo.ApplyStatusChanges(c.Context(), changes); err != nil {
+			var be *repository.BatchError
+			if !errors.As(err, &be) {
+				return respondError(c, err, "Failed to apply bulk decisions")
+			}
+			markSkipped(results, decisions)
+			failed := &results[decisions[be.Index].index]
+			failed.Code, failed.Message = errorStatus(be.Err, "Failed to apply decision")
+			return bulkResponse(c, failed.Code, "No achievements were updated because some items failed", name, allOrNothing, results)
+		}
+		for _, d := range decisions {
+			s.completeDecision(&results[d.index], d, verifier)
+		}
+	} else {
+		for _, d := range decisions {
+			if err := s.applyDecision(c.Context(), d); err != nil {
+				results[d.index].Code, results[d.index].Message = errorStatus(err, "Failed to apply decision")
+				continue
+			}
+			s.completeDecision(&results[d.index], d, verifier)
+		}
+	}
+
+	return bulkResponse(c, 200, "Bulk "+name+" processed", name, allOrNothing, results)
+}
+
+// prepareVerify menghitung poin final (atau persetujuan tahap) untuk satu item
+func (s *AchievementService) prepareVerify(ctx context.Context, actor achievementActor, item bulkVerifyItem) (*bulkDecision, error) {
+	ref, content, err := s.achRepo.FindDetail(ctx, item.ID)
+	if err != nil || content == nil {
+		return nil, &WorkflowError{Code: 404, Message: "Achievement not found"}
+	}
+	if _, werr := checkAction(ref, actor, ActionVerify); werr != nil {
+		return nil, werr
+	}
+	// Persetujuan per anggota tim dicatat satu per satu lewat verify tunggal
+	if perMemberApproval(ref) {
+		return nil, &WorkflowError{Code: 422, Message: "Team achievements awaiting per-member advisor approval must be verified individually"}
+	}
+
+	calc, err := s.suggestPoints(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	points, overrideReason, errs := resolveAwardedPoints(calc, item.Points, item.Justification)
+	if len(errs) > 0 {
+		return nil, &WorkflowError{Code: 422, Message: errs[0].Message}
+	}
+
+	d, err := newBulkDecision(actor, ref, ActionVerify, overrideReason, points)
+	if err != nil {
+		return nil, err
+	}
+	if d.next == nil {
+		d.change.Calculation = calc
+		d.change.OverrideReason = overrideReason
+		d.change.Awards = teamAwards(ref, calc, points)
+	}
+	return d, nil
+}
+
+// prepareDecision memuat prestasi dan menjalankan pengecekan state machine
+func (s *AchievementService) prepareDecision(ctx context.Context, actor achievementActor, id string, name string, note string, points int) (*bulkDecision, error) {
+	ref, _, err := s.achRepo.FindDetail(ctx, id)
+	if err != nil {
+		return nil, &WorkflowError{Code: 404, Message: "Achievement not found"}
+	}
+	return newBulkDecision(actor, ref, name, note, points)
+}
+
+// newBulkDecision menyiapkan StatusChange; verify pada tahap yang bukan terakhir hanya
+// memindahkan pengajuan ke tahap berikutnya
+func newBulkDecision(actor achievementActor, ref *model.AchievementReference, name string, note string, points int) (*bulkDecision, error) {
+	change, err := newStatusChange(actor, ref, name, note, points)
+	if err != nil {
+		return nil, err
+	}
+	d := &bulkDecision{ref: ref, change: change}
+	if name == ActionVerify {
+		if next := nextStage(ref); next != nil {
+			d.next = next
+			d.change.NextStage = next.Key
+		}
+	}
+	return d, nil
+}
+
+// applyDecision menyimpan satu keputusan di transaksinya sendiri
+func (s *AchievementService) applyDecision(ctx context.Context, d bulkDecision) error {
+	if d.next != nil {
+		return s.achRepo.AdvanceStage(ctx, d.change, d.next.Key)
+	}
+	return s.achRepo.UpdateStatus(ctx, d.change)
+}
+
+// completeDecision mengisi hasil item yang berhasil lalu memberi tahu mahasiswa
+func (s *AchievementService) completeDecision(result *model.BulkItemResult, d bulkDecision, verifier *model.User) {
+	result.Success, result.Code = true, 200
+	switch {
+	case d.next != nil:
+		result.Status, result.CurrentStage = model.StatusSubmitted, d.next.Key
+		result.Message = "Tahap " + d.ref.CurrentStage.Name + " disetujui, menunggu persetujuan " + d.next.Name
+		notifyStageApproved(d.ref, d.next, verifier)
+	case d.change.Status == model.StatusVerified:
+		points := d.change.Points
+		result.Status, result.Points = model.StatusVerified, &points
+		result.Message = "Prestasi berhasil diverifikasi"
+		notifyVerified(d.ref, points, verifier)
+	default:
+		result.Status = model.StatusRejected
+		result.Message = "Prestasi berhasil ditolak"
+		notifyRejected(d.ref, d.change.Note, verifier)
+	}
+}
+
+// markSkipped menandai item yang lolos pengecekan tetapi tidak disimpan
+// karena item lain gagal (allOrNothing)
+func markSkipped(results []model.BulkItemResult, decisions []bulkDecision) {
+	for _, d := range decisions {
+		results[d.index].Code = 424
+		results[d.index].Message = "Not applied because another item failed (allOrNothing)"
+	}
+}
+
+func bulkResponse(c *fiber.Ctx, code int, message string, action string, allOrNothing bool, results []model.BulkItemResult) error {
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+
+	status := "success"
+	if code >= 400 {
+		status = "error"
+	}
+	return c.Status(code).JSON(model.WebResponse{
+		Code:    code,
+		Status:  status,
+		Message: message,
+		Data: fiber.Map{
+			"action":       action,
+			"allOrNothing": allOrNothing,
+			"total":        len(results),
+			"succeeded":    succeeded,
+			"failed":       len(results) - succeeded,
+			"results":      results,
+		},
+	})
+}
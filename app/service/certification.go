@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CertificationRunResult adalah ringkasan satu kali pengecekan masa berlaku sertifikat
+type CertificationRunResult struct {
+	Checked  int `json:"checked"`
+	Reminded int `json:"reminded"`
+}
+
+// statsFilter membaca ?excludeExpired=true: prestasi dengan sertifikat yang
+// sudah kedaluwarsa tidak ikut dihitung di statistik & total poin
+func statsFilter(c *fiber.Ctx) repository.StatsFilter {
+	return repository.StatsFilter{ExcludeExpired: c.QueryBool("excludeExpired"), Now: time.Now()}
+}
+
+// ProcessCertificationExpiry mengingatkan mahasiswa yang sertifikat
+// terverifikasinya akan habis masa berlaku (sekali per masa berlaku)
+func (s *AchievementService) ProcessCertificationExpiry(ctx context.Context, now time.Time) (CertificationRunResult, error) {
+	var result CertificationRunResult
+
+	expiring, err := s.achRepo.FindExpiringCertifications(ctx, now)
+	if err != nil {
+		return result, err
+	}
+	result.Checked = len(expiring)
+
+	for _, e := range expiring {
+		ok, err := s.achRepo.MarkExpiryReminded(ctx, e.AchievementRefID, now)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			continue
+		}
+		result.Reminded++
+		notify("CERTIFICATION EXPIRING",
+			"To: "+e.Student,
+			"Achievement: "+e.Title,
+			"Valid until: "+e.ValidUntil.Format("2006-01-02"),
+			fmt.Sprintf("Days left: %d", int(e.ValidUntil.Sub(now).Hours()/24)),
+			"Please renew the certification and submit it as a new achievement",
+		)
+	}
+
+	return result, nil
+}
+
+// StartCertificationExpiryJob menyinkronkan masa berlaku data lama sekali,
+// lalu menjalankan ProcessCertificationExpiry setiap interval sampai ctx selesai
+func (s *AchievementService) StartCertificationExpiryJob(ctx context.Context, interval time.Duration) {
+	if synced, err := s.achRepo.SyncValidUntil(ctx); err != nil {
+		log.Printf("[ERROR] Certification validUntil sync failed: %v", err)
+	} else if synced > 0 {
+		log.Printf("[CERTIFICATION] synced validUntil of %d achievements", synced)
+	}
+
+	utils.RunPeriodic(ctx, interval, func(ctx context.Context) {
+		result, err := s.ProcessCertificationExpiry(ctx, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] Certification expiry check failed: %v", err)
+		} else if result.Reminded > 0 {
+			log.Printf("[CERTIFICATION] %d certifications expiring soon, %d students reminded", result.Checked, result.Reminded)
+		}
+	})
+}
+
+// POST /api/v1/reports/certifications/run (Jalankan Pengingat Kedaluwarsa Sekarang - Admin)
+func (s *AchievementService) RunCertificationCheck(c *fiber.Ctx) error {
+	result, err := s.ProcessCertificationExpiry(c.Context(), time.Now())
+	if err != nil {
+		return respondError(c, err, "Failed to run certification expiry check")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: fmt.Sprintf("Certification expiry check finished: %d reminded", result.Reminded),
+		Data:    result,
+	})
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mentionPattern menangkap @username (tanda @ tidak boleh didahului huruf,
+// sehingga alamat email tidak terbaca sebagai mention)
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.\-]+)`)
+
+// parseMentions mengembalikan username unik yang di-mention di body
+func parseMentions(body string) []string {
+	seen := make(map[string]bool)
+	usernames := make([]string, 0)
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames
+}
+
+// validAnchorField: jangkar komentar boleh ke field isi prestasi, termasuk
+// field details (mis. "details.rank") dan custom field tipe baru
+func validAnchorField(path string) bool {
+	switch path {
+	case "title", "description", "tags", "achievementType":
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, "details.")
+	if !ok {
+		return false
+	}
+	if name, ok := strings.CutPrefix(rest, "customFields."); ok {
+		return name != ""
+	}
+	return rest != "customFields" && detailFields()[rest]
+}
+
+// canDiscuss: yang boleh melihat prestasi, ditambah verifikator tahap lain
+// (pemegang permission tahap, pemutus tahap, dan tujuan eskalasi)
+func (s *AchievementService) canDiscuss(c *fiber.Ctx, ref *model.AchievementReference, actor achievementActor) bool {
+	if s.canViewAchievement(c, ref) {
+		return true
+	}
+	for i := range ref.Stages {
+		stage := &ref.Stages[i]
+		if stage.DecidedBy != nil && *stage.DecidedBy == actor.UserID {
+			return true
+		}
+		if canDecideEscalated(stage, actor) {
+			return true
+		}
+		// Tahap dosen wali hanya untuk dosen yang bertanggung jawab (sudah dicek canViewAchievement)
+		if stage.Key != model.StageAdvisor && actor.hasPermission(stage.Permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDiscussion memuat prestasi dan memastikan user boleh ikut diskusi.
+// Jika ditolak, ref bernilai nil dan response error sudah ditulis.
+func (s *AchievementService) loadDiscussion(c *fiber.Ctx, id string) (*model.AchievementReference, *model.Achievement, achievementActor, error) {
+	actor := s.resolveActor(c)
+	ref, content, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return nil, nil, actor, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	if !s.canDiscuss(c, ref, actor) {
+		return nil, nil, actor, c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You cannot access this achievement"})
+	}
+	return ref, content, actor, nil
+}
+
+// loadComment memuat komentar milik prestasi :id
+func (s *AchievementService) loadComment(c *fiber.Ctx, refID string, commentID string) (*model.AchievementComment, error) {
+	comment, err := s.achRepo.FindComment(c.Context(), commentID)
+	if err == nil && comment.AchievementRefID != refID {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Comment not found"})
+	}
+	if err != nil {
+		return nil, respondError(c, err, "Failed to retrieve comment")
+	}
+	return comment, nil
+}
+
+// resolveMentions mencocokkan @username dengan peserta diskusi. Mention ke
+// user yang tidak bisa melihat prestasi ditolak agar tidak ada notifikasi bocor.
+func (s *AchievementService) resolveMentions(ctx context.Context, refID string, body string) ([]model.User, []model.FieldError, error) {
+	usernames := parseMentions(body)
+	if len(usernames) == 0 {
+		return []model.User{}, nil, nil
+	}
+
+	users, err := s.achRepo.FindDiscussionParticipants(ctx, refID, usernames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string]bool, len(users))
+	for _, u := range users {
+		found[u.Username] = true
+	}
+	var errs []model.FieldError
+	for _, name := range usernames {
+		if !found[name] {
+			errs = append(errs, model.FieldError{Field: "body", Rule: "mention", Message: "@" + name + " is not a participant of this discussion"})
+		}
+	}
+	return users, errs, nil
+}
+
+// GET /api/v1/achievements/:id/comments (Thread Diskusi Prestasi)
+func (s *AchievementService) GetComments(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if ref, _, _, errResp := s.loadDiscussion(c, id); ref == nil {
+		return errResp
+	}
+
+	comments, err := s.achRepo.FindComments(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve comments")
+	}
+
+	threads := model.CommentThreads(comments)
+	unresolved := 0
+	for _, t := range threads {
+		if !t.IsResolved {
+			unresolved++
+		}
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Comments retrieved",
+		Data: fiber.Map{
+			"achievementId": id,
+			"total":         len(comments),
+			"unresolved":    unresolved,
+			"threads":       threads,
+		},
+	})
+}
+
+// POST /api/v1/achievements/:id/comments (Tambah Komentar / Balasan)
+// Mahasiswa bisa bertanya (mis. setelah ditolak) sebelum submit ulang;
+// dosen wali & verifikator bisa menjawab atau menandai field/lampiran tertentu.
+func (s *AchievementService) CreateComment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		Body                string  `json:"body" validate:"required,max=5000"`
+		ParentID            *string `json:"parentId" validate:"omitempty,uuid"`
+		AnchorField         *string `json:"anchorField" validate:"omitempty,max=100"`
+		AnchorAttachmentURL *string `json:"anchorAttachmentUrl" validate:"omitempty,max=500"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, content, actor, errResp := s.loadDiscussion(c, id)
+	if ref == nil {
+		return errResp
+	}
+	if ref.Status == model.StatusDeleted {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Cannot comment on a deleted achievement"})
+	}
+
+	comment := model.AchievementComment{
+		AchievementRefID: id,
+		AuthorID:         actor.UserID,
+		Body:             strings.TrimSpace(req.Body),
+		CreatedAt:        time.Now(),
+	}
+
+	var errs []model.FieldError
+	if comment.Body == "" {
+		errs = append(errs, model.FieldError{Field: "body", Rule: "required", Message: "body is required"})
+	}
+	if req.AnchorField != nil {
+		if !validAnchorField(*req.AnchorField) {
+			errs = append(errs, model.FieldError{Field: "anchorField", Rule: "field", Message: "anchorField must be an achievement field such as title or details.rank"})
+		}
+		comment.AnchorField = req.AnchorField
+	}
+	if req.AnchorAttachmentURL != nil {
+		found := false
+		if content != nil {
+			for _, a := range content.Attachments {
+				found = found || a.FileURL == *req.AnchorAttachmentURL
+			}
+		}
+		if !found {
+			errs = append(errs, model.FieldError{Field: "anchorAttachmentUrl", Rule: "attachment", Message: "anchorAttachmentUrl must refer to an attachment of this achievement"})
+		}
+		comment.AnchorAttachmentURL = req.AnchorAttachmentURL
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Balasan untuk balasan ditempelkan ke komentar akarnya
+	if req.ParentID != nil {
+		parent, errResp := s.loadComment(c, id, *req.ParentID)
+		if parent == nil {
+			return errResp
+		}
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+	}
+
+	mentions, errs, err := s.resolveMentions(c.Context(), id, comment.Body)
+	if err != nil {
+		return respondError(c, err, "Failed to add comment")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+	comment.Mentions = mentions
+
+	if err := s.achRepo.CreateComment(c.Context(), &comment); err != nil {
+		return respondError(c, err, "Failed to add comment")
+	}
+
+	s.notifyComment(ref, &comment, actor, mentions)
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Comment added", Data: comment})
+}
+
+// PATCH /api/v1/achievements/:id/comments/:commentId (Ubah Komentar)
+// Hanya penulis, dalam model.CommentEditWindow sejak komentar dibuat.
+func (s *AchievementService) UpdateComment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		Body string `json:"body" validate:"required,max=5000"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, _, actor, errResp := s.loadDiscussion(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	comment, errResp := s.loadComment(c, id, c.Params("commentId"))
+	if comment == nil {
+		return errResp
+	}
+	if !comment.Editable(actor.UserID, time.Now()) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Comments can only be edited by their author within 15 minutes"})
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return validationFailed(c, []model.FieldError{{Field: "body", Rule: "required", Message: "body is required"}})
+	}
+
+	mentions, errs, err := s.resolveMentions(c.Context(), id, body)
+	if err != nil {
+		return respondError(c, err, "Failed to update comment")
+	}
+	if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	// Notifikasi hanya untuk mention yang baru ditambahkan
+	previous := make(map[string]bool)
+	for _, name := range parseMentions(comment.Body) {
+		previous[name] = true
+	}
+	added := make([]model.User, 0)
+	for _, u := range mentions {
+		if !previous[u.Username] {
+			added = append(added, u)
+		}
+	}
+
+	comment.Body, comment.Mentions = body, mentions
+	if err := s.achRepo.UpdateCommentBody(c.Context(), comment, time.Now()); err != nil {
+		return respondError(c, err, "Failed to update comment")
+	}
+
+	s.notifyMentions(ref, comment, added)
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Comment updated", Data: comment})
+}
+
+// POST /api/v1/achievements/:id/comments/:commentId/resolve
+func (s *AchievementService) ResolveComment(c *fiber.Ctx) error {
+	return s.setCommentResolved(c, true)
+}
+
+// POST /api/v1/achievements/:id/comments/:commentId/reopen
+func (s *AchievementService) ReopenComment(c *fiber.Ctx) error {
+	return s.setCommentResolved(c, false)
+}
+
+// setCommentResolved: semua peserta diskusi boleh menandai thread selesai
+func (s *AchievementService) setCommentResolved(c *fiber.Ctx, resolved bool) error {
+	id := c.Params("id")
+
+	ref, _, actor, errResp := s.loadDiscussion(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	comment, errResp := s.loadComment(c, id, c.Params("commentId"))
+	if comment == nil {
+		return errResp
+	}
+	if comment.ParentID != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only top-level comments can be resolved"})
+	}
+
+	now := time.Now()
+	if err := s.achRepo.SetCommentResolved(c.Context(), comment.ID, resolved, actor.UserID, now); err != nil {
+		return respondError(c, err, "Failed to update comment")
+	}
+
+	comment.IsResolved = resolved
+	comment.ResolvedBy, comment.ResolvedAt = nil, nil
+	message := "Comment reopened"
+	if resolved {
+		comment.ResolvedBy, comment.ResolvedAt = &actor.UserID, &now
+		message = "Comment resolved"
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: message, Data: comment})
+}
+
+// notifyComment memberi tahu pihak lain di diskusi: komentar mahasiswa ke
+// dosen wali, komentar dosen/verifikator ke mahasiswa; ditambah user yang di-mention
+func (s *AchievementService) notifyComment(ref *model.AchievementReference, comment *model.AchievementComment, actor achievementActor, mentions []model.User) {
+	if ref.Student != nil {
+		recipient := ""
+		if actor.StudentID == ref.StudentID {
+			if ref.Student.AdvisorID != nil {
+				if advisor, err := s.userRepo.FindLecturerByID(*ref.Student.AdvisorID); err == nil && advisor.User != nil {
+					recipient = advisor.User.FullName + " (dosen wali)"
+				}
+			}
+		} else if ref.Student.User != nil {
+			recipient = ref.Student.User.FullName + " (" + ref.Student.StudentID + ")"
+		}
+		if recipient != "" {
+			notify("ACHIEVEMENT COMMENT",
+				"To: "+recipient,
+				"Achievement: "+ref.Title,
+				"Comment: "+comment.Body,
+			)
+		}
+	}
+	s.notifyMentions(ref, comment, mentions)
+}
+
+func (s *AchievementService) notifyMentions(ref *model.AchievementReference, comment *model.AchievementComment, mentions []model.User) {
+	for _, u := range mentions {
+		if u.ID == comment.AuthorID {
+			continue
+		}
+		notify("COMMENT MENTION",
+			"To: "+u.FullName+" (@"+u.Username+")",
+			"Achievement: "+ref.Title,
+			"Comment: "+comment.Body,
+		)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// detailReasons adalah alasan yang dinilai ulang setiap kali isi prestasi berubah
+var detailReasons = []string{model.DuplicateCertificationNumber, model.DuplicateSimilarDetails}
+
+// detectDuplicates memperbarui sidik jari prestasi lalu menandai prestasi lain
+// yang nomor sertifikatnya sama atau details-nya mirip (skor >= DuplicateThreshold)
+func (s *AchievementService) detectDuplicates(ctx context.Context, refID string, content *model.Achievement) ([]model.DuplicateFlag, error) {
+	fp := model.NewFingerprint(refID, content)
+	if err := s.achRepo.SaveFingerprint(ctx, fp); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.achRepo.FindDuplicateCandidates(ctx, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := []model.DuplicateFlag{}
+	for _, candidate := range candidates {
+		score, reason := fp.Compare(candidate)
+		if score < model.DuplicateThreshold {
+			continue
+		}
+		detail := "Same certification number: " + fp.CertificationNumber
+		if reason == model.DuplicateSimilarDetails {
+			detail = fmt.Sprintf("Similar name, organizer and event date (score %.2f)", score)
+		}
+		flags = append(flags, model.DuplicateFlag{
+			MatchedRefID: candidate.AchievementRefID,
+			Reason:       reason,
+			Score:        math.Round(score*1000) / 1000,
+			Detail:       detail,
+		})
+	}
+
+	if err := s.achRepo.SaveDuplicateFlags(ctx, refID, flags, detailReasons); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// checkDuplicates menjalankan detectDuplicates tanpa menggagalkan request:
+// deteksi hanya membantu verifikator, bukan syarat menyimpan prestasi
+func (s *AchievementService) checkDuplicates(ctx context.Context, ref *model.AchievementReference, content *model.Achievement) {
+	flags, err := s.detectDuplicates(ctx, ref.ID, content)
+	if err != nil {
+		fmt.Printf("[WARNING] Duplicate check failed for achievement %s: %v\n", ref.ID, err)
+		return
+	}
+	notifyDuplicates(ref, content.Title, flags)
+}
+
+// hashAttachment menghitung SHA-256 file yang diunggah
+func hashAttachment(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// notifyDuplicates memberi tahu verifikator bahwa prestasi perlu diperiksa
+func notifyDuplicates(ref *model.AchievementReference, title string, flags []model.DuplicateFlag) {
+	if len(flags) == 0 {
+		return
+	}
+	student := "-"
+	if ref.Student != nil && ref.Student.User != nil {
+		student = ref.Student.User.FullName + " (" + ref.Student.StudentID + ")"
+	}
+	lines := []string{
+		"Student: " + student,
+		"Achievement: " + title,
+	}
+	for _, f := range flags {
+		lines = append(lines, "Possible duplicate of /api/v1/achievements/"+f.MatchedRefID+" ["+f.Reason+"] "+f.Detail)
+	}
+	notify("DUPLICATE SUSPECTED", lines...)
+}
+
+// hideDuplicateFlags: dugaan duplikat bisa menunjuk prestasi mahasiswa lain,
+// sehingga hanya ditampilkan ke dosen/verifikator/Admin
+func hideDuplicateFlags(ref *model.AchievementReference, role string) {
+	if role == "Mahasiswa" {
+		ref.DuplicateFlagCount = 0
+		ref.DuplicateFlags = nil
+	}
+}
+
+// GET /api/v1/reports/duplicates (Laporan Pola Mencurigakan - Admin)
+func (s *AchievementService) GetDuplicateReport(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	report, err := s.achRepo.GetDuplicateReport(c.Context(), limit)
+	if err != nil {
+		return respondError(c, err, "Failed to build duplicate report")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Duplicate report generated", Data: report})
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"log"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// respondError memetakan error dari repository ke status HTTP yang sesuai.
+// Pelanggaran constraint dijawab 409/422 dengan nama field; error lain dicatat
+// di log server dan client hanya menerima pesan umum (tanpa teks error SQL).
+func respondError(c *fiber.Ctx, err error, message string) error {
+	// Status berubah di antara pengecekan dan update (mis. diputuskan dosen lain)
+	if errors.Is(err, repository.ErrStatusConflict) || errors.Is(err, repository.ErrInvalidTransition) {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Achievement status has changed, action is no longer allowed"})
+	}
+	// ETag (If-Match) usang: baris diubah request lain di antara baca & update
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return preconditionFailed(c, 0)
+	}
+	var we *WorkflowError
+	if errors.As(err, &we) {
+		return c.Status(we.Code).JSON(model.WebResponse{Code: we.Code, Status: "error", Message: we.Message})
+	}
+
+	var ce *repository.ConstraintError
+	if errors.As(err, &ce) {
+		switch {
+		case errors.Is(err, repository.ErrDuplicate):
+			return c.Status(409).JSON(model.WebResponse{
+				Code:    409,
+				Status:  "error",
+				Message: ce.Field + " already exists",
+				Errors:  []model.FieldError{{Field: ce.Field, Rule: "unique", Message: ce.Field + " is already in use"}},
+			})
+		case errors.Is(err, repository.ErrInvalidReference):
+			return c.Status(422).JSON(model.WebResponse{
+				Code:    422,
+				Status:  "error",
+				Message: "Validation failed",
+				Errors:  []model.FieldError{{Field: ce.Field, Rule: "exists", Message: ce.Field + " refers to a record that does not exist"}},
+			})
+		default:
+			return c.Status(422).JSON(model.WebResponse{
+				Code:    422,
+				Status:  "error",
+				Message: "Validation failed",
+				Errors:  []model.FieldError{{Field: ce.Field, Rule: "constraint", Message: ce.Field + " has an invalid value"}},
+			})
+		}
+	}
+
+	log.Printf("[ERROR] %s: %v", message, err)
+	return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: message})
+}
+
+// errorStatus adalah pemetaan respondError dalam bentuk kode & pesan, untuk
+// laporan per item pada request bulk (satu response berisi banyak hasil)
+func errorStatus(err error, message string) (int, string) {
+	if errors.Is(err, repository.ErrStatusConflict) || errors.Is(err, repository.ErrInvalidTransition) {
+		return 409, "Achievement status has changed, action is no longer allowed"
+	}
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return 412, "Resource was modified by another request, reload and try again"
+	}
+	var we *WorkflowError
+	if errors.As(err, &we) {
+		return we.Code, we.Message
+	}
+	var ce *repository.ConstraintError
+	if errors.As(err, &ce) {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return 409, ce.Field + " already exists"
+		}
+		return 422, ce.Field + " has an invalid value"
+	}
+
+	log.Printf("[ERROR] %s: %v", message, err)
+	return 500, message
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag mengubah version baris (achievement_references/users) menjadi ETag
+func etag(version int) string {
+	return `"v` + strconv.Itoa(version) + `"`
+}
+
+// contentETag adalah ETag detail prestasi: version ditambah hash body yang
+// dirender. Sebagian isi detail (SLA, masa berlaku sertifikat, kelengkapan
+// bukti, flag duplikat, tim, poin) bisa berubah tanpa version naik.
+func contentETag(version int, body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"v` + strconv.Itoa(version) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagListMatches mengecek apakah header If-Match/If-None-Match memuat ETag
+// version. Dengan weak=false (If-Match) ETag lemah (W/) tidak pernah cocok.
+func etagListMatches(header string, version int, weak bool) bool {
+	return etagListContains(header, etag(version), weak)
+}
+
+// etagListContains mengecek apakah header memuat ETag want. If-Match juga
+// menerima ETag detail ("vN-hash") selama versinya sama, karena hanya version
+// yang dijaga saat update.
+func etagListContains(header string, want string, weak bool) bool {
+	versionPrefix := strings.TrimSuffix(want, `"`) + "-"
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == want || (!weak && strings.HasPrefix(tag, versionPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified memasang ETag pada response GET dan mengembalikan true jika
+// If-None-Match cocok (handler cukup membalas 304 tanpa body)
+func notModified(c *fiber.Ctx, version int) bool {
+	return notModifiedTag(c, etag(version))
+}
+
+// notModifiedTag seperti notModified untuk ETag yang sudah jadi (contentETag)
+func notModifiedTag(c *fiber.Ctx, tag string) bool {
+	c.Set(fiber.HeaderETag, tag)
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && etagListContains(header, tag, true)
+}
+
+// checkIfMatch mewajibkan header If-Match pada update dan mencocokkannya dengan
+// version saat ini: 428 jika tidak dikirim, 412 jika sudah usang. Jika tidak
+// ok, response error sudah ditulis.
+func checkIfMatch(c *fiber.Ctx, version int) (bool, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return false, c.Status(428).JSON(model.WebResponse{Code: 428, Status: "error", Message: "If-Match header is required, use the ETag from the latest GET"})
+	}
+	if !etagListMatches(header, version, false) {
+		return false, preconditionFailed(c, version)
+	}
+	return true, nil
+}
+
+// preconditionFailed menjawab 412 beserta ETag terbaru yang diketahui
+func preconditionFailed(c *fiber.Ctx, version int) error {
+	if version > 0 {
+		c.Set(fiber.HeaderETag, etag(version))
+	}
+	return c.Status(412).JSON(model.WebResponse{Code: 412, Status: "error", Message: "Resource was modified by another request, reload and try again"})
+}
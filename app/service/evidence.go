@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Jenis file yang boleh diunggah sebagai lampiran
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg": true, "image/png": true, "image/jpg": true,
+	"application/pdf": true, "application/msword": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+}
+
+var evidenceKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// checkEvidenceRequirements memvalidasi bukti wajib yang dikirim Admin
+func checkEvidenceRequirements(requirements []model.EvidenceRequirement) []model.FieldError {
+	var errs []model.FieldError
+	known := detailFields()
+	seen := make(map[string]bool, len(requirements))
+
+	for i, req := range requirements {
+		field := fmt.Sprintf("evidenceRequirements[%d]", i)
+		if !evidenceKeyPattern.MatchString(req.Key) || len(req.Key) > 50 {
+			errs = append(errs, model.FieldError{Field: field + ".key", Rule: "format", Message: "key must be lowercase letters, digits or '_' (max 50)"})
+		} else if seen[req.Key] {
+			errs = append(errs, model.FieldError{Field: field + ".key", Rule: "unique", Message: "key must be unique within an achievement type"})
+		}
+		seen[req.Key] = true
+		if req.Label == "" || len(req.Label) > 200 {
+			errs = append(errs, model.FieldError{Field: field + ".label", Rule: "required", Message: "label is required (max 200 characters)"})
+		}
+
+		switch req.Type {
+		case model.EvidenceAttachment:
+			if req.MinCount < 0 || req.MinCount > 20 {
+				errs = append(errs, model.FieldError{Field: field + ".minCount", Rule: "range", Message: "minCount must be between 0 and 20"})
+			}
+			if req.Category != "" && !evidenceKeyPattern.MatchString(req.Category) {
+				errs = append(errs, model.FieldError{Field: field + ".category", Rule: "format", Message: "category must be lowercase letters, digits or '_'"})
+			}
+			for j, fileType := range req.FileTypes {
+				if !fileTypeAllowed(fileType) {
+					errs = append(errs, model.FieldError{Field: fmt.Sprintf("%s.fileTypes[%d]", field, j), Rule: "oneof", Message: fileType + " is not an accepted upload type"})
+				}
+			}
+		case model.EvidenceField:
+			root, _, _ := strings.Cut(req.Field, ".")
+			if !known[root] || req.Field == "customFields" {
+				errs = append(errs, model.FieldError{Field: field + ".field", Rule: "exists", Message: "field must be a details field or customFields.<name>"})
+			}
+			if req.Pattern != "" {
+				if _, err := regexp.Compile(req.Pattern); err != nil {
+					errs = append(errs, model.FieldError{Field: field + ".pattern", Rule: "regex", Message: "pattern is not a valid regular expression"})
+				}
+			}
+		default:
+			errs = append(errs, model.FieldError{Field: field + ".type", Rule: "oneof", Message: "type must be one of: attachment field"})
+		}
+	}
+	return errs
+}
+
+// fileTypeAllowed mengecek jenis file di bukti wajib: MIME type lampiran yang
+// diterima upload, atau wildcard seperti "image/*"
+func fileTypeAllowed(fileType string) bool {
+	if allowedAttachmentTypes[fileType] {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(fileType, "/*"); ok {
+		for allowed := range allowedAttachmentTypes {
+			if strings.HasPrefix(allowed, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAttachmentCategory memastikan kategori lampiran dikenal tipe prestasi:
+// salah satu kategori bukti wajib bertipe attachment
+func (s *AchievementService) checkAttachmentCategory(ctx context.Context, achievementType, category string) ([]model.FieldError, error) {
+	t, err := s.achRepo.FindAchievementType(ctx, achievementType)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []string
+	for _, req := range t.EvidenceRequirements {
+		if req.Type == model.EvidenceAttachment && req.Category != "" {
+			if req.Category == category {
+				return nil, nil
+			}
+			categories = append(categories, req.Category)
+		}
+	}
+	if len(categories) == 0 {
+		return []model.FieldError{{Field: "category", Rule: "oneof", Message: "achievement type " + achievementType + " does not use attachment categories"}}, nil
+	}
+	return []model.FieldError{{Field: "category", Rule: "oneof", Message: "category must be one of: " + strings.Join(categories, " ")}}, nil
+}
+
+// isEvidenceTracked menandai status yang menampilkan indikator kelengkapan
+// bukti (prestasi yang masih bisa dilengkapi lalu diajukan)
+func isEvidenceTracked(status string) bool {
+	return status == model.StatusDraft || status == model.StatusRejected
+}
+
+// attachEvidence mengisi ref.Evidence untuk draft & rejected. Tipe yang tidak
+// lagi terdaftar dianggap tanpa bukti wajib.
+func (s *AchievementService) attachEvidence(ctx context.Context, ref *model.AchievementReference, content *model.Achievement) {
+	if content == nil || !isEvidenceTracked(ref.Status) {
+		return
+	}
+	var requirements []model.EvidenceRequirement
+	if t, err := s.achRepo.FindAchievementType(ctx, content.AchievementType); err == nil {
+		requirements = t.EvidenceFor(ref.CreatedAt)
+	}
+	ref.Evidence = model.EvaluateEvidence(requirements, content)
+}
+
+// attachListEvidence mengisi indikator kelengkapan bukti untuk draft & rejected
+// di satu halaman list. Gagal membaca MongoDB tidak menggagalkan list.
+func (s *AchievementService) attachListEvidence(ctx context.Context, data []model.AchievementReference) {
+	var mongoIDs []string
+	for _, ref := range data {
+		if isEvidenceTracked(ref.Status) {
+			mongoIDs = append(mongoIDs, ref.MongoAchievementID)
+		}
+	}
+	if len(mongoIDs) == 0 {
+		return
+	}
+
+	contents, err := s.achRepo.FindContents(ctx, mongoIDs)
+	if err != nil {
+		fmt.Printf("[WARNING] Evidence check skipped for achievement list: %v\n", err)
+		return
+	}
+	types, err := s.achRepo.FindAchievementTypes(ctx, false)
+	if err != nil {
+		fmt.Printf("[WARNING] Evidence check skipped for achievement list: %v\n", err)
+		return
+	}
+	byKey := make(map[string]*model.AchievementType, len(types))
+	for i := range types {
+		byKey[types[i].Key] = &types[i]
+	}
+
+	for i := range data {
+		content, ok := contents[data[i].MongoAchievementID]
+		if !ok || !isEvidenceTracked(data[i].Status) {
+			continue
+		}
+		var requirements []model.EvidenceRequirement
+		if t, ok := byKey[content.AchievementType]; ok {
+			requirements = t.EvidenceFor(data[i].CreatedAt)
+		}
+		data[i].Evidence = model.EvaluateEvidence(requirements, content)
+	}
+}
+
+// evidenceMissing menjawab 422 berisi checklist bukti yang belum dipenuhi
+func evidenceMissing(c *fiber.Ctx, evidence *model.EvidenceCompleteness) error {
+	var errs []model.FieldError
+	for _, item := range evidence.Items {
+		if !item.Satisfied {
+			errs = append(errs, model.FieldError{Field: "evidence." + item.Key, Rule: "required", Message: item.Missing})
+		}
+	}
+	return c.Status(422).JSON(model.WebResponse{
+		Code:    422,
+		Status:  "error",
+		Message: fmt.Sprintf("Required evidence is missing (%d of %d complete)", evidence.Satisfied, evidence.Required),
+		Data:    evidence,
+		Errors:  errs,
+	})
+}
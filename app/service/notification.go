@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// notify mencetak notifikasi ke log server dengan format yang sama seperti
+// log [NOTIFICATION] sebelumnya. Titik tunggal ini nantinya bisa diganti
+// dengan email / push notification tanpa menyentuh handler.
+func notify(tag string, lines ...string) {
+	fmt.Printf("[%s]\n", tag)
+	for _, line := range lines {
+		fmt.Printf("  - %s\n", line)
+	}
+	fmt.Printf("  - Time: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// Notifikasi keputusan verifikasi dipakai handler tunggal maupun bulk agar
+// keduanya menghasilkan isi yang sama.
+
+// notifyStageApproved: tahap disetujui, prestasi lanjut ke tahap berikutnya
+func notifyStageApproved(ref *model.AchievementReference, next *model.VerificationStage, verifier *model.User) {
+	notify("STAGE APPROVED",
+		"Student: "+studentLabel(ref),
+		"Achievement: "+ref.Title,
+		"Approved stage: "+ref.CurrentStage.Name+" by "+verifier.FullName,
+		"Next stage: "+next.Name+" (permission "+next.Permission+")",
+	)
+}
+
+// notifyVerified: tahap terakhir disetujui dan poin diberikan
+func notifyVerified(ref *model.AchievementReference, points int, verifier *model.User) {
+	notify("VERIFICATION",
+		"Student: "+studentLabel(ref),
+		"Achievement: "+ref.Title,
+		fmt.Sprintf("Points awarded: %d", points),
+		"Verified by: "+verifier.FullName,
+	)
+}
+
+// notifyRejected: prestasi ditolak beserta alasannya
+func notifyRejected(ref *model.AchievementReference, note string, verifier *model.User) {
+	notify("REJECTION",
+		"Student: "+studentLabel(ref),
+		"Achievement: "+ref.Title,
+		"Rejection reason: "+note,
+		"Rejected by: "+verifier.FullName,
+	)
+}
+
+// studentLabel menampilkan "Nama (NIM)" pemilik prestasi, "-" jika tidak dimuat
+func studentLabel(ref *model.AchievementReference) string {
+	if ref.Student == nil || ref.Student.User == nil {
+		return "-"
+	}
+	return ref.Student.User.FullName + " (" + ref.Student.StudentID + ")"
+}
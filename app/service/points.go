@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// suggestPoints menghitung poin dengan rubrik aktif. Mengembalikan nil jika
+// belum ada rubrik aktif.
+func (s *AchievementService) suggestPoints(ctx context.Context, content *model.Achievement) (*model.PointCalculation, error) {
+	rubric, err := s.achRepo.FindActiveRubric(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	calc := rubric.Calculate(content)
+	return &calc, nil
+}
+
+// applySuggestion menyalin hasil rubrik ke reference (kolom suggested_points & rubric_version)
+func applySuggestion(ref *model.AchievementReference, calc *model.PointCalculation) {
+	if calc == nil {
+		ref.SuggestedPoints, ref.RubricVersion = nil, nil
+		return
+	}
+	points, version := calc.Points, calc.RubricVersion
+	ref.SuggestedPoints, ref.RubricVersion = &points, &version
+}
+
+// resolveAwardedPoints menentukan poin final saat verify. requested 0 berarti
+// memakai hasil rubrik; poin yang berbeda dari rubrik wajib disertai justification.
+// Mengembalikan poin, alasan override (kosong jika sesuai rubrik), dan error validasi.
+func resolveAwardedPoints(calc *model.PointCalculation, requested int, justification string) (int, string, []model.FieldError) {
+	justification = strings.TrimSpace(justification)
+	suggested := 0
+	if calc != nil && calc.Rule != nil {
+		suggested = calc.Points
+	}
+
+	if requested == 0 {
+		if calc == nil || calc.Rule == nil {
+			return 0, "", []model.FieldError{{Field: "points", Rule: "required", Message: "no rubric rule matches this achievement; points and justification are required"}}
+		}
+		return suggested, "", nil
+	}
+	if calc != nil && calc.Rule != nil && requested == suggested {
+		return suggested, "", nil
+	}
+	if justification == "" {
+		return 0, "", []model.FieldError{{Field: "justification", Rule: "required", Message: "justification is required when points differ from the rubric"}}
+	}
+	return requested, justification, nil
+}
+
+// ledgerBalance menjumlahkan entri ledger
+func ledgerBalance(entries []model.PointsLedgerEntry) int {
+	total := 0
+	for _, e := range entries {
+		total += e.Points
+	}
+	return total
+}
+
+// GET /api/v1/achievements/:id/points (Riwayat Poin Prestasi dari Ledger)
+func (s *AchievementService) GetAchievementPoints(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	if !s.canViewAchievement(c, ref) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You cannot access this achievement"})
+	}
+
+	entries, err := s.achRepo.FindLedgerByAchievement(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve points ledger")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Points ledger retrieved",
+		Data: fiber.Map{
+			"achievementId": id,
+			"balance":       ledgerBalance(entries),
+			"entries":       entries,
+		},
+	})
+}
+
+// POST /api/v1/achievements/:id/points/adjustments (Koreksi Poin - Admin)
+// Koreksi tidak mengubah award; selisihnya dicatat sebagai entri baru.
+func (s *AchievementService) AdjustPoints(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		Points int    `json:"points" validate:"required,ne=0"`
+		Reason string `json:"reason" validate:"required,max=1000"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, err := s.loadVerifiedAchievement(c, id)
+	if ref == nil {
+		return err
+	}
+
+	return s.recordLedgerEntry(c, ref, model.PointsLedgerEntry{
+		AchievementRefID: id,
+		EntryType:        model.LedgerAdjustment,
+		Points:           req.Points,
+		Reason:           strings.TrimSpace(req.Reason),
+	}, "Points adjusted")
+}
+
+// POST /api/v1/achievements/:id/points/expire (Hapus Sisa Poin - Admin)
+func (s *AchievementService) ExpirePoints(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		Reason string `json:"reason" validate:"required,max=1000"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, err := s.loadVerifiedAchievement(c, id)
+	if ref == nil {
+		return err
+	}
+
+	entries, err := s.achRepo.FindLedgerByAchievement(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to expire points")
+	}
+	balance := ledgerBalance(entries)
+	if balance <= 0 {
+		return c.Status(422).JSON(model.WebResponse{Code: 422, Status: "error", Message: "Achievement has no points left to expire"})
+	}
+
+	if !ref.IsTeam {
+		return s.recordLedgerEntry(c, ref, model.PointsLedgerEntry{
+			AchievementRefID: id,
+			EntryType:        model.LedgerExpiration,
+			Points:           -balance,
+			Reason:           strings.TrimSpace(req.Reason),
+		}, "Points expired")
+	}
+
+	// Prestasi tim: sisa poin setiap anggota dihapus dengan entrinya sendiri,
+	// dicatat dalam satu transaksi
+	userID := c.Locals("user_id").(string)
+	expired := []model.PointsLedgerEntry{}
+	for _, b := range studentBalances(entries) {
+		if b.Points <= 0 {
+			continue
+		}
+		expired = append(expired, model.PointsLedgerEntry{
+			StudentID:        b.StudentID,
+			AchievementRefID: id,
+			EntryType:        model.LedgerExpiration,
+			Points:           -b.Points,
+			Reason:           strings.TrimSpace(req.Reason),
+			ActorID:          &userID,
+		})
+	}
+	if err := s.achRepo.AddLedgerEntries(c.Context(), id, expired); err != nil {
+		return respondError(c, err, "Failed to record points")
+	}
+
+	notify("POINTS "+strings.ToUpper(model.LedgerExpiration),
+		"Team achievement: "+ref.Title,
+		fmt.Sprintf("Members affected: %d", len(expired)),
+		"Reason: "+strings.TrimSpace(req.Reason),
+	)
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Points expired", Data: expired})
+}
+
+// studentBalances menjumlahkan entri ledger per mahasiswa (urutan entri pertama)
+func studentBalances(entries []model.PointsLedgerEntry) []model.MemberPoints {
+	var balances []model.MemberPoints
+	index := make(map[string]int)
+	for _, e := range entries {
+		i, ok := index[e.StudentID]
+		if !ok {
+			i = len(balances)
+			index[e.StudentID] = i
+			balances = append(balances, model.MemberPoints{StudentID: e.StudentID})
+		}
+		balances[i].Points += e.Points
+	}
+	return balances
+}
+
+// POST /api/v1/points/ledger/:entryId/reverse (Batalkan Entri Ledger - Admin)
+func (s *AchievementService) ReverseLedgerEntry(c *fiber.Ctx) error {
+	entryID := c.Params("entryId")
+	var req struct {
+		Reason string `json:"reason" validate:"required,max=1000"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	original, err := s.achRepo.FindLedgerEntry(c.Context(), entryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Ledger entry not found"})
+		}
+		return respondError(c, err, "Failed to reverse ledger entry")
+	}
+
+	ref, _, err := s.achRepo.FindDetail(c.Context(), original.AchievementRefID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+
+	// Jumlah & jenis entri ditentukan repository dari entri asal
+	return s.recordLedgerEntry(c, ref, model.PointsLedgerEntry{
+		AchievementRefID: original.AchievementRefID,
+		ReversesEntryID:  &original.ID,
+		Reason:           strings.TrimSpace(req.Reason),
+	}, "Ledger entry reversed")
+}
+
+// loadVerifiedAchievement: koreksi poin hanya untuk prestasi yang sudah diverifikasi
+func (s *AchievementService) loadVerifiedAchievement(c *fiber.Ctx, id string) (*model.AchievementReference, error) {
+	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return nil, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	if ref.Status != model.StatusVerified {
+		return nil, c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: fmt.Sprintf("Points can only be changed on verified achievements (current status: %s)", ref.Status)})
+	}
+	return ref, nil
+}
+
+// recordLedgerEntry menyimpan entri atas nama user login lalu memberi tahu mahasiswa
+func (s *AchievementService) recordLedgerEntry(c *fiber.Ctx, ref *model.AchievementReference, entry model.PointsLedgerEntry, message string) error {
+	userID := c.Locals("user_id").(string)
+	entry.ActorID = &userID
+
+	if err := s.achRepo.AddLedgerEntry(c.Context(), &entry); err != nil {
+		if errors.Is(err, repository.ErrAlreadyReversed) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Ledger entry has already been reversed"})
+		}
+		return respondError(c, err, "Failed to record points")
+	}
+
+	student := "-"
+	if ref.Student != nil && ref.Student.User != nil {
+		student = ref.Student.User.FullName + " (" + ref.Student.StudentID + ")"
+	}
+	notify("POINTS "+strings.ToUpper(entry.EntryType),
+		"Student: "+student,
+		"Achievement: "+ref.Title,
+		fmt.Sprintf("Points: %+d", entry.Points),
+		"Reason: "+entry.Reason,
+	)
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: message, Data: entry})
+}
+
+// GET /api/v1/students/:id/points (Saldo & Riwayat Poin Mahasiswa)
+// :id adalah user_id mahasiswa (sama seperti GET /students/:id) atau "me".
+// Boleh diakses Admin, mahasiswa itu sendiri, dan dosen walinya.
+func (s *AchievementService) GetStudentPoints(c *fiber.Ctx) error {
+	requestID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+	if requestID == "me" {
+		requestID = userID
+	}
+
+	student, err := s.userRepo.FindStudentByUserID(requestID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+	}
+
+	allowed := false
+	switch c.Locals("role") {
+	case "Admin":
+		allowed = true
+	case "Mahasiswa":
+		allowed = student.UserID == userID
+	case "Dosen Wali":
+		lecturer, err := s.userRepo.FindLecturerByUserID(userID)
+		allowed = err == nil && student.AdvisorID != nil && *student.AdvisorID == lecturer.ID
+	}
+	if !allowed {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Forbidden"})
+	}
+
+	entries, err := s.achRepo.FindLedgerByStudent(c.Context(), student.ID)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve points ledger")
+	}
+
+	// ?excludeExpired=true: poin dari sertifikat kedaluwarsa tidak ikut total
+	total := ledgerBalance(entries)
+	filter := statsFilter(c)
+	if filter.ExcludeExpired {
+		if total, err = s.achRepo.StudentPoints(c.Context(), student.ID, filter); err != nil {
+			return respondError(c, err, "Failed to calculate points")
+		}
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Student points retrieved",
+		Data: fiber.Map{
+			"studentId":      student.ID,
+			"nim":            student.StudentID,
+			"totalPoints":    total,
+			"excludeExpired": filter.ExcludeExpired,
+			"entries":        entries,
+		},
+	})
+}
+
+// GET /api/v1/points/leaderboard?limit=10&programStudy= (Peringkat Poin Mahasiswa)
+func (s *AchievementService) GetLeaderboard(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	board, err := s.achRepo.GetLeaderboard(c.Context(), limit, c.Query("programStudy"), statsFilter(c))
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve leaderboard")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Leaderboard retrieved", Data: board})
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SLARunResult adalah ringkasan satu kali pengecekan SLA
+type SLARunResult struct {
+	Checked   int `json:"checked"`
+	Reminded  int `json:"reminded"`
+	Escalated int `json:"escalated"`
+}
+
+// ProcessSLAs memeriksa tahap yang lewat jatuh tempo: kirim pengingat sekali,
+// lalu eskalasi jika sudah melewati batas eskalasi. Aman dijalankan bersamaan
+// karena repository hanya menandai tahap yang belum ditandai.
+func (s *AchievementService) ProcessSLAs(ctx context.Context, now time.Time) (SLARunResult, error) {
+	var result SLARunResult
+
+	breaches, err := s.achRepo.FindSLABreaches(ctx, now)
+	if err != nil {
+		return result, err
+	}
+	result.Checked = len(breaches)
+
+	for _, b := range breaches {
+		if !now.Before(b.SLA.EscalateAt(*b.Stage.StartedAt)) {
+			ok, err := s.achRepo.EscalateStage(ctx, b.Stage.ID, b.SLA, now)
+			if err != nil {
+				return result, err
+			}
+			if ok {
+				result.Escalated++
+				s.notifyEscalation(b)
+			}
+			continue
+		}
+
+		if b.Stage.RemindedAt != nil {
+			continue
+		}
+		ok, err := s.achRepo.MarkStageReminded(ctx, b.Stage.ID, now)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			result.Reminded++
+			notify("SLA REMINDER",
+				"To: "+stageAssignee(b),
+				"Student: "+b.Student,
+				"Achievement: "+b.Title,
+				"Stage: "+b.Stage.Name,
+				"Due: "+b.SLA.DueAt(*b.Stage.StartedAt).Format("2006-01-02 15:04"),
+				"Escalation: "+b.SLA.EscalateAt(*b.Stage.StartedAt).Format("2006-01-02 15:04"),
+			)
+		}
+	}
+
+	return result, nil
+}
+
+// stageAssignee: dosen wali untuk tahap advisor, selain itu pemegang permission tahap
+func stageAssignee(b model.SLABreach) string {
+	if b.Stage.Key == model.StageAdvisor && b.AdvisorName != "" {
+		return b.AdvisorName + " (dosen wali)"
+	}
+	return "pemegang permission " + b.Stage.Permission
+}
+
+func (s *AchievementService) notifyEscalation(b model.SLABreach) {
+	var targets []string
+	if b.SLA.EscalationPermission != nil {
+		targets = append(targets, "pemegang permission "+*b.SLA.EscalationPermission)
+	}
+	if b.SLA.DelegateUserID != nil {
+		name := *b.SLA.DelegateUserID
+		if user, err := s.userRepo.FindByID(name); err == nil {
+			name = user.FullName
+		}
+		targets = append(targets, name+" (delegasi)")
+	}
+
+	notify("SLA ESCALATION",
+		"To: "+strings.Join(targets, ", "),
+		"Cc: "+stageAssignee(b),
+		"Student: "+b.Student,
+		"Achievement: "+b.Title,
+		"Stage: "+b.Stage.Name+" (menunggu sejak "+b.Stage.StartedAt.Format("2006-01-02 15:04")+")",
+	)
+}
+
+// StartSLAJob menjalankan ProcessSLAs setiap interval sampai ctx selesai
+func (s *AchievementService) StartSLAJob(ctx context.Context, interval time.Duration) {
+	utils.RunPeriodic(ctx, interval, func(ctx context.Context) {
+		result, err := s.ProcessSLAs(ctx, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] SLA check failed: %v", err)
+		} else if result.Reminded > 0 || result.Escalated > 0 {
+			log.Printf("[SLA] checked %d overdue stages: %d reminded, %d escalated", result.Checked, result.Reminded, result.Escalated)
+		}
+	})
+}
+
+// GET /api/v1/verification/slas (Konfigurasi SLA per Tahap - Admin)
+func (s *AchievementService) GetSLAs(c *fiber.Ctx) error {
+	slas, err := s.achRepo.FindSLAs(c.Context())
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve verification SLAs")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verification SLAs retrieved", Data: slas})
+}
+
+// PUT /api/v1/verification/slas/:stageKey (Buat/Ganti SLA Tahap - Admin)
+func (s *AchievementService) UpdateSLA(c *fiber.Ctx) error {
+	var req model.VerificationSLA
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	req.StageKey = c.Params("stageKey")
+	if req.StageKey == "" || len(req.StageKey) > 50 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid stage key"})
+	}
+	if req.EscalationPermission == nil && req.DelegateUserID == nil {
+		return validationFailed(c, []model.FieldError{{
+			Field: "escalationPermission", Rule: "required_without", Message: "escalationPermission or delegateUserId is required",
+		}})
+	}
+
+	if err := s.achRepo.SaveSLA(c.Context(), &req); err != nil {
+		return respondError(c, err, "Failed to save verification SLA")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verification SLA saved", Data: req})
+}
+
+// DELETE /api/v1/verification/slas/:stageKey (Hapus SLA Tahap - Admin)
+func (s *AchievementService) DeleteSLA(c *fiber.Ctx) error {
+	if err := s.achRepo.DeleteSLA(c.Context(), c.Params("stageKey")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Verification SLA not found"})
+		}
+		return respondError(c, err, "Failed to delete verification SLA")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verification SLA deleted"})
+}
+
+// POST /api/v1/verification/slas/run (Jalankan Pengecekan SLA Sekarang - Admin)
+func (s *AchievementService) RunSLACheck(c *fiber.Ctx) error {
+	result, err := s.ProcessSLAs(c.Context(), time.Now())
+	if err != nil {
+		return respondError(c, err, "Failed to run SLA check")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: fmt.Sprintf("SLA check finished: %d reminded, %d escalated", result.Reminded, result.Escalated),
+		Data:    result,
+	})
+}
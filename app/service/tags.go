@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// normalizeTags menormalisasi tags yang diisi mahasiswa (huruf kecil, '-',
+// tanpa duplikat) lalu mengganti sinonim dengan tag kanoniknya. Tag di luar
+// kosakata tetap disimpan apa adanya (sudah dinormalisasi).
+func (s *AchievementService) normalizeTags(ctx context.Context, content *model.Achievement) ([]model.FieldError, error) {
+	tags := model.NormalizeTags(content.Tags)
+
+	var errs []model.FieldError
+	for i, tag := range tags {
+		if len(tag) > model.MaxTagLength {
+			errs = append(errs, model.FieldError{
+				Field: fmt.Sprintf("tags[%d]", i), Rule: "max", Message: fmt.Sprintf("tag must be at most %d characters", model.MaxTagLength),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	synonyms, err := s.achRepo.ResolveTagSynonyms(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	for i, tag := range tags {
+		if key, ok := synonyms[tag]; ok {
+			tags[i] = key
+		}
+	}
+
+	// Dua sinonim dari tag yang sama menjadi satu
+	content.Tags = model.NormalizeTags(tags)
+	return nil, nil
+}
+
+// GET /api/v1/tags (Kosakata Tag)
+// Admin bisa menyertakan tag nonaktif dengan ?includeInactive=true
+func (s *AchievementService) GetTags(c *fiber.Ctx) error {
+	admin := achievementActor{Permissions: localPermissions(c)}.hasPermission("user:manage")
+	activeOnly := !(admin && c.QueryBool("includeInactive"))
+
+	tags, err := s.achRepo.FindTags(c.Context(), activeOnly)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve tags")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Tags retrieved", Data: tags})
+}
+
+// GET /api/v1/tags/suggest?q=&limit= (Autocomplete Tag)
+func (s *AchievementService) SuggestTags(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := s.achRepo.SuggestTags(c.Context(), c.Query("q"), limit)
+	if err != nil {
+		return respondError(c, err, "Failed to suggest tags")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Tag suggestions retrieved", Data: suggestions})
+}
+
+// GET /api/v1/tags/:key
+func (s *AchievementService) GetTag(c *fiber.Ctx) error {
+	t, err := s.achRepo.FindTag(c.Context(), c.Params("key"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Tag not found"})
+		}
+		return respondError(c, err, "Failed to retrieve tag")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Tag retrieved", Data: t})
+}
+
+// tagRequest adalah body POST/PUT tag
+type tagRequest struct {
+	Key         string   `json:"key" validate:"max=50"`
+	Name        string   `json:"name" validate:"required,max=100"`
+	ParentKey   *string  `json:"parentKey" validate:"omitempty,max=50"`
+	Description string   `json:"description" validate:"max=1000"`
+	Synonyms    []string `json:"synonyms" validate:"max=50"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+// POST /api/v1/tags (Tambah Tag - Admin)
+func (s *AchievementService) CreateTag(c *fiber.Ctx) error {
+	var req tagRequest
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	key := req.Key
+	if key == "" {
+		return validationFailed(c, []model.FieldError{{Field: "key", Rule: "required", Message: "key is required"}})
+	}
+	if model.NormalizeTag(key) != key {
+		return validationFailed(c, []model.FieldError{{Field: "key", Rule: "format", Message: "key must use lowercase letters, digits, '+', '#', '.' or '-', e.g. " + model.NormalizeTag(key)}})
+	}
+
+	t, errs, err := s.buildTag(c.Context(), key, req)
+	if err != nil {
+		return respondError(c, err, "Failed to create tag")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	if err := s.achRepo.CreateTag(c.Context(), t); err != nil {
+		return respondError(c, err, "Failed to create tag")
+	}
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Tag created", Data: t})
+}
+
+// PUT /api/v1/tags/:key (Ubah Tag, Induk & Sinonim - Admin)
+func (s *AchievementService) UpdateTag(c *fiber.Ctx) error {
+	var req tagRequest
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	key := c.Params("key")
+	if _, err := s.achRepo.FindTag(c.Context(), key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Tag not found"})
+		}
+		return respondError(c, err, "Failed to update tag")
+	}
+
+	t, errs, err := s.buildTag(c.Context(), key, req)
+	if err != nil {
+		return respondError(c, err, "Failed to update tag")
+	} else if len(errs) > 0 {
+		return validationFailed(c, errs)
+	}
+
+	if err := s.achRepo.UpdateTag(c.Context(), t); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Tag not found"})
+		}
+		return respondError(c, err, "Failed to update tag")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Tag updated", Data: t})
+}
+
+// buildTag menyusun tag dari request dan memeriksa aturan kosakata: induk harus
+// ada dan tidak membentuk siklus, sinonim tidak boleh sama dengan key tag lain,
+// dan key tag baru tidak boleh sudah dipakai sebagai sinonim tag lain.
+func (s *AchievementService) buildTag(ctx context.Context, key string, req tagRequest) (*model.Tag, []model.FieldError, error) {
+	t := &model.Tag{Key: key, Name: req.Name, Description: req.Description, Synonyms: []string{}, IsActive: true}
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+
+	var errs []model.FieldError
+	if req.ParentKey != nil && *req.ParentKey != "" {
+		parent := *req.ParentKey
+		if _, err := s.achRepo.FindTag(ctx, parent); errors.Is(err, sql.ErrNoRows) {
+			errs = append(errs, model.FieldError{Field: "parentKey", Rule: "exists", Message: "parentKey must be a registered tag"})
+		} else if err != nil {
+			return nil, nil, err
+		} else if cycle, err := s.achRepo.IsTagDescendant(ctx, key, parent); err != nil {
+			return nil, nil, err
+		} else if cycle {
+			errs = append(errs, model.FieldError{Field: "parentKey", Rule: "cycle", Message: "parentKey cannot be the tag itself or one of its descendants"})
+		}
+		t.ParentKey = &parent
+	}
+
+	// Sinonim dinormalisasi seperti tag dari mahasiswa
+	for _, synonym := range model.NormalizeTags(req.Synonyms) {
+		if synonym != key {
+			t.Synonyms = append(t.Synonyms, synonym)
+		}
+	}
+	for i, synonym := range t.Synonyms {
+		if len(synonym) > model.MaxTagLength {
+			errs = append(errs, model.FieldError{Field: fmt.Sprintf("synonyms[%d]", i), Rule: "max", Message: fmt.Sprintf("synonym must be at most %d characters", model.MaxTagLength)})
+		}
+	}
+
+	existing, err := s.achRepo.FindExistingTagKeys(ctx, t.Synonyms)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, synonym := range existing {
+		errs = append(errs, model.FieldError{Field: "synonyms", Rule: "unique", Message: synonym + " is already a tag key"})
+	}
+
+	resolved, err := s.achRepo.ResolveTagSynonyms(ctx, append([]string{key}, t.Synonyms...))
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner, ok := resolved[key]; ok {
+		errs = append(errs, model.FieldError{Field: "key", Rule: "unique", Message: key + " is already a synonym of " + owner})
+	}
+	for _, synonym := range t.Synonyms {
+		if owner, ok := resolved[synonym]; ok && owner != key {
+			errs = append(errs, model.FieldError{Field: "synonyms", Rule: "unique", Message: synonym + " is already a synonym of " + owner})
+		}
+	}
+
+	return t, errs, nil
+}
+
+// SyncLegacyTags menyalin tags prestasi lama dari MongoDB ke Postgres agar
+// ikut terbaca filter dan statistik tag. Dijalankan sekali saat aplikasi start.
+func (s *AchievementService) SyncLegacyTags(ctx context.Context) {
+	if synced, err := s.achRepo.SyncTags(ctx); err != nil {
+		log.Printf("[ERROR] Tag sync failed: %v", err)
+	} else if synced > 0 {
+		log.Printf("[TAGS] synced tags of %d achievements", synced)
+	}
+}
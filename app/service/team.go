@@ -0,0 +1,298 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// teamVerificationOrDefault: tipe tanpa kebijakan tim diverifikasi oleh dosen wali ketua
+func teamVerificationOrDefault(policy string) string {
+	if policy == "" {
+		return model.TeamVerificationLeader
+	}
+	return policy
+}
+
+// findTeamMember mengembalikan keanggotaan mahasiswa di tim (nil jika bukan anggota)
+func findTeamMember(ref *model.AchievementReference, studentID string) *model.TeamMember {
+	for i := range ref.Team {
+		if ref.Team[i].StudentID == studentID {
+			return &ref.Team[i]
+		}
+	}
+	return nil
+}
+
+// perMemberApproval: tahap dosen wali prestasi tim dengan kebijakan per_member
+// baru selesai setelah dosen wali setiap anggota menyetujui
+func perMemberApproval(ref *model.AchievementReference) bool {
+	if !ref.IsTeam || ref.TeamVerification == nil || *ref.TeamVerification != model.TeamVerificationPerMember {
+		return false
+	}
+	return ref.CurrentStage == nil || ref.CurrentStage.Key == model.StageAdvisor
+}
+
+// pendingTeamApprovals mengembalikan anggota yang persetujuannya bisa diberikan
+// actor: dosen wali anggota tersebut, dosen yang bertanggung jawab atas ketua,
+// atau pemutus tahap yang dieskalasi (semua anggota).
+func pendingTeamApprovals(ref *model.AchievementReference, actor achievementActor) []string {
+	escalated := ref.CurrentStage != nil && canDecideEscalated(ref.CurrentStage, actor)
+	ids := []string{}
+	for _, m := range ref.Team {
+		if m.Status != model.MemberConfirmed || m.AdvisorApprovedAt != nil {
+			continue
+		}
+		switch {
+		case escalated:
+		case actor.LecturerID == "":
+			continue
+		case m.Role == model.TeamRoleLeader:
+			if !isResponsibleVerifier(ref, actor.LecturerID) {
+				continue
+			}
+		case m.Student == nil || m.Student.AdvisorID == nil || *m.Student.AdvisorID != actor.LecturerID:
+			continue
+		}
+		ids = append(ids, m.StudentID)
+	}
+	return ids
+}
+
+// advisesTeamMember mengecek apakah dosen adalah dosen wali salah satu anggota
+// tim yang sudah konfirmasi
+func advisesTeamMember(ref *model.AchievementReference, lecturerID string) bool {
+	for _, m := range ref.Team {
+		if m.Status == model.MemberConfirmed && m.Student != nil && m.Student.AdvisorID != nil && *m.Student.AdvisorID == lecturerID {
+			return true
+		}
+	}
+	return false
+}
+
+// teamAwards membagi poin final ke anggota tim sesuai aturan rubrik yang
+// dipakai (nil untuk prestasi individu)
+func teamAwards(ref *model.AchievementReference, calc *model.PointCalculation, points int) []model.MemberPoints {
+	if !ref.IsTeam {
+		return nil
+	}
+	mode := model.TeamPointsDuplicate
+	if calc != nil && calc.Rule != nil && calc.Rule.TeamPoints != "" {
+		mode = calc.Rule.TeamPoints
+	}
+	return model.TeamAwards(ref.Team, points, mode)
+}
+
+// checkTeamReady: prestasi tim hanya bisa diajukan setelah semua anggota merespons undangan
+func checkTeamReady(ref *model.AchievementReference) []model.FieldError {
+	var errs []model.FieldError
+	for _, m := range ref.Team {
+		if m.Status == model.MemberPending {
+			nim := m.StudentID
+			if m.Student != nil {
+				nim = m.Student.StudentID
+			}
+			errs = append(errs, model.FieldError{Field: "team", Rule: "confirmed", Message: "Team member " + nim + " has not confirmed membership"})
+		}
+	}
+	return errs
+}
+
+// GET /api/v1/achievements/:id/team (Anggota Prestasi Tim)
+func (s *AchievementService) GetTeam(c *fiber.Ctx) error {
+	ref, _, err := s.achRepo.FindDetail(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	if !s.canViewAchievement(c, ref) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You cannot access this achievement"})
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Team retrieved",
+		Data: fiber.Map{
+			"isTeam":           ref.IsTeam,
+			"teamVerification": ref.TeamVerification,
+			"members":          ref.Team,
+		},
+	})
+}
+
+// PUT /api/v1/achievements/:id/team (Atur Anggota Prestasi Tim)
+// Hanya ketua (pemilik) selama prestasi masih bisa diedit. Anggota baru harus
+// mengonfirmasi; daftar kosong mengembalikan prestasi menjadi individu.
+func (s *AchievementService) UpdateTeam(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		// NIM anggota selain ketua (maksimal 20). Boleh kosong: kembali ke individu
+		Members []string `json:"members" validate:"max=20,dive,max=20"`
+	}
+
+	if ok, err := parseAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ref, _, _, errResp := s.authorizeAction(c, id, ActionEdit)
+	if ref == nil {
+		return errResp
+	}
+
+	// Normalisasi NIM & tolak duplikat / ketua sendiri
+	nims := make([]string, 0, len(req.Members))
+	seen := make(map[string]bool, len(req.Members))
+	for i, nim := range req.Members {
+		nim = strings.TrimSpace(nim)
+		if nim == "" {
+			field := fmt.Sprintf("members[%d]", i)
+			return validationFailed(c, []model.FieldError{{Field: field, Rule: "required", Message: field + " is required"}})
+		}
+		if nim == ref.Student.StudentID {
+			return validationFailed(c, []model.FieldError{{Field: "members", Rule: "leader", Message: "The team leader cannot be listed as a member"}})
+		}
+		if !seen[nim] {
+			seen[nim] = true
+			nims = append(nims, nim)
+		}
+	}
+
+	memberIDs := make([]string, 0, len(nims))
+	if len(nims) > 0 {
+		students, err := s.userRepo.FindStudentsByNIM(nims)
+		if err != nil {
+			return respondError(c, err, "Failed to look up team members")
+		}
+		byNIM := make(map[string]string, len(students))
+		for _, st := range students {
+			byNIM[st.StudentID] = st.ID
+		}
+		var errs []model.FieldError
+		for _, nim := range nims {
+			studentID, ok := byNIM[nim]
+			if !ok {
+				errs = append(errs, model.FieldError{Field: "members", Rule: "exists", Message: "Student " + nim + " is not registered"})
+				continue
+			}
+			memberIDs = append(memberIDs, studentID)
+		}
+		if len(errs) > 0 {
+			return validationFailed(c, errs)
+		}
+	}
+
+	if err := s.achRepo.ReplaceTeam(c.Context(), id, ref.StudentID, memberIDs); err != nil {
+		return respondError(c, err, "Failed to update team")
+	}
+
+	team, err := s.achRepo.FindTeam(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve team")
+	}
+
+	leader := ref.Student.User.FullName + " (" + ref.Student.StudentID + ")"
+	for _, m := range team {
+		if m.Status == model.MemberPending && m.Student != nil {
+			notify("TEAM INVITATION",
+				"To: "+m.Student.User.FullName+" ("+m.Student.StudentID+")",
+				"Achievement: "+ref.Title,
+				"Invited by: "+leader,
+			)
+		}
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Team updated",
+		Data:    fiber.Map{"isTeam": len(memberIDs) > 0, "members": team},
+	})
+}
+
+// POST /api/v1/achievements/:id/team/confirm (Konfirmasi Keanggotaan Tim)
+func (s *AchievementService) ConfirmTeamMembership(c *fiber.Ctx) error {
+	return s.respondTeamInvite(c, model.MemberConfirmed)
+}
+
+// POST /api/v1/achievements/:id/team/decline (Tolak Keanggotaan Tim)
+func (s *AchievementService) DeclineTeamMembership(c *fiber.Ctx) error {
+	return s.respondTeamInvite(c, model.MemberDeclined)
+}
+
+// respondTeamInvite: anggota merespons undangan selama prestasi belum diajukan
+// (draft/rejected); setelah itu daftar anggota dikunci sampai direvisi
+func (s *AchievementService) respondTeamInvite(c *fiber.Ctx, status string) error {
+	id := c.Params("id")
+	actor := s.resolveActor(c)
+
+	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	member := findTeamMember(ref, actor.StudentID)
+	if actor.StudentID == "" || member == nil || member.Role != model.TeamRoleMember {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You are not a member of this team"})
+	}
+	if ref.Status != model.StatusDraft && ref.Status != model.StatusRejected {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Team membership can only change while achievement is 'draft' or 'rejected' (current status: " + ref.Status + ")"})
+	}
+
+	now := time.Now()
+	if err := s.achRepo.RespondTeamInvite(c.Context(), id, actor.StudentID, status, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You are not a member of this team"})
+		}
+		return respondError(c, err, "Failed to update team membership")
+	}
+
+	member.Status, member.RespondedAt = status, &now
+	notify("TEAM MEMBERSHIP "+strings.ToUpper(status),
+		"To: "+ref.Student.User.FullName+" ("+ref.Student.StudentID+")",
+		"Achievement: "+ref.Title,
+		"Member: "+member.Student.User.FullName+" ("+member.Student.StudentID+")",
+	)
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Team membership " + status, Data: member})
+}
+
+// approveTeamMembers mencatat persetujuan dosen wali untuk anggota tim
+// (kebijakan per_member). done=false berarti masih ada dosen wali anggota lain
+// yang belum menyetujui dan response sudah ditulis.
+func (s *AchievementService) approveTeamMembers(c *fiber.Ctx, ref *model.AchievementReference, actor achievementActor, verifier *model.User) (bool, error) {
+	ids := pendingTeamApprovals(ref, actor)
+	if len(ids) == 0 {
+		return false, c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Your approval has already been recorded; waiting for the other members' advisors"})
+	}
+
+	remaining, err := s.achRepo.ApproveTeamMembers(c.Context(), ref.ID, ids, actor.UserID, time.Now())
+	if err != nil {
+		return false, respondError(c, err, "Failed to approve team members")
+	}
+	if remaining == 0 {
+		return true, nil
+	}
+
+	notify("TEAM MEMBERS APPROVED",
+		"Achievement: "+ref.Title,
+		"Approved by: "+verifier.FullName,
+		fmt.Sprintf("Members waiting for advisor approval: %d", remaining),
+	)
+
+	return false, c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Persetujuan dosen wali tercatat, menunggu dosen wali anggota lain",
+		Data: fiber.Map{
+			"id":               ref.ID,
+			"status":           model.StatusSubmitted,
+			"approvedMembers":  ids,
+			"remainingMembers": remaining,
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadDir adalah folder penyimpanan lampiran (lihat UploadAttachment)
+const uploadDir = "./uploads"
+
+// purgeBatchSize membatasi jumlah prestasi yang dihapus permanen per putaran job
+const purgeBatchSize = 100
+
+// TrashPurgeResult adalah ringkasan satu kali purge tempat sampah
+type TrashPurgeResult struct {
+	Purged       int `json:"purged"`
+	Failed       int `json:"failed"`
+	FilesRemoved int `json:"filesRemoved"`
+}
+
+// GET /api/v1/achievements/trash (Tempat Sampah Mahasiswa)
+func (s *AchievementService) GetTrash(c *fiber.Ctx) error {
+	actor := s.resolveActor(c)
+	if actor.StudentID == "" {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: Only students have a trash"})
+	}
+
+	refs, err := s.achRepo.FindTrash(c.Context(), actor.StudentID)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve trash")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Trash retrieved", Data: refs})
+}
+
+// POST /api/v1/achievements/:id/restore (Pulihkan Draft dari Tempat Sampah)
+// Hanya pemilik, dan hanya sebelum masa retensi (TrashRetention) habis.
+func (s *AchievementService) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	actor := s.resolveActor(c)
+
+	ref, err := s.achRepo.FindDeleted(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found in trash"})
+		}
+		return respondError(c, err, "Failed to retrieve achievement")
+	}
+
+	if _, werr := checkAction(ref, actor, ActionRestore); werr != nil {
+		return c.Status(werr.Code).JSON(model.WebResponse{Code: werr.Code, Status: "error", Message: werr.Message})
+	}
+	if ref.PurgeAt != nil && !time.Now().Before(*ref.PurgeAt) {
+		return c.Status(410).JSON(model.WebResponse{Code: 410, Status: "error", Message: "Retention period has expired, achievement can no longer be restored"})
+	}
+
+	if err := s.achRepo.Restore(c.Context(), id, actor.UserID); err != nil {
+		return respondError(c, err, "Failed to restore achievement")
+	}
+
+	notify("RESTORED",
+		"Achievement: "+ref.Title,
+		"Status: "+model.StatusDeleted+" -> "+model.StatusDraft,
+	)
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Prestasi berhasil dipulihkan",
+		Data: fiber.Map{
+			"id":     id,
+			"status": model.StatusDraft,
+		},
+	})
+}
+
+// PurgeTrash menghapus permanen prestasi yang sudah melewati masa retensi:
+// baris Postgres, dokumen MongoDB, dan file lampiran yang tidak dipakai lagi.
+// Kegagalan satu prestasi dicatat lalu dilanjutkan ke prestasi berikutnya.
+func (s *AchievementService) PurgeTrash(ctx context.Context, now time.Time) (TrashPurgeResult, error) {
+	var result TrashPurgeResult
+
+	ids, err := s.achRepo.FindExpiredTrash(ctx, now.Add(-model.TrashRetention), purgeBatchSize)
+	if err != nil {
+		return result, err
+	}
+
+	for _, id := range ids {
+		files, err := s.achRepo.Purge(ctx, id)
+		if err != nil {
+			result.Failed++
+			log.Printf("[ERROR] Failed to purge achievement %s: %v", id, err)
+			continue
+		}
+		result.Purged++
+		result.FilesRemoved += removeUploads(files)
+	}
+
+	return result, nil
+}
+
+// removeUploads menghapus file lampiran berdasarkan URL-nya (/uploads/<nama>).
+// Hanya nama file yang dipakai agar URL tidak bisa menunjuk ke luar uploadDir.
+func removeUploads(urls []string) int {
+	removed := 0
+	for _, url := range urls {
+		if !strings.HasPrefix(url, "/uploads/") {
+			continue
+		}
+		name := filepath.Base(url)
+		if err := os.Remove(filepath.Join(uploadDir, name)); err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("[WARNING] Failed to remove attachment %s: %v", name, err)
+			}
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// StartTrashPurgeJob menjalankan PurgeTrash setiap interval sampai ctx selesai
+func (s *AchievementService) StartTrashPurgeJob(ctx context.Context, interval time.Duration) {
+	utils.RunPeriodic(ctx, interval, func(ctx context.Context) {
+		result, err := s.PurgeTrash(ctx, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] Trash purge failed: %v", err)
+		} else if result.Purged > 0 || result.Failed > 0 {
+			log.Printf("[TRASH] purged %d achievements (%d failed), removed %d files", result.Purged, result.Failed, result.FilesRemoved)
+		}
+	})
+}
+
+// POST /api/v1/achievements/trash/purge (Jalankan Purge Sekarang - Admin)
+func (s *AchievementService) RunTrashPurge(c *fiber.Ctx) error {
+	result, err := s.PurgeTrash(c.Context(), time.Now())
+	if err != nil {
+		return respondError(c, err, "Failed to purge trash")
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: fmt.Sprintf("Trash purge finished: %d purged, %d failed", result.Purged, result.Failed),
+		Data:    result,
+	})
+}
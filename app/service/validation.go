@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validationFailed mengirim response 422 dengan daftar error per field
+func validationFailed(c *fiber.Ctx, errs []model.FieldError) error {
+	return c.Status(422).JSON(model.WebResponse{
+		Code:    422,
+		Status:  "error",
+		Message: "Validation failed",
+		Errors:  errs,
+	})
+}
+
+// parseAndValidate membaca body request ke dst lalu menjalankan tag `validate`.
+// Jika gagal, response error sudah ditulis dan ok bernilai false; handler cukup
+// mengembalikan err.
+func parseAndValidate(c *fiber.Ctx, dst interface{}) (ok bool, err error) {
+	if err := c.BodyParser(dst); err != nil {
+		return false, c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input: " + err.Error()})
+	}
+	if errs := utils.ValidateStruct(dst); len(errs) > 0 {
+		return false, validationFailed(c, errs)
+	}
+	return true, nil
+}
+
+// detailFields adalah nama field JSON di model.AchievementDetails. Schema tipe
+// prestasi hanya boleh mendefinisikan field ini di root; field tambahan milik
+// tipe baru ditaruh di dalam customFields.
+func detailFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(model.AchievementDetails{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}
+
+// checkDetailSchema memvalidasi schema `details` yang dikirim Admin
+func checkDetailSchema(schema *model.DetailSchema) []model.FieldError {
+	if schema.Type != "object" {
+		return []model.FieldError{{Field: "schema.type", Rule: "oneof", Message: "schema.type must be object"}}
+	}
+
+	errs := utils.CheckSchema(schema, "schema")
+	known := detailFields()
+	for key := range schema.Properties {
+		if !known[key] {
+			errs = append(errs, model.FieldError{
+				Field:   "schema.properties." + key,
+				Rule:    "unknown",
+				Message: fmt.Sprintf("'%s' is not a details field; define custom fields under properties.customFields", key),
+			})
+		}
+	}
+	return errs
+}
+
+// validateDetails memeriksa achievementType terdaftar lalu memvalidasi `details`
+// terhadap schema tipe tersebut. Tipe nonaktif hanya boleh dipakai oleh prestasi
+// yang memang sudah bertipe itu (currentType).
+func (s *AchievementService) validateDetails(ctx context.Context, content *model.Achievement, currentType string) ([]model.FieldError, error) {
+	t, err := s.achRepo.FindAchievementType(ctx, content.AchievementType)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !t.IsActive && content.AchievementType != currentType) {
+		return []model.FieldError{{Field: "achievementType", Rule: "exists", Message: "achievementType must be a registered achievement type"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ValidateSchema(&t.Schema, detailsDocument(content.Details), "details"), nil
+}
+
+// detailsDocument mengubah details ke bentuk JSON generik untuk validasi schema.
+// Tanggal zero value (mis. period.start yang tidak dikirim) dianggap kosong.
+func detailsDocument(details model.AchievementDetails) map[string]interface{} {
+	var doc map[string]interface{}
+	raw, _ := json.Marshal(details)
+	_ = json.Unmarshal(raw, &doc)
+	return dropZeroTimes(doc).(map[string]interface{})
+}
+
+var zeroTimeJSON = time.Time{}.Format(time.RFC3339)
+
+func dropZeroTimes(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if item == zeroTimeJSON {
+				delete(val, k)
+				continue
+			}
+			val[k] = dropZeroTimes(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = dropZeroTimes(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// loadViewableAchievement memastikan prestasi ada dan boleh dilihat user yang
+// login. Jika tidak, ref bernilai nil dan response error sudah ditulis.
+func (s *AchievementService) loadViewableAchievement(c *fiber.Ctx, id string) (*model.AchievementReference, error) {
+	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return nil, c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+	}
+	if !s.canViewAchievement(c, ref) {
+		return nil, c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized: You cannot access this achievement"})
+	}
+	return ref, nil
+}
+
+// GET /api/v1/achievements/:id/versions (Daftar Versi Konten)
+// Setiap versi disertai keputusan status yang tercatat terhadapnya, sehingga
+// terlihat versi mana yang diajukan, ditolak, dan disetujui.
+func (s *AchievementService) GetContentVersions(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ref, errResp := s.loadViewableAchievement(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	versions, err := s.achRepo.FindContentVersions(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve versions")
+	}
+	history, err := s.achRepo.FindHistory(c.Context(), id)
+	if err != nil {
+		return respondError(c, err, "Failed to retrieve versions")
+	}
+
+	byVersion := make(map[int]int, len(versions))
+	for i, v := range versions {
+		byVersion[v.Version] = i
+	}
+	for _, h := range history {
+		if h.ContentVersion == nil {
+			continue
+		}
+		if i, ok := byVersion[*h.ContentVersion]; ok {
+			versions[i].Decisions = append(versions[i].Decisions, h)
+		}
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Versions retrieved",
+		Data: fiber.Map{
+			"achievementId":  id,
+			"currentVersion": ref.ContentVersion,
+			"versions":       versions,
+		},
+	})
+}
+
+// GET /api/v1/achievements/:id/versions/:version (Isi Satu Versi Konten)
+func (s *AchievementService) GetContentVersion(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	version, err := c.ParamsInt("version")
+	if err != nil || version < 1 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid version"})
+	}
+
+	ref, errResp := s.loadViewableAchievement(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	v, err := s.achRepo.FindContentVersion(c.Context(), id, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Version not found"})
+		}
+		return respondError(c, err, "Failed to retrieve version")
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Version retrieved", Data: v})
+}
+
+// GET /api/v1/achievements/:id/versions/diff?from=&to= (Perbandingan Dua Versi)
+// Default to = versi terakhir; from = versi yang terakhir ditolak (jika lebih
+// lama dari to), selain itu versi sebelum to.
+func (s *AchievementService) GetContentDiff(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ref, errResp := s.loadViewableAchievement(c, id)
+	if ref == nil {
+		return errResp
+	}
+
+	to, ok := versionQuery(c, "to", ref.ContentVersion)
+	if !ok {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid 'to' version"})
+	}
+	defaultFrom := to - 1
+	if rejected, err := s.achRepo.LastRejectedContentVersion(c.Context(), id); err != nil {
+		return respondError(c, err, "Failed to compare versions")
+	} else if rejected > 0 && rejected < to {
+		defaultFrom = rejected
+	}
+	from, ok := versionQuery(c, "from", defaultFrom)
+	if !ok {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid 'from' version"})
+	}
+	if from < 1 || to < 1 {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement has no earlier version to compare"})
+	}
+
+	docs := make([]map[string]interface{}, 2)
+	for i, version := range []int{from, to} {
+		v, err := s.achRepo.FindContentVersion(c.Context(), id, version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Version " + strconv.Itoa(version) + " not found"})
+			}
+			return respondError(c, err, "Failed to compare versions")
+		}
+		raw, _ := json.Marshal(v.Content)
+		_ = json.Unmarshal(raw, &docs[i])
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Diff generated",
+		Data: model.ContentDiff{
+			AchievementRefID: id,
+			From:             from,
+			To:               to,
+			Changes:          utils.DiffJSON(docs[0], docs[1], model.ContentDiffIgnoredFields...),
+		},
+	})
+}
+
+// versionQuery membaca nomor versi dari query string (def jika tidak dikirim)
+func versionQuery(c *fiber.Ctx, key string, def int) (int, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return def, true
+	}
+	v, err := strconv.Atoi(raw)
+	return v, err == nil && v > 0
+}
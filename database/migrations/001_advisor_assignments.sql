@@ -0,0 +1,29 @@
+-- Riwayat penugasan dosen wali + serah terima prestasi 'submitted'
+CREATE TABLE IF NOT EXISTS advisor_assignments (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id      UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    lecturer_id     UUID NOT NULL REFERENCES lecturers(id),
+    effective_from  TIMESTAMP NOT NULL DEFAULT NOW(),
+    effective_to    TIMESTAMP NULL,
+    assigned_by     UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    handover_policy VARCHAR(20) NOT NULL DEFAULT 'transfer'
+        CHECK (handover_policy IN ('transfer', 'keep')),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_advisor_assignments_student ON advisor_assignments(student_id, effective_from);
+
+-- Hanya boleh ada satu penugasan aktif per mahasiswa
+CREATE UNIQUE INDEX IF NOT EXISTS uq_advisor_assignments_active
+    ON advisor_assignments(student_id) WHERE effective_to IS NULL;
+
+-- Verifikator yang ditahan (kebijakan 'keep'). NULL = ikut dosen wali aktif
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS assigned_verifier_id UUID NULL REFERENCES lecturers(id);
+
+-- Backfill: penugasan aktif dari data students yang sudah ada
+INSERT INTO advisor_assignments (student_id, lecturer_id, effective_from, handover_policy)
+SELECT s.id, s.advisor_id, s.created_at, 'transfer'
+FROM students s
+WHERE s.advisor_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM advisor_assignments aa WHERE aa.student_id = s.id);
@@ -0,0 +1,39 @@
+-- Riwayat transisi status prestasi (append-only)
+CREATE TABLE IF NOT EXISTS achievement_status_history (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    from_status        VARCHAR(20) NULL,
+    to_status          VARCHAR(20) NOT NULL,
+    actor_id           UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    note               TEXT NOT NULL DEFAULT '',
+    points             INTEGER NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_ref ON achievement_status_history(achievement_ref_id, created_at);
+
+-- Baris riwayat tidak boleh diubah. Penghapusan hanya terjadi lewat cascade
+-- saat achievement_references dihapus permanen.
+CREATE OR REPLACE FUNCTION forbid_status_history_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'achievement_status_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_status_history_no_update ON achievement_status_history;
+CREATE TRIGGER trg_status_history_no_update
+    BEFORE UPDATE ON achievement_status_history
+    FOR EACH ROW EXECUTE FUNCTION forbid_status_history_update();
+
+-- Backfill: event pembuatan + status terakhir untuk data lama
+INSERT INTO achievement_status_history (achievement_ref_id, from_status, to_status, actor_id, created_at)
+SELECT ar.id, NULL, 'draft', s.user_id, ar.created_at
+FROM achievement_references ar
+JOIN students s ON ar.student_id = s.id
+WHERE NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.achievement_ref_id = ar.id);
+
+INSERT INTO achievement_status_history (achievement_ref_id, from_status, to_status, actor_id, note, created_at)
+SELECT ar.id, 'draft', ar.status, ar.verified_by, COALESCE(ar.rejection_note, ''), ar.updated_at
+FROM achievement_references ar
+WHERE ar.status != 'draft'
+  AND (SELECT COUNT(*) FROM achievement_status_history h WHERE h.achievement_ref_id = ar.id) = 1;
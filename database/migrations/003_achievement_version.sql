@@ -0,0 +1,5 @@
+-- Version untuk compare-and-set pada achievement_references.
+-- Setiap perubahan status/konten menaikkan version; update yang membawa
+-- version lama ditolak (409).
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
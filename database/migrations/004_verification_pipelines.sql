@@ -0,0 +1,88 @@
+-- Alur verifikasi bertahap per tipe & tingkat prestasi.
+-- achievement_type '*' berlaku untuk semua tipe, competition_level NULL untuk semua tingkat.
+-- Konfigurasi paling spesifik (tipe + tingkat) yang dipakai.
+CREATE TABLE IF NOT EXISTS verification_pipeline_stages (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_type  VARCHAR(50) NOT NULL,
+    competition_level VARCHAR(20) NULL,
+    stage_order       INTEGER NOT NULL CHECK (stage_order > 0),
+    stage_key         VARCHAR(50) NOT NULL,
+    name              VARCHAR(100) NOT NULL,
+    permission        VARCHAR(100) NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_pipeline_stage_order
+    ON verification_pipeline_stages(achievement_type, COALESCE(competition_level, ''), stage_order);
+
+-- Tahap yang dijalani setiap pengajuan. Disalin dari konfigurasi saat submit,
+-- sehingga perubahan konfigurasi tidak mempengaruhi pengajuan yang sedang berjalan.
+CREATE TABLE IF NOT EXISTS achievement_verification_stages (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    round              INTEGER NOT NULL,
+    stage_order        INTEGER NOT NULL,
+    stage_key          VARCHAR(50) NOT NULL,
+    name               VARCHAR(100) NOT NULL,
+    permission         VARCHAR(100) NOT NULL,
+    status             VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    started_at         TIMESTAMP NULL,
+    decided_by         UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    decided_at         TIMESTAMP NULL,
+    note               TEXT NOT NULL DEFAULT '',
+    points             INTEGER NULL,
+    UNIQUE (achievement_ref_id, round, stage_order)
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_stages_queue
+    ON achievement_verification_stages(stage_key, status);
+
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS verification_round INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS current_stage VARCHAR(50) NULL;
+
+-- Role & permission untuk tahap persetujuan Kemahasiswaan fakultas
+INSERT INTO permissions (name, resource, action, description)
+SELECT 'achievement:approve_affairs', 'achievement', 'approve_affairs', 'Persetujuan prestasi tahap Kemahasiswaan'
+WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'achievement:approve_affairs');
+
+INSERT INTO roles (name, description)
+SELECT 'Kemahasiswaan', 'Bagian kemahasiswaan fakultas (persetujuan tahap kedua)'
+WHERE NOT EXISTS (SELECT 1 FROM roles WHERE name = 'Kemahasiswaan');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('Kemahasiswaan', 'Admin') AND p.name = 'achievement:approve_affairs'
+  AND NOT EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role_id = r.id AND rp.permission_id = p.id);
+
+-- Konfigurasi awal: semua prestasi cukup diverifikasi dosen wali, kecuali
+-- kompetisi tingkat nasional & internasional yang butuh persetujuan Kemahasiswaan.
+INSERT INTO verification_pipeline_stages (achievement_type, competition_level, stage_order, stage_key, name, permission)
+SELECT v.achievement_type, v.competition_level, v.stage_order, v.stage_key, v.name, v.permission
+FROM (VALUES
+    ('*',           NULL,            1, 'advisor',         'Dosen Wali',    'achievement:verify'),
+    ('competition', 'national',      1, 'advisor',         'Dosen Wali',    'achievement:verify'),
+    ('competition', 'national',      2, 'student_affairs', 'Kemahasiswaan', 'achievement:approve_affairs'),
+    ('competition', 'international', 1, 'advisor',         'Dosen Wali',    'achievement:verify'),
+    ('competition', 'international', 2, 'student_affairs', 'Kemahasiswaan', 'achievement:approve_affairs')
+) AS v(achievement_type, competition_level, stage_order, stage_key, name, permission)
+WHERE NOT EXISTS (SELECT 1 FROM verification_pipeline_stages);
+
+-- Backfill: pengajuan lama dianggap satu tahap (dosen wali)
+INSERT INTO achievement_verification_stages
+    (achievement_ref_id, round, stage_order, stage_key, name, permission, status, started_at, decided_by, decided_at, note)
+SELECT ar.id, 1, 1, 'advisor', 'Dosen Wali', 'achievement:verify',
+    CASE ar.status WHEN 'verified' THEN 'approved' WHEN 'rejected' THEN 'rejected' ELSE 'pending' END,
+    ar.submitted_at,
+    CASE WHEN ar.status IN ('verified', 'rejected') THEN ar.verified_by END,
+    CASE WHEN ar.status IN ('verified', 'rejected') THEN ar.verified_at END,
+    COALESCE(ar.rejection_note, '')
+FROM achievement_references ar
+WHERE ar.status IN ('submitted', 'verified', 'rejected')
+  AND NOT EXISTS (SELECT 1 FROM achievement_verification_stages vs WHERE vs.achievement_ref_id = ar.id);
+
+UPDATE achievement_references
+SET verification_round = 1,
+    current_stage = CASE WHEN status = 'submitted' THEN 'advisor' END
+WHERE status IN ('submitted', 'verified', 'rejected') AND verification_round = 0;
@@ -0,0 +1,93 @@
+-- Registry tipe prestasi. Kolom schema berisi JSON Schema untuk field `details`
+-- (lihat model.DetailSchema) dan dipakai untuk validasi server-side serta
+-- render form di frontend. Tipe baru dari Admin menaruh field-nya di
+-- properties.customFields.
+CREATE TABLE IF NOT EXISTS achievement_types (
+    key          VARCHAR(50) PRIMARY KEY CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    name         VARCHAR(100) NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    schema       JSONB NOT NULL DEFAULT '{"type": "object"}',
+    is_system    BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Tipe bawaan (SRS Halaman 6)
+INSERT INTO achievement_types (key, name, description, schema, is_system) VALUES
+('academic', 'Akademik', 'Prestasi akademik (IPK, beasiswa, karya ilmiah internal)', '{
+    "type": "object",
+    "properties": {
+        "eventDate": {"type": "string", "format": "date-time", "title": "Tanggal", "x-notFuture": true},
+        "organizer": {"type": "string", "title": "Penyelenggara", "maxLength": 200},
+        "score":     {"type": "number", "title": "Nilai", "minimum": 0},
+        "customFields": {"type": "object"}
+    }
+}', TRUE),
+('competition', 'Kompetisi', 'Lomba dan kompetisi', '{
+    "type": "object",
+    "required": ["competitionName", "competitionLevel", "eventDate"],
+    "properties": {
+        "competitionName":  {"type": "string", "title": "Nama Kompetisi", "maxLength": 200},
+        "competitionLevel": {"type": "string", "title": "Tingkat", "enum": ["local", "regional", "national", "international"]},
+        "rank":             {"type": "integer", "title": "Peringkat", "minimum": 1},
+        "medalType":        {"type": "string", "title": "Medali", "enum": ["gold", "silver", "bronze"]},
+        "eventDate":        {"type": "string", "format": "date-time", "title": "Tanggal Kompetisi", "x-notFuture": true},
+        "location":         {"type": "string", "title": "Lokasi", "maxLength": 200},
+        "organizer":        {"type": "string", "title": "Penyelenggara", "maxLength": 200},
+        "customFields":     {"type": "object"}
+    }
+}', TRUE),
+('publication', 'Publikasi', 'Publikasi ilmiah (jurnal, konferensi, buku)', '{
+    "type": "object",
+    "required": ["publicationType", "publicationTitle", "authors"],
+    "properties": {
+        "publicationType":  {"type": "string", "title": "Jenis Publikasi", "enum": ["journal", "conference", "book"]},
+        "publicationTitle": {"type": "string", "title": "Judul Publikasi", "maxLength": 300},
+        "authors":          {"type": "array", "title": "Penulis", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+        "publisher":        {"type": "string", "title": "Penerbit", "maxLength": 200},
+        "issn":             {"type": "string", "title": "ISSN", "maxLength": 20},
+        "eventDate":        {"type": "string", "format": "date-time", "title": "Tanggal Terbit", "x-notFuture": true},
+        "customFields":     {"type": "object"}
+    }
+}', TRUE),
+('organization', 'Organisasi', 'Kepengurusan organisasi', '{
+    "type": "object",
+    "required": ["organizationName", "position", "period"],
+    "properties": {
+        "organizationName": {"type": "string", "title": "Nama Organisasi", "maxLength": 200},
+        "position":         {"type": "string", "title": "Jabatan", "maxLength": 100},
+        "period": {
+            "type": "object",
+            "title": "Periode",
+            "required": ["start", "end"],
+            "properties": {
+                "start": {"type": "string", "format": "date-time", "title": "Mulai", "x-notFuture": true},
+                "end":   {"type": "string", "format": "date-time", "title": "Selesai", "x-after": "start"}
+            }
+        },
+        "customFields": {"type": "object"}
+    }
+}', TRUE),
+('certification', 'Sertifikasi', 'Sertifikasi profesi dan kompetensi', '{
+    "type": "object",
+    "required": ["certificationName", "issuedBy", "eventDate"],
+    "properties": {
+        "certificationName":   {"type": "string", "title": "Nama Sertifikasi", "maxLength": 200},
+        "issuedBy":            {"type": "string", "title": "Penerbit Sertifikat", "maxLength": 200},
+        "certificationNumber": {"type": "string", "title": "Nomor Sertifikat", "maxLength": 100},
+        "eventDate":           {"type": "string", "format": "date-time", "title": "Tanggal Terbit", "x-notFuture": true},
+        "validUntil":          {"type": "string", "format": "date-time", "title": "Berlaku Sampai", "x-after": "eventDate"},
+        "customFields":        {"type": "object"}
+    }
+}', TRUE),
+('other', 'Lainnya', 'Prestasi lain', '{
+    "type": "object",
+    "properties": {
+        "eventDate":    {"type": "string", "format": "date-time", "title": "Tanggal", "x-notFuture": true},
+        "location":     {"type": "string", "title": "Lokasi", "maxLength": 200},
+        "organizer":    {"type": "string", "title": "Penyelenggara", "maxLength": 200},
+        "customFields": {"type": "object"}
+    }
+}', TRUE)
+ON CONFLICT (key) DO NOTHING;
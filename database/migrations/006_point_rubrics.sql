@@ -0,0 +1,112 @@
+-- Rubrik perhitungan poin. Setiap perubahan aturan membuat versi baru; versi
+-- lama tidak pernah diubah sehingga poin yang sudah diberikan bisa dihitung ulang.
+CREATE TABLE IF NOT EXISTS point_rubrics (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version     INTEGER NOT NULL UNIQUE CHECK (version > 0),
+    name        VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_active   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by  UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Hanya satu rubrik yang aktif
+CREATE UNIQUE INDEX IF NOT EXISTS uq_point_rubrics_active ON point_rubrics(is_active) WHERE is_active;
+
+-- Kriteria NULL berarti "semua nilai". Aturan paling spesifik (kriteria terisi
+-- terbanyak) yang cocok dipakai.
+CREATE TABLE IF NOT EXISTS point_rubric_rules (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rubric_id         UUID NOT NULL REFERENCES point_rubrics(id) ON DELETE CASCADE,
+    achievement_type  VARCHAR(50) NOT NULL,
+    competition_level VARCHAR(20) NULL,
+    rank              INTEGER NULL,
+    medal_type        VARCHAR(20) NULL,
+    publication_type  VARCHAR(20) NULL,
+    indexing          VARCHAR(20) NULL,
+    position          VARCHAR(100) NULL,
+    points            INTEGER NOT NULL CHECK (points >= 0),
+    description       TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_rubric_rules_rubric ON point_rubric_rules(rubric_id, achievement_type);
+
+-- Aturan versi lama tidak boleh diubah; rubrik hanya boleh diaktifkan/nonaktifkan
+CREATE OR REPLACE FUNCTION forbid_point_rule_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'point_rubric_rules is immutable, create a new rubric version instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_point_rubric_rules_immutable ON point_rubric_rules;
+CREATE TRIGGER trg_point_rubric_rules_immutable
+    BEFORE UPDATE ON point_rubric_rules
+    FOR EACH ROW EXECUTE FUNCTION forbid_point_rule_change();
+
+CREATE OR REPLACE FUNCTION forbid_point_rubric_change() RETURNS trigger AS $$
+BEGIN
+    IF NEW.version <> OLD.version OR NEW.name <> OLD.name OR NEW.description <> OLD.description THEN
+        RAISE EXCEPTION 'point_rubrics is immutable except is_active';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_point_rubrics_immutable ON point_rubrics;
+CREATE TRIGGER trg_point_rubrics_immutable
+    BEFORE UPDATE ON point_rubrics
+    FOR EACH ROW EXECUTE FUNCTION forbid_point_rubric_change();
+
+-- Poin saran (dihitung saat simpan/submit) dan jejak poin final saat verify
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS suggested_points INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS rubric_version INTEGER NULL REFERENCES point_rubrics(version),
+    ADD COLUMN IF NOT EXISTS points_override_reason TEXT NULL;
+
+-- Indexing publikasi dipakai rubrik
+UPDATE achievement_types
+SET schema = jsonb_set(schema, '{properties,indexing}',
+    '{"type": "string", "title": "Indeksasi", "enum": ["scopus", "wos", "sinta1", "sinta2", "sinta3", "sinta4", "sinta5", "sinta6", "none"]}'),
+    updated_at = NOW()
+WHERE key = 'publication' AND NOT schema->'properties' ? 'indexing';
+
+-- Rubrik awal (versi 1)
+INSERT INTO point_rubrics (version, name, description, is_active)
+SELECT 1, 'Rubrik Poin 2024', 'Rubrik awal berdasarkan tingkat, peringkat, medali, indeksasi, dan jabatan', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM point_rubrics);
+
+INSERT INTO point_rubric_rules (rubric_id, achievement_type, competition_level, rank, medal_type, publication_type, indexing, position, points, description)
+SELECT r.id, v.achievement_type, v.competition_level, v.rank, v.medal_type, v.publication_type, v.indexing, v.position, v.points, v.description
+FROM point_rubrics r
+CROSS JOIN (VALUES
+    ('competition',   'international', NULL::int, NULL,     NULL,         NULL,     NULL,             100, 'Kompetisi internasional'),
+    ('competition',   'international', NULL,      'gold',   NULL,         NULL,     NULL,             150, 'Medali emas internasional'),
+    ('competition',   'international', NULL,      'silver', NULL,         NULL,     NULL,             125, 'Medali perak internasional'),
+    ('competition',   'international', NULL,      'bronze', NULL,         NULL,     NULL,             110, 'Medali perunggu internasional'),
+    ('competition',   'international', 1,         NULL,     NULL,         NULL,     NULL,             140, 'Juara 1 internasional'),
+    ('competition',   'national',      NULL,      NULL,     NULL,         NULL,     NULL,             75,  'Kompetisi nasional'),
+    ('competition',   'national',      NULL,      'gold',   NULL,         NULL,     NULL,             100, 'Medali emas nasional'),
+    ('competition',   'national',      NULL,      'silver', NULL,         NULL,     NULL,             90,  'Medali perak nasional'),
+    ('competition',   'national',      NULL,      'bronze', NULL,         NULL,     NULL,             80,  'Medali perunggu nasional'),
+    ('competition',   'national',      1,         NULL,     NULL,         NULL,     NULL,             95,  'Juara 1 nasional'),
+    ('competition',   'regional',      NULL,      NULL,     NULL,         NULL,     NULL,             50,  'Kompetisi regional'),
+    ('competition',   'local',         NULL,      NULL,     NULL,         NULL,     NULL,             25,  'Kompetisi lokal'),
+    ('competition',   NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             10,  'Kompetisi lainnya'),
+    ('publication',   NULL,            NULL,      NULL,     'journal',    'scopus', NULL,             100, 'Jurnal terindeks Scopus'),
+    ('publication',   NULL,            NULL,      NULL,     'journal',    'wos',    NULL,             100, 'Jurnal terindeks Web of Science'),
+    ('publication',   NULL,            NULL,      NULL,     'journal',    'sinta1', NULL,             80,  'Jurnal SINTA 1'),
+    ('publication',   NULL,            NULL,      NULL,     'journal',    'sinta2', NULL,             70,  'Jurnal SINTA 2'),
+    ('publication',   NULL,            NULL,      NULL,     'journal',    NULL,     NULL,             40,  'Jurnal lainnya'),
+    ('publication',   NULL,            NULL,      NULL,     'conference', 'scopus', NULL,             60,  'Prosiding terindeks Scopus'),
+    ('publication',   NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             20,  'Publikasi lainnya'),
+    ('organization',  NULL,            NULL,      NULL,     NULL,         NULL,     'ketua',          40,  'Ketua organisasi'),
+    ('organization',  NULL,            NULL,      NULL,     NULL,         NULL,     'wakil ketua',    30,  'Wakil ketua organisasi'),
+    ('organization',  NULL,            NULL,      NULL,     NULL,         NULL,     'sekretaris',     25,  'Sekretaris organisasi'),
+    ('organization',  NULL,            NULL,      NULL,     NULL,         NULL,     'bendahara',      25,  'Bendahara organisasi'),
+    ('organization',  NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             15,  'Pengurus organisasi'),
+    ('certification', NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             30,  'Sertifikasi'),
+    ('academic',      NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             20,  'Prestasi akademik'),
+    ('other',         NULL,            NULL,      NULL,     NULL,         NULL,     NULL,             10,  'Prestasi lainnya')
+) AS v(achievement_type, competition_level, rank, medal_type, publication_type, indexing, position, points, description)
+WHERE r.version = 1
+  AND NOT EXISTS (SELECT 1 FROM point_rubric_rules x WHERE x.rubric_id = r.id);
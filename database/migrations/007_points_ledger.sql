@@ -0,0 +1,46 @@
+-- Buku besar poin (append-only). Poin tidak pernah diubah di tempat: koreksi
+-- dicatat sebagai entri baru yang mengimbangi entri sebelumnya, sehingga saldo
+-- mahasiswa = SUM(points) dan setiap perubahan punya pelaku & alasan.
+--   award       poin final saat prestasi diverifikasi
+--   adjustment  koreksi manual Admin (boleh negatif)
+--   reversal    membatalkan satu entri (points = -entri asal)
+--   expiration  menghapus sisa poin prestasi yang kedaluwarsa
+CREATE TABLE IF NOT EXISTS points_ledger (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id         UUID NOT NULL REFERENCES students(id),
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id),
+    entry_type         VARCHAR(20) NOT NULL
+        CHECK (entry_type IN ('award', 'adjustment', 'reversal', 'expiration')),
+    points             INTEGER NOT NULL,
+    reason             TEXT NOT NULL DEFAULT '',
+    actor_id           UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    reverses_entry_id  UUID NULL UNIQUE REFERENCES points_ledger(id),
+    rubric_version     INTEGER NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((entry_type = 'reversal') = (reverses_entry_id IS NOT NULL)),
+    CHECK (entry_type = 'award' OR points <> 0)
+);
+
+-- Satu award per prestasi
+CREATE UNIQUE INDEX IF NOT EXISTS uq_points_ledger_award
+    ON points_ledger(achievement_ref_id) WHERE entry_type = 'award';
+CREATE INDEX IF NOT EXISTS idx_points_ledger_student ON points_ledger(student_id, created_at);
+
+CREATE OR REPLACE FUNCTION forbid_points_ledger_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'points_ledger is append-only, record a reversal or adjustment instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_points_ledger_append_only ON points_ledger;
+CREATE TRIGGER trg_points_ledger_append_only
+    BEFORE UPDATE OR DELETE ON points_ledger
+    FOR EACH ROW EXECUTE FUNCTION forbid_points_ledger_change();
+
+-- Backfill: award dari riwayat verifikasi yang sudah ada
+INSERT INTO points_ledger (student_id, achievement_ref_id, entry_type, points, reason, actor_id, rubric_version, created_at)
+SELECT ar.student_id, h.achievement_ref_id, 'award', h.points, 'Backfill dari riwayat verifikasi', h.actor_id, ar.rubric_version, h.created_at
+FROM achievement_status_history h
+JOIN achievement_references ar ON ar.id = h.achievement_ref_id
+WHERE h.to_status = 'verified' AND h.points IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM points_ledger l WHERE l.achievement_ref_id = h.achievement_ref_id AND l.entry_type = 'award');
@@ -0,0 +1,11 @@
+-- Pencabutan prestasi yang sudah diverifikasi (mis. sertifikat palsu).
+-- Status 'revoked' adalah status akhir; data tetap disimpan dan ditampilkan
+-- dengan keterangan pencabutan, poinnya dibatalkan lewat reversal di points_ledger.
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS revoked_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS revocation_reason TEXT NULL;
+
+ALTER TABLE achievement_references DROP CONSTRAINT IF EXISTS chk_achievement_revocation;
+ALTER TABLE achievement_references ADD CONSTRAINT chk_achievement_revocation
+    CHECK (status <> 'revoked' OR (revoked_at IS NOT NULL AND revocation_reason IS NOT NULL));
@@ -0,0 +1,33 @@
+-- SLA verifikasi per tahap. Tahap dianggap terlambat setelah due_days sejak
+-- mulai menunggu (pengingat dikirim ke pemutus tahap), lalu dieskalasi setelah
+-- escalate_after_days berikutnya ke pemegang escalation_permission dan/atau
+-- delegate_user_id. Tahap tanpa baris di tabel ini tidak punya SLA.
+CREATE TABLE IF NOT EXISTS verification_slas (
+    stage_key             VARCHAR(50) PRIMARY KEY,
+    due_days              INTEGER NOT NULL CHECK (due_days > 0),
+    escalate_after_days   INTEGER NOT NULL CHECK (escalate_after_days > 0),
+    escalation_permission VARCHAR(100) NULL,
+    delegate_user_id      UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    updated_at            TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (escalation_permission IS NOT NULL OR delegate_user_id IS NOT NULL)
+);
+
+-- Jejak pengingat & eskalasi per tahap pengajuan (masing-masing sekali)
+ALTER TABLE achievement_verification_stages
+    ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS escalated_permission VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS escalated_to UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_verification_stages_sla
+    ON achievement_verification_stages(started_at) WHERE status = 'pending' AND escalated_at IS NULL;
+
+-- Konfigurasi awal: dosen wali 7 hari, lalu dieskalasi ke Kemahasiswaan setelah
+-- 7 hari berikutnya; tahap Kemahasiswaan dieskalasi ke Admin.
+INSERT INTO verification_slas (stage_key, due_days, escalate_after_days, escalation_permission)
+SELECT v.stage_key, v.due_days, v.escalate_after_days, v.escalation_permission
+FROM (VALUES
+    ('advisor',         7, 7, 'achievement:approve_affairs'),
+    ('student_affairs', 7, 7, 'user:manage')
+) AS v(stage_key, due_days, escalate_after_days, escalation_permission)
+WHERE NOT EXISTS (SELECT 1 FROM verification_slas);
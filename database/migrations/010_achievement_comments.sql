@@ -0,0 +1,31 @@
+-- Diskusi pada prestasi antara mahasiswa, dosen wali, dan verifikator.
+-- Menggantikan rejection_note sebagai satu-satunya saluran balik: catatan
+-- penolakan tetap ada, tetapi tanya-jawab sebelum submit ulang dicatat di sini.
+-- Balasan selalu menempel ke komentar akar (thread satu tingkat).
+CREATE TABLE IF NOT EXISTS achievement_comments (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_ref_id    UUID NOT NULL REFERENCES achievement_references(id),
+    parent_id             UUID NULL REFERENCES achievement_comments(id),
+    author_id             UUID NOT NULL REFERENCES users(id),
+    body                  TEXT NOT NULL CHECK (length(btrim(body)) > 0),
+    -- Opsional: field prestasi (mis. "details.rank") atau URL lampiran yang dibahas
+    anchor_field          VARCHAR(100) NULL,
+    anchor_attachment_url TEXT NULL,
+    -- Hanya komentar akar yang bisa ditandai selesai
+    is_resolved           BOOLEAN NOT NULL DEFAULT FALSE,
+    resolved_by           UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at           TIMESTAMP NULL,
+    edited_at             TIMESTAMP NULL,
+    created_at            TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (parent_id IS NULL OR NOT is_resolved),
+    CHECK (is_resolved = (resolved_at IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_comments_ref ON achievement_comments(achievement_ref_id, created_at);
+
+-- User yang di-mention (@username) pada komentar
+CREATE TABLE IF NOT EXISTS achievement_comment_mentions (
+    comment_id UUID NOT NULL REFERENCES achievement_comments(id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
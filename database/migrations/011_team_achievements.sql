@@ -0,0 +1,45 @@
+-- Prestasi tim: satu prestasi dengan beberapa mahasiswa pemilik. Pemilik
+-- achievement_references.student_id adalah ketua (leader); anggota lain harus
+-- mengonfirmasi sebelum prestasi bisa diajukan.
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS is_team BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Kebijakan verifikasi tim, disalin dari tipe prestasi saat submit
+    ADD COLUMN IF NOT EXISTS team_verification VARCHAR(20) NULL
+        CHECK (team_verification IN ('leader', 'per_member'));
+
+CREATE TABLE IF NOT EXISTS achievement_team_members (
+    achievement_ref_id  UUID NOT NULL REFERENCES achievement_references(id),
+    student_id          UUID NOT NULL REFERENCES students(id),
+    role                VARCHAR(20) NOT NULL CHECK (role IN ('leader', 'member')),
+    status              VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'confirmed', 'declined')),
+    responded_at        TIMESTAMP NULL,
+    -- Persetujuan dosen wali anggota (kebijakan per_member), direset setiap submit
+    advisor_approved_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    advisor_approved_at TIMESTAMP NULL,
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (achievement_ref_id, student_id),
+    CHECK (role = 'member' OR status = 'confirmed')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_achievement_team_leader
+    ON achievement_team_members(achievement_ref_id) WHERE role = 'leader';
+CREATE INDEX IF NOT EXISTS idx_achievement_team_members_student
+    ON achievement_team_members(student_id, status);
+
+-- Verifikasi tim: 'leader' = dosen wali ketua memverifikasi atas nama semua
+-- anggota; 'per_member' = tahap dosen wali perlu persetujuan dosen wali setiap anggota
+ALTER TABLE achievement_types
+    ADD COLUMN IF NOT EXISTS team_verification VARCHAR(20) NOT NULL DEFAULT 'leader'
+        CHECK (team_verification IN ('leader', 'per_member'));
+
+-- Poin tim per aturan rubrik: 'duplicate' = setiap anggota mendapat poin penuh,
+-- 'split' = poin dibagi rata (sisa pembagian ke ketua lebih dulu)
+ALTER TABLE point_rubric_rules
+    ADD COLUMN IF NOT EXISTS team_points VARCHAR(20) NOT NULL DEFAULT 'duplicate'
+        CHECK (team_points IN ('duplicate', 'split'));
+
+-- Award sekarang satu per anggota per prestasi
+DROP INDEX IF EXISTS uq_points_ledger_award;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_points_ledger_award
+    ON points_ledger(achievement_ref_id, student_id) WHERE entry_type = 'award';
@@ -0,0 +1,49 @@
+-- Deteksi duplikat/kecurangan: sidik jari isi prestasi dan hash lampiran
+-- dibandingkan dengan prestasi lain setiap kali prestasi dibuat, diubah, atau
+-- lampiran diunggah. Hasilnya ditampilkan ke verifikator, bukan memblokir.
+
+-- Sidik jari ternormalisasi (huruf kecil, tanpa tanda baca) dari details MongoDB
+CREATE TABLE IF NOT EXISTS achievement_fingerprints (
+    achievement_ref_id   UUID PRIMARY KEY REFERENCES achievement_references(id),
+    achievement_type     VARCHAR(50) NOT NULL,
+    -- Nama kompetisi / sertifikasi / publikasi / organisasi
+    name                 TEXT NOT NULL DEFAULT '',
+    organizer            TEXT NOT NULL DEFAULT '',
+    certification_number VARCHAR(200) NOT NULL DEFAULT '',
+    event_date           DATE NULL,
+    updated_at           TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_fingerprints_cert
+    ON achievement_fingerprints(certification_number) WHERE certification_number <> '';
+CREATE INDEX IF NOT EXISTS idx_achievement_fingerprints_date
+    ON achievement_fingerprints(event_date) WHERE event_date IS NOT NULL;
+
+-- SHA-256 setiap lampiran yang diunggah
+CREATE TABLE IF NOT EXISTS achievement_attachment_hashes (
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id),
+    file_url           TEXT NOT NULL,
+    file_name          TEXT NOT NULL,
+    sha256             CHAR(64) NOT NULL,
+    uploaded_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (achievement_ref_id, file_url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_attachment_hashes_sha ON achievement_attachment_hashes(sha256);
+
+-- Dugaan duplikat: achievement_ref_id adalah prestasi yang lebih baru,
+-- matched_ref_id prestasi lama yang mirip
+CREATE TABLE IF NOT EXISTS achievement_duplicate_flags (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id),
+    matched_ref_id     UUID NOT NULL REFERENCES achievement_references(id),
+    reason             VARCHAR(30) NOT NULL
+        CHECK (reason IN ('certification_number', 'similar_details', 'attachment_hash')),
+    score              NUMERIC(4, 3) NOT NULL,
+    detail             TEXT NOT NULL DEFAULT '',
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (achievement_ref_id <> matched_ref_id),
+    UNIQUE (achievement_ref_id, matched_ref_id, reason)
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_duplicate_flags_matched ON achievement_duplicate_flags(matched_ref_id);
@@ -0,0 +1,16 @@
+-- Tempat sampah prestasi: draft yang dihapus (status 'deleted') masih bisa
+-- dipulihkan selama masa retensi, setelah itu dihapus permanen oleh job purge
+-- (baris Postgres, dokumen MongoDB, dan file lampiran di ./uploads).
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- Data lama: waktu hapus diambil dari riwayat status
+UPDATE achievement_references ar
+SET deleted_at = COALESCE(
+    (SELECT MAX(h.created_at) FROM achievement_status_history h
+     WHERE h.achievement_ref_id = ar.id AND h.to_status = 'deleted'),
+    ar.updated_at)
+WHERE ar.status = 'deleted' AND ar.deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_achievement_references_trash
+    ON achievement_references(deleted_at) WHERE status = 'deleted';
@@ -0,0 +1,10 @@
+-- Masa berlaku sertifikat: salinan details.validUntil dari MongoDB agar status
+-- expired bisa dipakai di filter list, statistik, dan total poin tanpa join ke Mongo.
+-- Data lama diisi oleh job pengingat saat pertama kali berjalan.
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP NULL,
+    -- Pengingat "segera kedaluwarsa" hanya dikirim sekali per masa berlaku
+    ADD COLUMN IF NOT EXISTS expiry_reminded_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_achievement_references_valid_until
+    ON achievement_references(valid_until) WHERE valid_until IS NOT NULL;
@@ -0,0 +1,20 @@
+-- Idempotency-Key untuk POST yang membuat data / menjalankan workflow.
+-- Key berlaku per user; response pertama disimpan lalu diputar ulang saat
+-- klien mengulang request dengan key yang sama (mis. koneksi Wi-Fi putus).
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id       TEXT NOT NULL,
+    key           VARCHAR(255) NOT NULL,
+    method        VARCHAR(10) NOT NULL,
+    path          TEXT NOT NULL,
+    -- SHA-256 isi request; key yang dipakai ulang dengan isi berbeda ditolak
+    request_hash  CHAR(64) NOT NULL,
+    -- NULL selama request pertama masih diproses
+    status_code   INT NULL,
+    content_type  TEXT NULL,
+    response_body BYTEA NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at  TIMESTAMP NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
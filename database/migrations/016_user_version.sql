@@ -0,0 +1,5 @@
+-- Version untuk optimistic concurrency pada users (ETag / If-Match).
+-- Setiap perubahan data user (termasuk role) menaikkan version; update yang
+-- membawa version lama ditolak (412).
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,34 @@
+-- Versi konten prestasi: salinan dokumen MongoDB yang tidak bisa diubah,
+-- disimpan setiap kali konten berubah (dibuat, diedit, lampiran diunggah).
+-- Nomor versi berurutan per prestasi; content_version di achievement_references
+-- adalah versi terakhir (0 = data lama yang belum punya salinan).
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS content_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS achievement_content_versions (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    version            INTEGER NOT NULL CHECK (version > 0),
+    change             VARCHAR(20) NOT NULL
+        CHECK (change IN ('created', 'edited', 'attachment', 'imported')),
+    content            JSONB NOT NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (achievement_ref_id, version)
+);
+
+CREATE OR REPLACE FUNCTION forbid_content_version_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'achievement_content_versions is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_content_versions_no_update ON achievement_content_versions;
+CREATE TRIGGER trg_content_versions_no_update
+    BEFORE UPDATE ON achievement_content_versions
+    FOR EACH ROW EXECUTE FUNCTION forbid_content_version_update();
+
+-- Versi konten yang dilihat saat transisi status / keputusan tahap verifikasi
+ALTER TABLE achievement_status_history
+    ADD COLUMN IF NOT EXISTS content_version INTEGER NULL;
+ALTER TABLE achievement_verification_stages
+    ADD COLUMN IF NOT EXISTS content_version INTEGER NULL;
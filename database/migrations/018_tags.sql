@@ -0,0 +1,61 @@
+-- Kosakata tag yang dikelola Admin. Tag membentuk hierarki lewat parent_key
+-- (mis. 'ai' di bawah 'technology'); filter dan statistik per tag ikut
+-- menghitung tag turunannya.
+CREATE TABLE IF NOT EXISTS tags (
+    key          VARCHAR(50) PRIMARY KEY CHECK (key ~ '^[a-z0-9][a-z0-9+#.-]*$'),
+    name         VARCHAR(100) NOT NULL,
+    parent_key   VARCHAR(50) NULL REFERENCES tags(key) ON DELETE SET NULL CHECK (parent_key <> key),
+    description  TEXT NOT NULL DEFAULT '',
+    -- Tag nonaktif tidak muncul di autocomplete, tetapi prestasi lama tetap memakainya
+    is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tags_parent_key ON tags(parent_key);
+
+-- Sinonim dinormalisasi ke tag kanonik saat prestasi disimpan
+-- (mis. 'artificial-intelligence' -> 'ai')
+CREATE TABLE IF NOT EXISTS tag_synonyms (
+    synonym  VARCHAR(50) PRIMARY KEY CHECK (synonym ~ '^[a-z0-9][a-z0-9+#.-]*$'),
+    tag_key  VARCHAR(50) NOT NULL REFERENCES tags(key) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_synonyms_tag_key ON tag_synonyms(tag_key);
+
+-- Salinan tags dari MongoDB (sudah dinormalisasi) untuk filter list dan
+-- statistik. Data lama diisi saat aplikasi start.
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_achievement_references_tags
+    ON achievement_references USING GIN (tags);
+
+-- Contoh kosakata awal
+INSERT INTO tags (key, name, parent_key, description) VALUES
+('technology', 'Teknologi', NULL, 'Teknologi informasi dan rekayasa'),
+('ai', 'Kecerdasan Buatan', 'technology', 'Machine learning, data science, dan AI'),
+('web', 'Pengembangan Web', 'technology', ''),
+('cybersecurity', 'Keamanan Siber', 'technology', ''),
+('business', 'Bisnis', NULL, 'Kewirausahaan dan rencana bisnis'),
+('arts', 'Seni', NULL, ''),
+('sports', 'Olahraga', NULL, ''),
+('social', 'Sosial', NULL, 'Pengabdian masyarakat dan kegiatan sosial')
+ON CONFLICT (key) DO NOTHING;
+
+INSERT INTO tag_synonyms (synonym, tag_key) VALUES
+('artificial-intelligence', 'ai'),
+('kecerdasan-buatan', 'ai'),
+('machine-learning', 'ai'),
+('ml', 'ai'),
+('teknologi', 'technology'),
+('tech', 'technology'),
+('web-development', 'web'),
+('security', 'cybersecurity'),
+('keamanan-siber', 'cybersecurity'),
+('bisnis', 'business'),
+('kewirausahaan', 'business'),
+('seni', 'arts'),
+('olahraga', 'sports'),
+('sosial', 'social')
+ON CONFLICT (synonym) DO NOTHING;
@@ -0,0 +1,24 @@
+-- Bukti wajib per tipe prestasi (lihat model.EvidenceRequirement). Pengajuan
+-- verifikasi ditolak selama ada bukti yang belum dipenuhi.
+ALTER TABLE achievement_types
+    ADD COLUMN IF NOT EXISTS evidence_requirements JSONB NOT NULL DEFAULT '[]';
+
+-- Publikasi: DOI disimpan di details.doi
+UPDATE achievement_types
+SET schema = jsonb_set(schema, '{properties,doi}', '{"type": "string", "title": "DOI", "maxLength": 200}'),
+    updated_at = NOW()
+WHERE key = 'publication' AND NOT (schema->'properties' ? 'doi');
+
+-- Bukti bawaan; lampiran dikelompokkan lewat field 'category' saat upload
+UPDATE achievement_types SET evidence_requirements = '[
+    {"key": "certificate", "label": "Sertifikat/piagam (PDF)", "type": "attachment", "category": "certificate", "fileTypes": ["application/pdf"]},
+    {"key": "photo", "label": "Foto kegiatan/penyerahan hadiah", "type": "attachment", "category": "photo", "fileTypes": ["image/*"]}
+]' WHERE key = 'competition' AND evidence_requirements = '[]';
+
+UPDATE achievement_types SET evidence_requirements = '[
+    {"key": "doi", "label": "DOI publikasi", "type": "field", "field": "doi", "pattern": "^10\\.\\d{4,9}/\\S+$"}
+]' WHERE key = 'publication' AND evidence_requirements = '[]';
+
+UPDATE achievement_types SET evidence_requirements = '[
+    {"key": "certificate", "label": "Sertifikat (PDF atau gambar)", "type": "attachment", "category": "certificate", "fileTypes": ["application/pdf", "image/*"]}
+]' WHERE key = 'certification' AND evidence_requirements = '[]';
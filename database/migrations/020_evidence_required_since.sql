@@ -0,0 +1,8 @@
+-- Bukti wajib hanya berlaku untuk prestasi yang dibuat setelah bukti wajib
+-- tipenya ditetapkan/diubah. Lampiran lama belum punya kategori dan tidak bisa
+-- dikategorikan ulang, sehingga draft lama tidak boleh terkunci.
+ALTER TABLE achievement_types
+    ADD COLUMN IF NOT EXISTS evidence_required_since TIMESTAMPTZ;
+
+UPDATE achievement_types SET evidence_required_since = NOW()
+WHERE evidence_required_since IS NULL AND evidence_requirements != '[]';
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/WedhaWS/uasgosmt5/app/repository"
 	"github.com/WedhaWS/uasgosmt5/app/service"
@@ -46,11 +48,29 @@ func main() {
 	// AchService: Butuh AchRepo & UserRepo
 	achService := service.NewAchievementService(achRepo, userRepo)
 
+	// Job latar belakang. Interval bisa diatur lewat env (format durasi Go, mis. "30m")
+	// Job SLA verifikasi: pengingat & eskalasi pengajuan yang terlalu lama menunggu
+	go achService.StartSLAJob(context.Background(), envDuration("SLA_CHECK_INTERVAL", time.Hour))
+
+	// Job purge tempat sampah: hapus permanen draft yang melewati masa retensi
+	go achService.StartTrashPurgeJob(context.Background(), envDuration("TRASH_PURGE_INTERVAL", 24*time.Hour))
+
+	// Job masa berlaku sertifikat: ingatkan mahasiswa sebelum sertifikat kedaluwarsa
+	go achService.StartCertificationExpiryJob(context.Background(), envDuration("CERTIFICATION_CHECK_INTERVAL", 24*time.Hour))
+
+	// Salin tags prestasi lama dari MongoDB agar ikut filter & statistik tag
+	go achService.SyncLegacyTags(context.Background())
+
 	// 5. Setup Middleware
 	// ---------------------------------------------------------
 	// AuthMiddleware: Butuh RoleRepo untuk validasi permission
 	authMiddleware := middleware.NewAuthMiddleware(roleRepo)
 
+	// IdempotencyMiddleware: snapshot response per Idempotency-Key (Postgres),
+	// key kedaluwarsa dibersihkan setiap jam
+	idemMiddleware := middleware.NewIdempotencyMiddleware(repository.NewIdempotencyRepository(db.Postgres))
+	go idemMiddleware.StartCleanupJob(context.Background(), time.Hour)
+
 	// 6. Initialize Fiber App
 	// ---------------------------------------------------------
 	app := fiber.New(fiber.Config{
@@ -70,7 +90,7 @@ func main() {
 
 	// Default Middlewares
 	app.Use(logger.New()) // Log request ke terminal
-	app.Use(cors.New())   // Enable CORS
+	app.Use(cors.New(cors.Config{ExposeHeaders: "ETag, Idempotent-Replayed"})) // Enable CORS (header ETag & replay terbaca di browser)
 
 	app.Static("/docs", "./docs")
 
@@ -81,7 +101,7 @@ func main() {
 	// 8. Setup Routes (Wiring Semua Komponen)
 	// ---------------------------------------------------------
 	// Mengirimkan app, services, dan middleware ke router
-	route.SetupRoutes(app, authService, achService, authMiddleware)
+	route.SetupRoutes(app, authService, achService, authMiddleware, idemMiddleware)
 
 	// 9. Start Server
 	// ---------------------------------------------------------
@@ -95,3 +115,17 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// envDuration membaca durasi dari env; kosong atau tidak valid memakai def
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("⚠️  Warning: invalid %s, using %s", name, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
+	"github.com/WedhaWS/uasgosmt5/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type IdempotencyMiddleware struct {
+	repo *repository.IdempotencyRepository
+}
+
+func NewIdempotencyMiddleware(repo *repository.IdempotencyRepository) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{repo: repo}
+}
+
+// ---------------------------------------------------------------------
+// Idempotent
+// Tugas: Request dengan header Idempotency-Key hanya diproses sekali per user.
+// Pengulangan dengan isi yang sama mendapat response pertama (header
+// Idempotent-Replayed: true); key yang dipakai ulang dengan isi berbeda ditolak.
+// Dipasang setelah AuthRequired karena key dicatat per user_id.
+// ---------------------------------------------------------------------
+func (m *IdempotencyMiddleware) Idempotent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(model.IdempotencyKeyHeader))
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Idempotency-Key must be at most 255 characters"})
+		}
+
+		hash, err := requestHash(c)
+		if err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Failed to read request body"})
+		}
+
+		userID, _ := c.Locals("user_id").(string)
+		method, path := c.Method(), c.Path()
+
+		existing, err := m.repo.Reserve(c.Context(), userID, key, method, path, hash, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] Failed to reserve idempotency key: %v", err)
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to process Idempotency-Key"})
+		}
+
+		if existing != nil {
+			switch {
+			case !existing.Matches(method, path, hash):
+				return c.Status(422).JSON(model.WebResponse{Code: 422, Status: "error", Message: "Idempotency-Key has already been used with a different request"})
+			case existing.StatusCode == nil:
+				return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "A request with this Idempotency-Key is still being processed"})
+			}
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, existing.ContentType)
+			return c.Status(*existing.StatusCode).Send(existing.ResponseBody)
+		}
+
+		// Error yang dikembalikan handler dirender oleh ErrorHandler setelah
+		// middleware selesai, jadi tidak bisa di-snapshot: lepas key-nya.
+		// Begitu juga 5xx, agar klien bisa mengulang dengan key yang sama.
+		if err := c.Next(); err != nil {
+			m.release(c.Context(), userID, key)
+			return err
+		}
+		status := c.Response().StatusCode()
+		if status >= 500 {
+			m.release(c.Context(), userID, key)
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if err := m.repo.Complete(c.Context(), userID, key, status, contentType, body, time.Now()); err != nil {
+			log.Printf("[ERROR] Failed to store idempotent response: %v", err)
+		}
+		return nil
+	}
+}
+
+func (m *IdempotencyMiddleware) release(ctx context.Context, userID, key string) {
+	if err := m.repo.Release(ctx, userID, key); err != nil {
+		log.Printf("[ERROR] Failed to release idempotency key: %v", err)
+	}
+}
+
+// StartCleanupJob menghapus key kedaluwarsa setiap interval sampai ctx selesai
+func (m *IdempotencyMiddleware) StartCleanupJob(ctx context.Context, interval time.Duration) {
+	utils.RunPeriodic(ctx, interval, func(ctx context.Context) {
+		if n, err := m.repo.DeleteExpired(ctx, time.Now()); err != nil {
+			log.Printf("[ERROR] Idempotency key cleanup failed: %v", err)
+		} else if n > 0 {
+			log.Printf("[IDEMPOTENCY] removed %d expired keys", n)
+		}
+	})
+}
+
+// requestHash menghitung SHA-256 isi request. Upload multipart di-hash per
+// field & isi file (bukan body mentah) karena boundary berbeda di setiap
+// pengulangan.
+func requestHash(c *fiber.Ctx) (string, error) {
+	h := sha256.New()
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		h.Write(c.Body())
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte("field:" + name + "\n"))
+		for _, v := range form.Value[name] {
+			h.Write([]byte(v + "\n"))
+		}
+	}
+
+	names = names[:0]
+	for name := range form.File {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, fh := range form.File[name] {
+			h.Write([]byte("file:" + name + ":" + fh.Filename + "\n"))
+			f, err := fh.Open()
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -12,6 +12,7 @@ func SetupRoutes(
 	authService *service.AuthService,
 	achService *service.AchievementService,
 	authMiddleware *middleware.AuthMiddleware,
+	idemMiddleware *middleware.IdempotencyMiddleware,
 ) {
 	api := app.Group("/api/v1")
 
@@ -45,24 +46,88 @@ func SetupRoutes(
 
 	// List (filtered by role)
 	ach.Get("/", achService.GetAll)
+	// Tempat sampah draft yang dihapus (Mahasiswa) & purge manual (Admin).
+	// Didaftarkan sebelum /:id agar "trash" tidak terbaca sebagai :id
+	ach.Get("/trash", achService.GetTrash)
+	ach.Post("/trash/purge", authMiddleware.PermissionRequired("user:manage"), achService.RunTrashPurge)
 	// Detail
 	ach.Get("/:id", achService.GetDetail)
 	// Create (Mahasiswa)
-	ach.Post("/", authMiddleware.PermissionRequired("achievement:create"), achService.Submit)
+	// Header Idempotency-Key (opsional) mencegah duplikat saat klien mengulang request
+	ach.Post("/", authMiddleware.PermissionRequired("achievement:create"), idemMiddleware.Idempotent(), achService.Submit)
 	// Update (Mahasiswa)
 	ach.Put("/:id", authMiddleware.PermissionRequired("achievement:update"), achService.Update)
+	// Partial update / merge patch (Mahasiswa)
+	ach.Patch("/:id", authMiddleware.PermissionRequired("achievement:update"), achService.Patch)
 	// Delete (Mahasiswa)
 	ach.Delete("/:id", authMiddleware.PermissionRequired("achievement:delete"), achService.Delete)
+	// Pulihkan draft dari tempat sampah (Mahasiswa, selama masa retensi)
+	ach.Post("/:id/restore", authMiddleware.PermissionRequired("achievement:delete"), achService.Restore)
 	// Submit for verification
-	ach.Post("/:id/submit", authMiddleware.PermissionRequired("achievement:create"), achService.RequestVerification)
-	// Verify (Dosen Wali)
-	ach.Post("/:id/verify", authMiddleware.PermissionRequired("achievement:verify"), achService.Verify)
-	// Reject (Dosen Wali)
-	ach.Post("/:id/reject", authMiddleware.PermissionRequired("achievement:verify"), achService.Reject)
+	ach.Post("/:id/submit", authMiddleware.PermissionRequired("achievement:create"), idemMiddleware.Idempotent(), achService.RequestVerification)
+	// Withdraw submission back to draft (Mahasiswa)
+	ach.Post("/:id/withdraw", authMiddleware.PermissionRequired("achievement:create"), achService.Withdraw)
+	// Reopen rejected achievement for revision (Mahasiswa)
+	ach.Post("/:id/revise", authMiddleware.PermissionRequired("achievement:update"), achService.Revise)
+	// Verify / Reject massal dengan laporan per item. Didaftarkan sebelum
+	// /:id/verify agar "bulk" tidak terbaca sebagai :id
+	ach.Post("/bulk/verify", achService.BulkVerify)
+	ach.Post("/bulk/reject", achService.BulkReject)
+	// Verify / Reject tahap aktif (Dosen Wali, Kemahasiswaan, ...)
+	// Permission dicek per tahap sesuai konfigurasi alur verifikasi
+	ach.Post("/:id/verify", idemMiddleware.Idempotent(), achService.Verify)
+	ach.Post("/:id/reject", idemMiddleware.Idempotent(), achService.Reject)
+	// Cabut prestasi terverifikasi (Admin)
+	ach.Post("/:id/revoke", authMiddleware.PermissionRequired("user:manage"), achService.Revoke)
 	// Status history
 	ach.Get("/:id/history", achService.GetHistory)
+	// Versi konten & diff antar versi. /versions/diff didaftarkan sebelum
+	// /versions/:version agar "diff" tidak terbaca sebagai nomor versi
+	ach.Get("/:id/versions", achService.GetContentVersions)
+	ach.Get("/:id/versions/diff", achService.GetContentDiff)
+	ach.Get("/:id/versions/:version", achService.GetContentVersion)
+	// Prestasi tim: ketua mengatur anggota, anggota mengonfirmasi/menolak
+	ach.Get("/:id/team", achService.GetTeam)
+	ach.Put("/:id/team", authMiddleware.PermissionRequired("achievement:update"), achService.UpdateTeam)
+	ach.Post("/:id/team/confirm", authMiddleware.PermissionRequired("achievement:create"), achService.ConfirmTeamMembership)
+	ach.Post("/:id/team/decline", authMiddleware.PermissionRequired("achievement:create"), achService.DeclineTeamMembership)
+	// Diskusi (pemilik, dosen wali, verifikator)
+	ach.Get("/:id/comments", achService.GetComments)
+	ach.Post("/:id/comments", achService.CreateComment)
+	ach.Patch("/:id/comments/:commentId", achService.UpdateComment)
+	ach.Post("/:id/comments/:commentId/resolve", achService.ResolveComment)
+	ach.Post("/:id/comments/:commentId/reopen", achService.ReopenComment)
 	// Upload files
-	ach.Post("/:id/attachments", authMiddleware.PermissionRequired("achievement:update"), achService.UploadAttachment)
+	ach.Post("/:id/attachments", authMiddleware.PermissionRequired("achievement:update"), idemMiddleware.Idempotent(), achService.UploadAttachment)
+	// Points ledger (riwayat poin, koreksi & kedaluwarsa oleh Admin)
+	ach.Get("/:id/points", achService.GetAchievementPoints)
+	ach.Post("/:id/points/adjustments", authMiddleware.PermissionRequired("user:manage"), achService.AdjustPoints)
+	ach.Post("/:id/points/expire", authMiddleware.PermissionRequired("user:manage"), achService.ExpirePoints)
+
+	// Registry tipe prestasi & schema details (form dinamis di frontend)
+	achTypes := api.Group("/achievement-types", authMiddleware.AuthRequired())
+	achTypes.Get("/", achService.GetAchievementTypes)
+	achTypes.Get("/:key", achService.GetAchievementType)
+	achTypes.Post("/", authMiddleware.PermissionRequired("user:manage"), achService.CreateAchievementType)
+	achTypes.Put("/:key", authMiddleware.PermissionRequired("user:manage"), achService.UpdateAchievementType)
+
+	// Kosakata tag (hierarki & sinonim) dan autocomplete. /suggest didaftarkan
+	// sebelum /:key agar "suggest" tidak terbaca sebagai key
+	tags := api.Group("/tags", authMiddleware.AuthRequired())
+	tags.Get("/", achService.GetTags)
+	tags.Get("/suggest", achService.SuggestTags)
+	tags.Get("/:key", achService.GetTag)
+	tags.Post("/", authMiddleware.PermissionRequired("user:manage"), achService.CreateTag)
+	tags.Put("/:key", authMiddleware.PermissionRequired("user:manage"), achService.UpdateTag)
+
+	// Rubrik poin berversi & ledger poin
+	points := api.Group("/points", authMiddleware.AuthRequired())
+	points.Get("/leaderboard", achService.GetLeaderboard)
+	points.Get("/rubrics", authMiddleware.PermissionRequired("user:manage"), achService.GetRubrics)
+	points.Get("/rubrics/:version", authMiddleware.PermissionRequired("user:manage"), achService.GetRubric)
+	points.Post("/rubrics", authMiddleware.PermissionRequired("user:manage"), achService.CreateRubric)
+	points.Put("/rubrics/:version/activate", authMiddleware.PermissionRequired("user:manage"), achService.ActivateRubric)
+	points.Post("/ledger/:entryId/reverse", authMiddleware.PermissionRequired("user:manage"), achService.ReverseLedgerEntry)
 
 	// =================================================================
 	// 5.5 Students & Lecturers
@@ -71,16 +136,31 @@ func SetupRoutes(
 	students.Get("/", authService.GetAllStudents)
 	students.Get("/:id", authService.GetStudentDetail)
 	students.Get("/:id/achievements", achService.GetStudentAchievements)
+	students.Get("/:id/points", achService.GetStudentPoints)
 	students.Put("/:id/advisor", authMiddleware.PermissionRequired("user:manage"), authService.UpdateStudentAdvisor)
+	students.Get("/:id/advisor-history", authMiddleware.PermissionRequired("user:manage"), authService.GetAdvisorHistory)
 
 	lecturers := api.Group("/lecturers", authMiddleware.AuthRequired())
 	lecturers.Get("/", authService.GetAllLecturers)
+	lecturers.Get("/:id", achService.GetLecturerDetail)
 	lecturers.Get("/:id/advisees", achService.GetAdviseeAchievements)
 
+	// Verifikasi bertahap: antrian per tahap, konfigurasi alur & SLA (Admin)
+	verification := api.Group("/verification", authMiddleware.AuthRequired())
+	verification.Get("/queue", achService.GetVerificationQueue)
+	verification.Get("/pipelines", authMiddleware.PermissionRequired("user:manage"), achService.GetPipelines)
+	verification.Put("/pipelines", authMiddleware.PermissionRequired("user:manage"), achService.UpdatePipeline)
+	verification.Get("/slas", authMiddleware.PermissionRequired("user:manage"), achService.GetSLAs)
+	verification.Post("/slas/run", authMiddleware.PermissionRequired("user:manage"), achService.RunSLACheck)
+	verification.Put("/slas/:stageKey", authMiddleware.PermissionRequired("user:manage"), achService.UpdateSLA)
+	verification.Delete("/slas/:stageKey", authMiddleware.PermissionRequired("user:manage"), achService.DeleteSLA)
+
 	// =================================================================
 	// 5.8 Reports & Analytics
 	// =================================================================
 	reports := api.Group("/reports", authMiddleware.AuthRequired())
 	reports.Get("/statistics", achService.GetStatistics)
+	reports.Get("/duplicates", authMiddleware.PermissionRequired("user:manage"), achService.GetDuplicateReport)
+	reports.Post("/certifications/run", authMiddleware.PermissionRequired("user:manage"), achService.RunCertificationCheck)
 	reports.Get("/student/:id", achService.GetStudentStatistics)
 }
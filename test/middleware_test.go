@@ -1,16 +1,24 @@
 package test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
+	"github.com/WedhaWS/uasgosmt5/app/repository"
 	"github.com/WedhaWS/uasgosmt5/middleware"
 	"github.com/WedhaWS/uasgosmt5/utils"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthMiddleware_AuthRequired(t *testing.T) {
@@ -284,3 +292,128 @@ func TestAuthMiddleware_IntegrationFlow(t *testing.T) {
 	assert.Contains(t, capturedPermissions, "user:manage")
 	assert.Contains(t, capturedPermissions, "achievement:verify")
 }
+
+func TestIdempotencyMiddleware_Idempotent(t *testing.T) {
+	body := `{"title":"Juara 1 GEMASTIK"}`
+	sum := sha256.Sum256([]byte(body))
+	bodyHash := hex.EncodeToString(sum[:])
+
+	setup := func(t *testing.T) (*fiber.App, sqlmock.Sqlmock, *int) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		idemMiddleware := middleware.NewIdempotencyMiddleware(repository.NewIdempotencyRepository(db))
+		calls := 0
+
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", "user-123")
+			return c.Next()
+		})
+		app.Post("/achievements", idemMiddleware.Idempotent(), func(c *fiber.Ctx) error {
+			calls++
+			return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "created"})
+		})
+		return app, mock, &calls
+	}
+
+	post := func(app *fiber.App, key, body string) (int, string, string) {
+		req := httptest.NewRequest("POST", "/achievements", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(model.IdempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Idempotent-Replayed"), string(raw)
+	}
+
+	t.Run("First request is processed and its response stored", func(t *testing.T) {
+		app, mock, calls := setup(t)
+
+		mock.ExpectExec(`DELETE FROM idempotency_keys WHERE user_id = \$1 AND key = \$2 AND created_at < \$3`).
+			WithArgs("user-123", "key-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO idempotency_keys`).
+			WithArgs("user-123", "key-1", "POST", "/achievements", bodyHash, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE idempotency_keys\s+SET status_code = \$3`).
+			WithArgs("user-123", "key-1", 201, "application/json", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		status, replayed, _ := post(app, "key-1", body)
+
+		// Assertions
+		assert.Equal(t, 201, status)
+		assert.Empty(t, replayed)
+		assert.Equal(t, 1, *calls)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry with the same payload replays the stored response", func(t *testing.T) {
+		app, mock, calls := setup(t)
+		stored := `{"code":201,"status":"success","message":"created"}`
+
+		mock.ExpectExec(`DELETE FROM idempotency_keys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO idempotency_keys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT method, path, request_hash, status_code, content_type, response_body, created_at, completed_at\s+FROM idempotency_keys`).
+			WithArgs("user-123", "key-1").
+			WillReturnRows(sqlmock.NewRows([]string{"method", "path", "request_hash", "status_code", "content_type", "response_body", "created_at", "completed_at"}).
+				AddRow("POST", "/achievements", bodyHash, 201, "application/json", []byte(stored), time.Now(), time.Now()))
+
+		// Execute
+		status, replayed, raw := post(app, "key-1", body)
+
+		// Assertions
+		assert.Equal(t, 201, status)
+		assert.Equal(t, "true", replayed)
+		assert.JSONEq(t, stored, raw)
+		assert.Equal(t, 0, *calls)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key reused with a different payload is rejected", func(t *testing.T) {
+		app, mock, calls := setup(t)
+
+		mock.ExpectExec(`DELETE FROM idempotency_keys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO idempotency_keys`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT method, path, request_hash`).
+			WillReturnRows(sqlmock.NewRows([]string{"method", "path", "request_hash", "status_code", "content_type", "response_body", "created_at", "completed_at"}).
+				AddRow("POST", "/achievements", bodyHash, 201, "application/json", []byte(`{}`), time.Now(), time.Now()))
+
+		// Execute
+		status, _, _ := post(app, "key-1", `{"title":"Juara 2 GEMASTIK"}`)
+
+		// Assertions
+		assert.Equal(t, 422, status)
+		assert.Equal(t, 0, *calls)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Requests without a key are not tracked", func(t *testing.T) {
+		app, mock, calls := setup(t)
+
+		// Execute
+		status, _, _ := post(app, "", body)
+
+		// Assertions
+		assert.Equal(t, 201, status)
+		assert.Equal(t, 1, *calls)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
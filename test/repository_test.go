@@ -1,15 +1,25 @@
 package test
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
 	"github.com/WedhaWS/uasgosmt5/app/repository"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func TestUserRepository_FindByEmail(t *testing.T) {
@@ -163,6 +173,96 @@ func TestUserRepository_Create(t *testing.T) {
 		// Verify all expectations were met
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("Duplicate email is mapped to domain error", func(t *testing.T) {
+		user := &model.User{Username: "dupe", Email: "dupe@example.com", RoleID: "role-123"}
+
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{
+				Code:       "23505",
+				Table:      "users",
+				Constraint: "users_email_key",
+				Detail:     "Key (email)=(dupe@example.com) already exists.",
+				Message:    `duplicate key value violates unique constraint "users_email_key"`,
+			})
+
+		// Execute
+		err := userRepo.Create(user)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+		var ce *repository.ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "email", ce.Field)
+		assert.NotContains(t, err.Error(), "duplicate key value")
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown role is mapped to invalid reference", func(t *testing.T) {
+		user := &model.User{Username: "norole", Email: "norole@example.com", RoleID: "missing"}
+
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{
+				Code:       "23503",
+				Table:      "users",
+				Constraint: "users_role_id_fkey",
+				Detail:     `Key (role_id)=(missing) is not present in table "roles".`,
+			})
+
+		// Execute
+		err := userRepo.Create(user)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrInvalidReference)
+		var ce *repository.ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "roleId", ce.Field)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_SaveStudent(t *testing.T) {
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Create repository
+	userRepo := repository.NewUserRepository(db)
+
+	t.Run("Duplicate NIM is mapped to studentId", func(t *testing.T) {
+		student := &model.Student{UserID: "user-123", StudentID: "434231044", ProgramStudy: "TI", AcademicYear: "2023"}
+
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM students WHERE user_id = \$1\)`).
+			WithArgs("user-123").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(`INSERT INTO students`).
+			WithArgs("user-123", "434231044", "TI", "2023").
+			WillReturnError(&pq.Error{
+				Code:       "23505",
+				Table:      "students",
+				Constraint: "students_student_id_key",
+				Detail:     "Key (student_id)=(434231044) already exists.",
+			})
+
+		// Execute
+		err := userRepo.SaveStudent(student)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+		var ce *repository.ConstraintError
+		require.ErrorAs(t, err, &ce)
+		assert.Equal(t, "studentId", ce.Field)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestUserRepository_FindStudentByUserID(t *testing.T) {
@@ -251,11 +351,12 @@ func TestUserRepository_Update(t *testing.T) {
 			Email:    "updated@example.com",
 			FullName: "Updated User",
 			IsActive: true,
+			Version:  3,
 		}
 
 		// Set up mock expectations
-		mock.ExpectExec(`UPDATE users SET full_name = \$1, username = \$2, email = \$3, is_active = \$4, updated_at = \$5 WHERE id = \$6`).
-			WithArgs(user.FullName, user.Username, user.Email, user.IsActive, sqlmock.AnyArg(), user.ID).
+		mock.ExpectExec(`UPDATE users SET full_name = \$1, username = \$2, email = \$3, is_active = \$4, updated_at = \$5, version = version \+ 1 WHERE id = \$6 AND \(\$7::int = 0 OR version = \$7\)`).
+			WithArgs(user.FullName, user.Username, user.Email, user.IsActive, sqlmock.AnyArg(), user.ID, 3).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		// Execute
@@ -263,6 +364,7 @@ func TestUserRepository_Update(t *testing.T) {
 
 		// Assertions
 		assert.NoError(t, err)
+		assert.Equal(t, 4, user.Version)
 
 		// Verify all expectations were met
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -279,7 +381,7 @@ func TestUserRepository_Update(t *testing.T) {
 
 		// Set up mock to return error
 		mock.ExpectExec(`UPDATE users`).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnError(sql.ErrConnDone)
 
 		// Execute
@@ -292,6 +394,71 @@ func TestUserRepository_Update(t *testing.T) {
 		// Verify all expectations were met
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("Stale version is rejected", func(t *testing.T) {
+		user := &model.User{
+			ID:       "user-123",
+			Username: "staleuser",
+			Email:    "stale@example.com",
+			FullName: "Stale User",
+			IsActive: true,
+			Version:  2,
+		}
+
+		// Admin lain sudah menaikkan version menjadi 3
+		mock.ExpectExec(`UPDATE users`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), user.ID, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		err := userRepo.Update(user)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		assert.Equal(t, 2, user.Version)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_UpdateRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	userRepo := repository.NewUserRepository(db)
+
+	roleQuery := `UPDATE users SET role_id = \$1, updated_at = \$2, version = version \+ 1 WHERE id = \$3 AND \(\$4::int = 0 OR version = \$4\)`
+
+	t.Run("Role is changed when the version still matches", func(t *testing.T) {
+		mock.ExpectExec(roleQuery).
+			WithArgs("role-lecturer", sqlmock.AnyArg(), "user-123", 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		err := userRepo.UpdateRole("user-123", "role-lecturer", 3)
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stale version is reported as ErrVersionMismatch", func(t *testing.T) {
+		mock.ExpectExec(roleQuery).
+			WithArgs("role-lecturer", sqlmock.AnyArg(), "user-123", 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		err := userRepo.UpdateRole("user-123", "role-lecturer", 3)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestUserRepository_Delete(t *testing.T) {
@@ -329,3 +496,1828 @@ func TestUserRepository_Delete(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestUserRepository_AssignAdvisor(t *testing.T) {
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Create repository
+	userRepo := repository.NewUserRepository(db)
+
+	t.Run("Keep policy holds submitted achievements with previous advisor", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-old"))
+		mock.ExpectExec(`UPDATE advisor_assignments SET effective_to = \$1 WHERE student_id = \$2 AND effective_to IS NULL`).
+			WithArgs(sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO advisor_assignments`).
+			WithArgs("student-123", "lecturer-new", sqlmock.AnyArg(), "admin-1", model.HandoverKeep, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE students SET advisor_id = \$1 WHERE id = \$2`).
+			WithArgs("lecturer-new", "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET assigned_verifier_id = \$1`).
+			WithArgs("lecturer-old", sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		// Execute
+		result, err := userRepo.AssignAdvisor("student-123", "lecturer-new", "admin-1", model.HandoverKeep)
+
+		// Assertions
+		assert.NoError(t, err)
+		require.NotNil(t, result)
+		require.NotNil(t, result.PreviousAdvisorID)
+		assert.Equal(t, "lecturer-old", *result.PreviousAdvisorID)
+		assert.Equal(t, int64(2), result.PendingAffected)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Same advisor is rejected and rolled back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-new"))
+		mock.ExpectRollback()
+
+		// Execute
+		result, err := userRepo.AssignAdvisor("student-123", "lecturer-new", "admin-1", model.HandoverTransfer)
+
+		// Assertions
+		assert.Error(t, err)
+		assert.Nil(t, result)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing policy is rejected inside the transaction when submissions are pending", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-old"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(\s+SELECT id FROM achievement_references\s+WHERE student_id = \$1 AND status = 'submitted'\s+AND \(assigned_verifier_id IS NULL OR assigned_verifier_id = \$2\)\s+FOR UPDATE`).
+			WithArgs("student-123", "lecturer-old").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectRollback()
+
+		// Execute
+		result, err := userRepo.AssignAdvisor("student-123", "lecturer-new", "admin-1", "")
+
+		// Assertions
+		var policyErr *repository.HandoverPolicyRequiredError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, int64(3), policyErr.Pending)
+		assert.Nil(t, result)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing policy defaults to transfer when nothing is pending", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-old"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM`).
+			WithArgs("student-123", "lecturer-old").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(`UPDATE advisor_assignments SET effective_to = \$1`).
+			WithArgs(sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO advisor_assignments`).
+			WithArgs("student-123", "lecturer-new", sqlmock.AnyArg(), "admin-1", model.HandoverTransfer, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE students SET advisor_id = \$1 WHERE id = \$2`).
+			WithArgs("lecturer-new", "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET assigned_verifier_id = NULL`).
+			WithArgs(sqlmock.AnyArg(), "student-123", "lecturer-old").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		// Execute
+		result, err := userRepo.AssignAdvisor("student-123", "lecturer-new", "admin-1", "")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, model.HandoverTransfer, result.HandoverPolicy)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transfer after keep leaves items held by the first advisor untouched", func(t *testing.T) {
+		// Penugasan pertama: lecturer-old -> lecturer-mid dengan policy keep
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-old"))
+		mock.ExpectExec(`UPDATE advisor_assignments SET effective_to = \$1`).
+			WithArgs(sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO advisor_assignments`).
+			WithArgs("student-123", "lecturer-mid", sqlmock.AnyArg(), "admin-1", model.HandoverKeep, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE students SET advisor_id = \$1 WHERE id = \$2`).
+			WithArgs("lecturer-mid", "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET assigned_verifier_id = \$1`).
+			WithArgs("lecturer-old", sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		kept, err := userRepo.AssignAdvisor("student-123", "lecturer-mid", "admin-1", model.HandoverKeep)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), kept.PendingAffected)
+
+		// Penugasan kedua: lecturer-mid -> lecturer-new dengan policy transfer.
+		// Hanya prestasi tanpa penahanan atau milik lecturer-mid yang dipindahkan.
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT advisor_id FROM students WHERE id = \$1 FOR UPDATE`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"advisor_id"}).AddRow("lecturer-mid"))
+		mock.ExpectExec(`UPDATE advisor_assignments SET effective_to = \$1`).
+			WithArgs(sqlmock.AnyArg(), "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO advisor_assignments`).
+			WithArgs("student-123", "lecturer-new", sqlmock.AnyArg(), "admin-1", model.HandoverTransfer, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE students SET advisor_id = \$1 WHERE id = \$2`).
+			WithArgs("lecturer-new", "student-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET assigned_verifier_id = NULL, updated_at = \$1\s+WHERE student_id = \$2 AND status = 'submitted'\s+AND \(assigned_verifier_id IS NULL OR assigned_verifier_id = \$3\)`).
+			WithArgs(sqlmock.AnyArg(), "student-123", "lecturer-mid").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		transferred, err := userRepo.AssignAdvisor("student-123", "lecturer-new", "admin-1", model.HandoverTransfer)
+
+		// Assertions
+		require.NoError(t, err)
+		require.NotNil(t, transferred.PreviousAdvisorID)
+		assert.Equal(t, "lecturer-mid", *transferred.PreviousAdvisorID)
+		assert.Equal(t, int64(2), transferred.PendingAffected)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_FindAdvisorHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db)
+	columns := []string{"id", "student_id", "lecturer_id", "effective_from", "effective_to", "assigned_by", "handover_policy", "created_at", "lecturer_id", "department", "full_name"}
+
+	t.Run("Student without advisor returns an empty history", func(t *testing.T) {
+		mock.ExpectQuery(`FROM advisor_assignments aa`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM students WHERE id = \$1\)`).
+			WithArgs("student-123").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		// Execute
+		history, err := userRepo.FindAdvisorHistory("student-123")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Empty(t, history)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown student returns ErrStudentNotFound", func(t *testing.T) {
+		mock.ExpectQuery(`FROM advisor_assignments aa`).
+			WithArgs("student-404").
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM students WHERE id = \$1\)`).
+			WithArgs("student-404").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		// Execute
+		history, err := userRepo.FindAdvisorHistory("student-404")
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrStudentNotFound)
+		assert.Nil(t, history)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_FindAll(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	t.Run("Advisor list hides submissions held by the previous advisor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM achievement_references ar.+WHERE \(\(s\.advisor_id = \$1 AND NOT \(ar\.status = 'submitted' AND ar\.assigned_verifier_id IS NOT NULL AND ar\.assigned_verifier_id != \$1\)\)\s+OR ar\.assigned_verifier_id = \$1`).
+			WithArgs("lecturer-new", "deleted").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT\s+ar\.id, ar\.student_id`).
+			WithArgs("lecturer-new", "deleted").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute
+		data, total, err := achRepo.FindAll(model.PaginationParam{Page: 1, Limit: 10}, "", "lecturer-new")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Empty(t, data)
+		assert.Equal(t, int64(0), total)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tag filter matches the canonical tag, its descendants and synonyms", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		tagFilter := `ar\.tags && ARRAY\(\s*WITH RECURSIVE subtree AS \(\s*` +
+			`SELECT key FROM tags WHERE key = COALESCE\(\(SELECT tag_key FROM tag_synonyms WHERE synonym = \$2::text\), \$2::text\)\s*` +
+			`UNION\s*SELECT t\.key FROM tags t JOIN subtree ON t\.parent_key = subtree\.key\s*\)\s*` +
+			`SELECT key::text FROM subtree\s*` +
+			`UNION SELECT synonym::text FROM tag_synonyms WHERE tag_key IN \(SELECT key FROM subtree\)\s*` +
+			`UNION SELECT \$2::text\s*\)`
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM achievement_references ar.+WHERE ar\.status != \$1 AND ` + tagFilter).
+			WithArgs("deleted", "machine-learning").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT\s+ar\.id, ar\.student_id.+` + tagFilter).
+			WithArgs("deleted", "machine-learning").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// Execute: filter dinormalisasi sebelum dikirim ke query
+		_, total, err := achRepo.FindAll(model.PaginationParam{Page: 1, Limit: 10, Tag: " Machine Learning "}, "", "")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_UpdateStatus(t *testing.T) {
+	// Client Mongo tidak dipakai untuk status selain 'verified' (koneksi lazy)
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	updateQuery := `UPDATE achievement_references`
+	// Keputusan tahap & riwayat menyalin versi konten yang sedang diputuskan
+	stageDecisionQuery := `UPDATE achievement_verification_stages\s+SET status = \$1, decided_by = \$2, decided_at = \$3, note = \$4, points = \$5,\s+content_version = \(SELECT NULLIF\(content_version, 0\) FROM achievement_references WHERE id = \$6\)`
+	historyQuery := `INSERT INTO achievement_status_history .+ NULLIF\(ar.content_version, 0\) FROM achievement_references ar WHERE ar.id = \$1`
+	ctx := context.Background()
+
+	t.Run("Reject records the decided content version in stage and history", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Sertifikat tidak terbaca", "ach-123", "submitted", 2, nil, "advisor").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("507f1f77bcf86cd799439011", 1))
+		mock.ExpectExec(stageDecisionQuery).
+			WithArgs("rejected", "lecturer-user-1", sqlmock.AnyArg(), "Sertifikat tidak terbaca", nil, "ach-123", 1, "advisor").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(historyQuery).
+			WithArgs("ach-123", "submitted", "rejected", "lecturer-user-1", "Sertifikat tidak terbaca", nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "submitted", ExpectedVersion: 2,
+			Status: "rejected", ActorID: "lecturer-user-1", Note: "Sertifikat tidak terbaca", Stage: "advisor",
+		})
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Submit opens a new round with pipeline stages", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		pipeline := []model.PipelineStage{
+			{Order: 1, Key: model.StageAdvisor, Name: "Dosen Wali", Permission: "achievement:verify"},
+			{Order: 2, Key: model.StageStudentAffairs, Name: "Kemahasiswaan", Permission: "achievement:approve_affairs"},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE achievement_references .+submitted_at = CASE WHEN \$1 = 'submitted' THEN \$2 ELSE submitted_at END`).
+			WithArgs("submitted", sqlmock.AnyArg(), "student-user-1", "", "ach-123", "draft", 1, "advisor", "").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("507f1f77bcf86cd799439011", 2))
+		mock.ExpectExec(`INSERT INTO achievement_verification_stages`).
+			WithArgs("ach-123", 2, 1, "advisor", "Dosen Wali", "achievement:verify", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO achievement_verification_stages`).
+			WithArgs("ach-123", 2, 2, "student_affairs", "Kemahasiswaan", "achievement:approve_affairs", nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO achievement_status_history`).
+			WithArgs("ach-123", "draft", "submitted", "student-user-1", "", nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "draft", ExpectedVersion: 1,
+			Status: "submitted", ActorID: "student-user-1", Pipeline: pipeline,
+		})
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Final verify stores rubric result and override reason", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs("verified", sqlmock.AnyArg(), "lecturer-user-1", "Juara umum, bobot lebih tinggi", "ach-123", "submitted", 5, nil, "advisor").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("", 1))
+		mock.ExpectExec(stageDecisionQuery).
+			WithArgs("approved", "lecturer-user-1", sqlmock.AnyArg(), "Juara umum, bobot lebih tinggi", 120, "ach-123", 1, "advisor").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET suggested_points = \$1, rubric_version = \$2, points_override_reason = \$3`).
+			WithArgs(100, 2, "Juara umum, bobot lebih tinggi", "ach-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "award", 120, "Juara umum, bobot lebih tinggi", "lecturer-user-1", nil, 2, sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("ledger-1", "student-1"))
+		mock.ExpectExec(historyQuery).
+			WithArgs("ach-123", "submitted", "verified", "lecturer-user-1", "Juara umum, bobot lebih tinggi", 120, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "submitted", ExpectedVersion: 5,
+			Status: "verified", ActorID: "lecturer-user-1", Note: "Juara umum, bobot lebih tinggi", Points: 120, Stage: "advisor",
+			Calculation:    &model.PointCalculation{RubricVersion: 2, Points: 100},
+			OverrideReason: "Juara umum, bobot lebih tinggi",
+		})
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke reverses the achievement's points", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs("revoked", sqlmock.AnyArg(), "admin-1", "Sertifikat palsu", "ach-123", "verified", 7, nil, "").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("", 1))
+		mock.ExpectExec(`INSERT INTO points_ledger .+ SELECT l.student_id, l.achievement_ref_id, 'reversal', -l.points`).
+			WithArgs("ach-123", "Sertifikat palsu", "admin-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO achievement_status_history`).
+			WithArgs("ach-123", "verified", "revoked", "admin-1", "Sertifikat palsu", nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "verified", ExpectedVersion: 7,
+			Status: "revoked", ActorID: "admin-1", Note: "Sertifikat palsu",
+		})
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Illegal transition is refused without touching the database", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		// Execute: verified adalah status akhir
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "verified", Status: "rejected", ActorID: "lecturer-user-1", Note: "Terlambat",
+		})
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrInvalidTransition)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stale status returns conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Duplikat", "ach-123", "submitted", 0, nil, "").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}))
+		mock.ExpectQuery(`SELECT status, version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow("verified", 3))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "submitted", Status: "rejected", ActorID: "lecturer-user-1", Note: "Duplikat",
+		})
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrStatusConflict)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown achievement is rolled back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs("submitted", sqlmock.AnyArg(), "student-user-1", "", "missing", "draft", 0, nil, "").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}))
+		mock.ExpectQuery(`SELECT status, version FROM achievement_references WHERE id = \$1`).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, model.StatusChange{
+			ID: "missing", ExpectedStatus: "draft", Status: "submitted", ActorID: "student-user-1",
+		})
+
+		// Assertions
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Repeated reject misses the status and version guard and maps to ErrStatusConflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		// Request kedua dari double-click: UPDATE bersyarat status = 'submitted'
+		// tidak mengenai baris karena request pertama sudah menolaknya
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE achievement_references[\s\S]+WHERE id = \$5 AND status = \$6 AND \(\$7::int = 0 OR version = \$7\)[\s\S]+RETURNING mongo_achievement_id`).
+			WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Bukti kurang", "ach-123", "submitted", 4, nil, "").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}))
+		mock.ExpectQuery(`SELECT status, version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow("rejected", 5))
+		mock.ExpectRollback()
+
+		change := model.StatusChange{
+			ID: "ach-123", ExpectedStatus: "submitted", ExpectedVersion: 4,
+			Status: "rejected", ActorID: "lecturer-user-1", Note: "Bukti kurang",
+		}
+
+		// Execute
+		err = achRepo.UpdateStatus(ctx, change)
+
+		// Assertions: tidak ada riwayat atau pembatalan tahap yang ditulis
+		assert.ErrorIs(t, err, repository.ErrStatusConflict)
+		assert.Contains(t, err.Error(), "expected submitted (version 4), found rejected (version 5)")
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_AdvanceStage(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	change := model.StatusChange{
+		ID: "ach-123", ExpectedStatus: "submitted", ExpectedVersion: 3,
+		Status: "submitted", ActorID: "lecturer-user-1", Points: 80, Stage: model.StageAdvisor,
+	}
+
+	t.Run("Advisor approval moves submission to student affairs", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE achievement_references\s+SET current_stage = \$1`).
+			WithArgs("student_affairs", sqlmock.AnyArg(), "ach-123", "advisor", 3).
+			WillReturnRows(sqlmock.NewRows([]string{"verification_round"}).AddRow(1))
+		mock.ExpectExec(`UPDATE achievement_verification_stages\s+SET status = \$1`).
+			WithArgs("approved", "lecturer-user-1", sqlmock.AnyArg(), "", 80, "ach-123", 1, "advisor").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_verification_stages SET started_at = \$1`).
+			WithArgs(sqlmock.AnyArg(), "ach-123", 1, "student_affairs").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.AdvanceStage(ctx, change, model.StageStudentAffairs)
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stage already decided returns conflict", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE achievement_references\s+SET current_stage = \$1`).
+			WithArgs("student_affairs", sqlmock.AnyArg(), "ach-123", "advisor", 3).
+			WillReturnRows(sqlmock.NewRows([]string{"verification_round"}))
+		mock.ExpectQuery(`SELECT current_stage, version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"current_stage", "version"}).AddRow("student_affairs", 4))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.AdvanceStage(ctx, change, model.StageStudentAffairs)
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrStatusConflict)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_ApplyStatusChanges(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	changes := []model.StatusChange{
+		{
+			ID: "ach-1", ExpectedStatus: "submitted", ExpectedVersion: 2,
+			Status: "rejected", ActorID: "lecturer-user-1", Note: "Bukti kurang", Stage: model.StageAdvisor,
+		},
+		{
+			ID: "ach-2", ExpectedStatus: "submitted", ExpectedVersion: 3,
+			Status: "rejected", ActorID: "lecturer-user-1", Note: "Bukti kurang", Stage: model.StageAdvisor,
+		},
+	}
+
+	t.Run("All decisions commit in one transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		for _, id := range []string{"ach-1", "ach-2"} {
+			mock.ExpectQuery(`UPDATE achievement_references`).
+				WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Bukti kurang", id, "submitted", sqlmock.AnyArg(), nil, "advisor").
+				WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("", 1))
+			mock.ExpectExec(`UPDATE achievement_verification_stages`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO achievement_status_history`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.ApplyStatusChanges(ctx, changes)
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("One conflicting decision rolls back the batch", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE achievement_references`).
+			WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Bukti kurang", "ach-1", "submitted", 2, nil, "advisor").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}).AddRow("", 1))
+		mock.ExpectExec(`UPDATE achievement_verification_stages`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO achievement_status_history`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE achievement_references`).
+			WithArgs("rejected", sqlmock.AnyArg(), "lecturer-user-1", "Bukti kurang", "ach-2", "submitted", 3, nil, "advisor").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "verification_round"}))
+		mock.ExpectQuery(`SELECT status, version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-2").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow("verified", 4))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.ApplyStatusChanges(ctx, changes)
+
+		// Assertions
+		var be *repository.BatchError
+		require.ErrorAs(t, err, &be)
+		assert.Equal(t, 1, be.Index)
+		assert.ErrorIs(t, err, repository.ErrStatusConflict)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_ApproveTeamMembers(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+
+	t.Run("Returns members still waiting for advisor approval", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_team_members\s+SET advisor_approved_by = \$3, advisor_approved_at = \$4`).
+			WithArgs("ach-123", pq.Array([]string{"student-2"}), "lecturer-user-2", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM achievement_team_members`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectCommit()
+
+		// Execute
+		remaining, err := achRepo.ApproveTeamMembers(ctx, "ach-123", []string{"student-2"}, "lecturer-user-2", time.Now())
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, 1, remaining)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Non-members cannot respond to an invitation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectExec(`UPDATE achievement_team_members\s+SET status = \$3, responded_at = \$4`).
+			WithArgs("ach-123", "student-9", "confirmed", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		err = achRepo.RespondTeamInvite(ctx, "ach-123", "student-9", model.MemberConfirmed, time.Now())
+
+		// Assertions
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_SaveDuplicateFlags(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+
+	t.Run("Detail flags are replaced on every check", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		reasons := []string{model.DuplicateCertificationNumber, model.DuplicateSimilarDetails}
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM achievement_duplicate_flags WHERE achievement_ref_id = \$1 AND reason = ANY\(\$2\)`).
+			WithArgs("ach-456", pq.Array(reasons)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`INSERT INTO achievement_duplicate_flags`).
+			WithArgs("ach-456", "ach-123", model.DuplicateCertificationNumber, 1.0, "Same certification number: srt0012024").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("flag-1", time.Now()))
+		mock.ExpectCommit()
+
+		// Execute
+		flags := []model.DuplicateFlag{{MatchedRefID: "ach-123", Reason: model.DuplicateCertificationNumber, Score: 1, Detail: "Same certification number: srt0012024"}}
+		err = achRepo.SaveDuplicateFlags(ctx, "ach-456", flags, reasons)
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, "flag-1", flags[0].ID)
+		assert.Equal(t, "ach-456", flags[0].AchievementRefID)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_Restore(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+
+	t.Run("Deleted draft is restored with history", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT mongo_achievement_id, status FROM achievement_references WHERE id = \$1 FOR UPDATE`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "status"}).AddRow("", "deleted"))
+		mock.ExpectExec(`UPDATE achievement_references SET status = \$1, updated_at = \$2, deleted_at = NULL`).
+			WithArgs("draft", sqlmock.AnyArg(), "ach-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO achievement_status_history`).
+			WithArgs("ach-123", "deleted", "draft", "user-123", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.Restore(ctx, "ach-123", "user-123")
+
+		// Assertions
+		assert.NoError(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Only deleted achievements can be restored", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT mongo_achievement_id, status FROM achievement_references WHERE id = \$1 FOR UPDATE`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "status"}).AddRow("", "draft"))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.Restore(ctx, "ach-123", "user-123")
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrInvalidTransition)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Purge removes the reference and its dependent rows", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT mongo_achievement_id, status FROM achievement_references WHERE id = \$1 FOR UPDATE`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "status"}).AddRow("", "deleted"))
+		for _, table := range []string{"achievement_duplicate_flags", "achievement_attachment_hashes", "achievement_fingerprints", "achievement_team_members", "achievement_comments", "achievement_references"} {
+			mock.ExpectExec(`DELETE FROM ` + table + ` WHERE`).
+				WithArgs("ach-123").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		// Execute
+		files, err := achRepo.Purge(ctx, "ach-123")
+
+		// Assertions
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// contentTitle mencocokkan argumen JSON salinan konten berdasarkan judulnya
+type contentTitle string
+
+func (c contentTitle) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	var doc model.Achievement
+	return ok && json.Unmarshal(raw, &doc) == nil && doc.Title == string(c)
+}
+
+func TestAchievementRepository_AddAttachment(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+
+	mt.Run("Postgres failure pulls the attachment back out of MongoDB", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		mongoID := primitive.NewObjectID()
+		attachment := model.AchievementAttachment{
+			FileName:   "sertifikat.pdf",
+			FileURL:    "/uploads/1700000000_sertifikat.pdf",
+			FileType:   "application/pdf",
+			UploadedAt: time.Now(),
+		}
+
+		mock.ExpectQuery(`SELECT mongo_achievement_id FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id"}).AddRow(mongoID.Hex()))
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{
+				{Key: "_id", Value: mongoID},
+				{Key: "title", Value: "Juara 1 GEMASTIK"},
+			}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET version = version \+ 1`).
+			WithArgs("ach-123", sqlmock.AnyArg()).
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.AddAttachment(ctx, "ach-123", attachment)
+
+		// Assertions
+		require.Error(mt, err)
+		assert.Contains(mt, err.Error(), "failed to record attachment in postgres")
+
+		var pulled []string
+		for _, evt := range mt.GetAllStartedEvents() {
+			if evt.CommandName == "update" {
+				updates, _ := evt.Command.Lookup("updates").Array().Values()
+				pulled = append(pulled, updates[0].Document().Lookup("u", "$pull", "attachments", "fileUrl").StringValue())
+			}
+		}
+		assert.Equal(mt, []string{attachment.FileURL}, pulled)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_UpdateContent(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+
+	mt.Run("Postgres failure restores the previous MongoDB document", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		previous := &model.Achievement{ID: primitive.NewObjectID(), AchievementType: "competition", Title: "Juara 1 GEMASTIK"}
+		updated := *previous
+		updated.Title = "Juara 2 GEMASTIK"
+		ref := &model.AchievementReference{ID: "ach-123", MongoAchievementID: previous.ID.Hex(), Version: 4}
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.UpdateContent(ctx, ref, previous, &updated)
+
+		// Assertions
+		require.Error(mt, err)
+		assert.Contains(mt, err.Error(), "failed to update reference in postgres")
+		assert.Equal(mt, 4, ref.Version)
+
+		var titles []string
+		for _, evt := range mt.GetAllStartedEvents() {
+			if evt.CommandName == "update" {
+				updates, _ := evt.Command.Lookup("updates").Array().Values()
+				titles = append(titles, updates[0].Document().Lookup("u", "$set", "title").StringValue())
+			}
+		}
+		assert.Equal(mt, []string{"Juara 2 GEMASTIK", "Juara 1 GEMASTIK"}, titles)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Stale version is reported as ErrVersionMismatch", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		previous := &model.Achievement{ID: primitive.NewObjectID(), Title: "Juara 1 GEMASTIK"}
+		ref := &model.AchievementReference{ID: "ach-123", MongoAchievementID: previous.ID.Hex(), Version: 4}
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.UpdateContent(ctx, ref, previous, &model.Achievement{Title: "Juara 2 GEMASTIK"})
+
+		// Assertions
+		assert.ErrorIs(mt, err, repository.ErrVersionMismatch)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	expectEdit := func(mt *mtest.T, mock sqlmock.Sqlmock, currentContentVersion int) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT content_version FROM achievement_references WHERE id = \$1 FOR UPDATE`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"content_version"}).AddRow(currentContentVersion))
+	}
+	versionInsert := `INSERT INTO achievement_content_versions`
+
+	mt.Run("Legacy row without versions gets an imported version first", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		previous := &model.Achievement{ID: primitive.NewObjectID(), Title: "Juara 1 GEMASTIK"}
+		ref := &model.AchievementReference{ID: "ach-123", MongoAchievementID: previous.ID.Hex(), Version: 4}
+
+		expectEdit(mt, mock, 0)
+		mock.ExpectExec(versionInsert).
+			WithArgs("ach-123", 1, model.ContentImported, contentTitle("Juara 1 GEMASTIK"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(versionInsert).
+			WithArgs("ach-123", 2, model.ContentEdited, contentTitle("Juara 2 GEMASTIK"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET content_version = \$2 WHERE id = \$1`).
+			WithArgs("ach-123", 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateContent(ctx, ref, previous, &model.Achievement{Title: "Juara 2 GEMASTIK"})
+
+		// Assertions
+		require.NoError(mt, err)
+		assert.Equal(mt, 2, ref.ContentVersion)
+		assert.Equal(mt, 5, ref.Version)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Versioned row only appends the edit", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		previous := &model.Achievement{ID: primitive.NewObjectID(), Title: "Juara 1 GEMASTIK"}
+		ref := &model.AchievementReference{ID: "ach-123", MongoAchievementID: previous.ID.Hex(), Version: 4, ContentVersion: 3}
+
+		expectEdit(mt, mock, 3)
+		mock.ExpectExec(versionInsert).
+			WithArgs("ach-123", 4, model.ContentEdited, contentTitle("Juara 2 GEMASTIK"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET content_version = \$2 WHERE id = \$1`).
+			WithArgs("ach-123", 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.UpdateContent(ctx, ref, previous, &model.Achievement{Title: "Juara 2 GEMASTIK"})
+
+		// Assertions
+		require.NoError(mt, err)
+		assert.Equal(mt, 4, ref.ContentVersion)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_GetStudentStatistics(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	tagQuery := `WITH RECURSIVE ancestors AS .+FROM UNNEST\(ar\.tags\) AS raw\(tag\)\s+LEFT JOIN tag_synonyms syn ON syn\.synonym = raw\.tag.+GROUP BY 1`
+
+	// Agregasi MongoDB kosong lalu ringkasan PostgreSQL sebelum hitungan tag
+	expectSummary := func(mt *mtest.T, mock sqlmock.Sqlmock) {
+		for i := 0; i < 3; i++ {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.achievements", mtest.FirstBatch))
+		}
+		for i := 0; i < 4; i++ {
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM achievement_references WHERE`).
+				WithArgs("student-123", false, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		}
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(l\.points\), 0\) FROM points_ledger`).
+			WithArgs("student-123", false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(50))
+	}
+
+	mt.Run("Tag totals are rolled up to canonical tags", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		expectSummary(mt, mock)
+		mock.ExpectQuery(tagQuery).
+			WithArgs("student-123", false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"tag", "count"}).AddRow("ai", 2).AddRow("machine-learning", 1))
+
+		// Execute
+		stats, err := achRepo.GetStudentStatistics(ctx, "student-123", repository.StatsFilter{Now: time.Now()})
+
+		// Assertions
+		require.NoError(mt, err)
+		assert.Equal(mt, map[string]int{"ai": 2, "machine-learning": 1}, stats.TotalPerTag)
+		assert.Equal(mt, 50, stats.Summary.TotalPoints)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Pending count failure is returned instead of zero", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		for i := 0; i < 3; i++ {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.achievements", mtest.FirstBatch))
+		}
+		for i := 0; i < 2; i++ {
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM achievement_references WHERE`).
+				WithArgs("student-123", true, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM achievement_references WHERE .+ AND status = 'submitted'`).
+			WithArgs("student-123", true, sqlmock.AnyArg()).
+			WillReturnError(errors.New("invalid input syntax for type timestamp"))
+
+		// Execute
+		stats, err := achRepo.GetStudentStatistics(ctx, "student-123", repository.StatsFilter{ExcludeExpired: true, Now: time.Now()})
+
+		// Assertions
+		assert.Error(mt, err)
+		assert.Nil(mt, stats)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Tag count failure is returned instead of empty totals", func(mt *mtest.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, mt.DB)
+
+		expectSummary(mt, mock)
+		mock.ExpectQuery(tagQuery).
+			WithArgs("student-123", false, sqlmock.AnyArg()).
+			WillReturnError(errors.New("relation \"tag_synonyms\" does not exist"))
+
+		// Execute
+		stats, err := achRepo.GetStudentStatistics(ctx, "student-123", repository.StatsFilter{Now: time.Now()})
+
+		// Assertions
+		assert.Error(mt, err)
+		assert.Nil(mt, stats)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_ContentVersions(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	versionQuery := `SELECT id, achievement_ref_id, version, change, created_at, content\s+FROM achievement_content_versions\s+WHERE achievement_ref_id = \$1 AND version = \$2`
+
+	t.Run("Version snapshot is decoded", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		content := `{"title":"Juara 1 Gemastik","achievementType":"competition","details":{"rank":1},"tags":["it"]}`
+		mock.ExpectQuery(versionQuery).
+			WithArgs("ach-123", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "achievement_ref_id", "version", "change", "created_at", "content"}).
+				AddRow("ver-2", "ach-123", 2, "edited", time.Now(), []byte(content)))
+
+		// Execute
+		v, err := achRepo.FindContentVersion(ctx, "ach-123", 2)
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, model.ContentEdited, v.Change)
+		require.NotNil(t, v.Content)
+		assert.Equal(t, "Juara 1 Gemastik", v.Content.Title)
+		assert.Equal(t, 1, v.Content.Details.Rank)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown version returns ErrNoRows", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(versionQuery).
+			WithArgs("ach-123", 9).
+			WillReturnError(sql.ErrNoRows)
+
+		// Execute
+		v, err := achRepo.FindContentVersion(ctx, "ach-123", 9)
+
+		// Assertions
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Nil(t, v)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Last rejected version comes from status history", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`SELECT content_version FROM achievement_status_history\s+WHERE achievement_ref_id = \$1 AND to_status = 'rejected'`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"content_version"}).AddRow(3))
+
+		// Execute
+		version, err := achRepo.LastRejectedContentVersion(ctx, "ach-123")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, 3, version)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejection before versioning or no rejection yields 0", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		lastRejected := `SELECT content_version FROM achievement_status_history\s+WHERE achievement_ref_id = \$1 AND to_status = 'rejected'`
+		mock.ExpectQuery(lastRejected).
+			WithArgs("ach-legacy").
+			WillReturnRows(sqlmock.NewRows([]string{"content_version"}).AddRow(nil))
+		mock.ExpectQuery(lastRejected).
+			WithArgs("ach-new").
+			WillReturnRows(sqlmock.NewRows([]string{"content_version"}))
+
+		// Execute
+		legacy, legacyErr := achRepo.LastRejectedContentVersion(ctx, "ach-legacy")
+		fresh, freshErr := achRepo.LastRejectedContentVersion(ctx, "ach-new")
+
+		// Assertions
+		require.NoError(t, legacyErr)
+		require.NoError(t, freshErr)
+		assert.Equal(t, 0, legacy)
+		assert.Equal(t, 0, fresh)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_CreateComment(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	field := "details.rank"
+
+	t.Run("Comment and mentions are stored together", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		comment := &model.AchievementComment{
+			AchievementRefID: "ach-123",
+			AuthorID:         "lecturer-user-1",
+			Body:             "@budi peringkat di sertifikat tertulis 2",
+			AnchorField:      &field,
+			CreatedAt:        time.Now(),
+			Mentions:         []model.User{{ID: "student-user-1", Username: "budi"}},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO achievement_comments`).
+			WithArgs("ach-123", nil, "lecturer-user-1", comment.Body, &field, nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("comment-1"))
+		mock.ExpectExec(`INSERT INTO achievement_comment_mentions`).
+			WithArgs("comment-1", "student-user-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Execute
+		err = achRepo.CreateComment(ctx, comment)
+
+		// Assertions
+		assert.NoError(t, err)
+		assert.Equal(t, "comment-1", comment.ID)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Replies cannot be resolved", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectExec(`UPDATE achievement_comments SET is_resolved = \$1`).
+			WithArgs(true, "lecturer-user-1", sqlmock.AnyArg(), "reply-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		err = achRepo.SetCommentResolved(ctx, "reply-1", true, "lecturer-user-1", time.Now())
+
+		// Assertions
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_GetAdviseeRoster(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	columns := []string{"id", "student_id", "full_name", "program_study", "academic_year", "pending", "verified"}
+
+	t.Run("Roster is merged with ledger points per student", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		// Pending dihitung dengan definisi antrian yang sama dengan workload dosen
+		mock.ExpectQuery(`COUNT\(ar.id\) FILTER \(WHERE ar.status = 'submitted' AND ar.current_stage = 'advisor'\s+AND COALESCE\(ar.assigned_verifier_id, s.advisor_id\) = \$1\).+FROM students s\s+JOIN users u ON s.user_id = u.id\s+LEFT JOIN achievement_references ar .+WHERE s.advisor_id = \$1`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("student-1", "2021001", "Andi", "Informatika", "2021", 2, 3).
+				AddRow("student-2", "2021002", "Budi", "Informatika", "2021", 0, 0))
+		mock.ExpectQuery(`SELECT l.student_id, SUM\(l.points\)\s+FROM points_ledger l`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows([]string{"student_id", "sum"}).AddRow("student-1", 150))
+
+		// Execute
+		roster, err := achRepo.GetAdviseeRoster(ctx, "lecturer-1")
+
+		// Assertions
+		require.NoError(t, err)
+		require.Len(t, roster, 2)
+		assert.Equal(t, "2021001", roster[0].NIM)
+		assert.Equal(t, 2, roster[0].PendingSubmissions)
+		assert.Equal(t, 3, roster[0].VerifiedCount)
+		assert.Equal(t, 150, roster[0].TotalPoints)
+		assert.Equal(t, 0, roster[1].TotalPoints)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lecturer without advisees skips the points query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`FROM students s`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		// Execute
+		roster, err := achRepo.GetAdviseeRoster(ctx, "lecturer-1")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.NotNil(t, roster)
+		assert.Empty(t, roster)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_GetLecturerWorkload(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+
+	expectCounts := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM students WHERE advisor_id = \$1`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		mock.ExpectQuery(`WHERE ar.status = 'submitted' AND ar.current_stage = 'advisor'\s+AND COALESCE\(ar.assigned_verifier_id, s.advisor_id\) = \$1`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	}
+
+	t.Run("Median decision time is reported in hours", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		expectCounts(mock)
+		mock.ExpectQuery(`PERCENTILE_CONT\(0.5\).+FROM achievement_verification_stages\s+WHERE decided_by = \$1`).
+			WithArgs("user-lecturer-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "median"}).AddRow(8, 5400.0))
+
+		// Execute
+		workload, err := achRepo.GetLecturerWorkload(ctx, "lecturer-1", "user-lecturer-1")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, 12, workload.TotalAdvisees)
+		assert.Equal(t, 4, workload.OpenQueueSize)
+		assert.Equal(t, 8, workload.TotalDecisions)
+		require.NotNil(t, workload.MedianHoursToDecision)
+		assert.InDelta(t, 1.5, *workload.MedianHoursToDecision, 0.0001)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No decisions leaves the median empty", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		expectCounts(mock)
+		mock.ExpectQuery(`PERCENTILE_CONT`).
+			WithArgs("user-lecturer-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "median"}).AddRow(0, nil))
+
+		// Execute
+		workload, err := achRepo.GetLecturerWorkload(ctx, "lecturer-1", "user-lecturer-1")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, 0, workload.TotalDecisions)
+		assert.Nil(t, workload.MedianHoursToDecision)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_FindAchievementType(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	columns := []string{"key", "name", "description", "schema", "is_system", "is_active", "team_verification", "evidence_requirements", "evidence_required_since", "created_at", "updated_at"}
+
+	t.Run("Schema is decoded from JSONB", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		schema := `{"type":"object","required":["competitionLevel"],"properties":{"competitionLevel":{"type":"string","enum":["local","national"]}}}`
+		evidence := `[{"key":"certificate","label":"Sertifikat","type":"attachment","category":"certificate","fileTypes":["application/pdf"],"minCount":1}]`
+		requiredSince := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT key, name, description, schema, is_system, is_active, team_verification, evidence_requirements, evidence_required_since, created_at, updated_at\s+FROM achievement_types\s+WHERE key = \$1`).
+			WithArgs("competition").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("competition", "Kompetisi", "", []byte(schema), true, true, "leader", []byte(evidence), requiredSince, time.Now(), time.Now()))
+
+		// Execute
+		result, err := achRepo.FindAchievementType(ctx, "competition")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, "object", result.Schema.Type)
+		assert.Equal(t, []string{"competitionLevel"}, result.Schema.Required)
+		assert.Equal(t, []interface{}{"local", "national"}, result.Schema.Properties["competitionLevel"].Enum)
+		require.Len(t, result.EvidenceRequirements, 1)
+		assert.Equal(t, "certificate", result.EvidenceRequirements[0].Category)
+		assert.Equal(t, []string{"application/pdf"}, result.EvidenceRequirements[0].FileTypes)
+		require.NotNil(t, result.EvidenceRequiredSince)
+		assert.Equal(t, requiredSince, *result.EvidenceRequiredSince)
+		assert.Empty(t, result.EvidenceFor(requiredSince.Add(-time.Hour)), "achievements created earlier are exempt")
+		assert.Len(t, result.EvidenceFor(requiredSince), 1)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown type returns ErrNoRows", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`FROM achievement_types\s+WHERE key = \$1`).
+			WithArgs("esports").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		// Execute
+		result, err := achRepo.FindAchievementType(ctx, "esports")
+
+		// Assertions
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_Tags(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+
+	t.Run("Synonyms resolve to their canonical tag", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`SELECT synonym, tag_key FROM tag_synonyms WHERE synonym = ANY\(\$1\)`).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"synonym", "tag_key"}).AddRow("machine-learning", "ai"))
+
+		// Execute
+		resolved, err := achRepo.ResolveTagSynonyms(ctx, []string{"machine-learning", "robotics"})
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"machine-learning": "ai"}, resolved)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tag with synonyms is decoded", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		columns := []string{"key", "name", "parent_key", "description", "is_active", "created_at", "updated_at", "synonyms"}
+		mock.ExpectQuery(`FROM tags t\s+LEFT JOIN tag_synonyms syn ON syn.tag_key = t.key\s+WHERE t.key = \$1`).
+			WithArgs("ai").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("ai", "Kecerdasan Buatan", "technology", "", true, time.Now(), time.Now(), "{machine-learning,ml}"))
+
+		// Execute
+		tag, err := achRepo.FindTag(ctx, "ai")
+
+		// Assertions
+		require.NoError(t, err)
+		require.NotNil(t, tag.ParentKey)
+		assert.Equal(t, "technology", *tag.ParentKey)
+		assert.Equal(t, []string{"machine-learning", "ml"}, tag.Synonyms)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Descendant cannot become the parent", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectQuery(`WITH RECURSIVE subtree AS`).
+			WithArgs("technology", "ai").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		// Execute
+		cycle, err := achRepo.IsTagDescendant(ctx, "technology", "ai")
+
+		// Assertions
+		require.NoError(t, err)
+		assert.True(t, cycle)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_AddLedgerEntry(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	originalQuery := `SELECT achievement_ref_id, student_id, entry_type, points,\s+EXISTS \(SELECT 1 FROM points_ledger WHERE reverses_entry_id = \$1\)\s+FROM points_ledger WHERE id = \$1 FOR UPDATE`
+
+	t.Run("Reversal offsets the original entry", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(originalQuery).
+			WithArgs("entry-1").
+			WillReturnRows(sqlmock.NewRows([]string{"achievement_ref_id", "student_id", "entry_type", "points", "exists"}).AddRow("ach-123", "student-1", "award", 120, false))
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "reversal", -120, "Salah input", "admin-1", "entry-1", nil, sqlmock.AnyArg(), "student-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("entry-2", "student-1"))
+		mock.ExpectQuery(`SELECT ar.mongo_achievement_id, COALESCE\(SUM\(l.points\), 0\)`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "balance"}).AddRow("", 0))
+		mock.ExpectCommit()
+
+		// Execute
+		actor, original := "admin-1", "entry-1"
+		entry := model.PointsLedgerEntry{ReversesEntryID: &original, ActorID: &actor, Reason: "Salah input"}
+		err = achRepo.AddLedgerEntry(ctx, &entry)
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, "entry-2", entry.ID)
+		assert.Equal(t, model.LedgerReversal, entry.EntryType)
+		assert.Equal(t, -120, entry.Points)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Entry cannot be reversed twice", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(originalQuery).
+			WithArgs("entry-1").
+			WillReturnRows(sqlmock.NewRows([]string{"achievement_ref_id", "student_id", "entry_type", "points", "exists"}).AddRow("ach-123", "student-1", "award", 120, true))
+		mock.ExpectRollback()
+
+		// Execute
+		original := "entry-1"
+		err = achRepo.AddLedgerEntry(ctx, &model.PointsLedgerEntry{ReversesEntryID: &original, Reason: "Salah input"})
+
+		// Assertions
+		assert.ErrorIs(t, err, repository.ErrAlreadyReversed)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_AddLedgerEntries(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	actor := "admin-1"
+	memberEntries := func() []model.PointsLedgerEntry {
+		return []model.PointsLedgerEntry{
+			{StudentID: "student-1", EntryType: model.LedgerExpiration, Points: -60, Reason: "Kedaluwarsa", ActorID: &actor},
+			{StudentID: "student-2", EntryType: model.LedgerExpiration, Points: -40, Reason: "Kedaluwarsa", ActorID: &actor},
+		}
+	}
+
+	t.Run("Team expiration is recorded in one transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "expiration", -60, "Kedaluwarsa", "admin-1", nil, nil, sqlmock.AnyArg(), "student-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("entry-1", "student-1"))
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "expiration", -40, "Kedaluwarsa", "admin-1", nil, nil, sqlmock.AnyArg(), "student-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("entry-2", "student-2"))
+		mock.ExpectQuery(`SELECT ar.mongo_achievement_id, COALESCE\(SUM\(l.points\), 0\)`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "balance"}).AddRow("", 0))
+		mock.ExpectCommit()
+
+		// Execute
+		entries := memberEntries()
+		err = achRepo.AddLedgerEntries(ctx, "ach-123", entries)
+
+		// Assertions
+		require.NoError(t, err)
+		assert.Equal(t, "entry-1", entries[0].ID)
+		assert.Equal(t, "entry-2", entries[1].ID)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failed member insert rolls back earlier members", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "expiration", -60, "Kedaluwarsa", "admin-1", nil, nil, sqlmock.AnyArg(), "student-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "student_id"}).AddRow("entry-1", "student-1"))
+		mock.ExpectQuery(`INSERT INTO points_ledger`).
+			WithArgs("ach-123", "expiration", -40, "Kedaluwarsa", "admin-1", nil, nil, sqlmock.AnyArg(), "student-2").
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		// Execute
+		err = achRepo.AddLedgerEntries(ctx, "ach-123", memberEntries())
+
+		// Assertions
+		assert.Error(t, err)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementRepository_GetLeaderboard(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT s.id, s.student_id, u.full_name, s.program_study, SUM\(l.points\) AS total\s+FROM points_ledger l\s+JOIN achievement_references ar`).
+		WithArgs(5, "", true, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_id", "full_name", "program_study", "total"}).
+			AddRow("student-1", "2021001", "Budi", "Informatika", 150).
+			AddRow("student-2", "2021002", "Sari", "Informatika", 90))
+
+	// Execute
+	board, err := achRepo.GetLeaderboard(context.Background(), 5, "", repository.StatsFilter{ExcludeExpired: true, Now: now})
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, board, 2)
+	assert.Equal(t, "Budi", board[0].FullName)
+	assert.Equal(t, 150, board[0].TotalPoints)
+
+	// Verify all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAchievementRepository_EscalateStage(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	escalateQuery := `UPDATE achievement_verification_stages\s+SET escalated_at = \$2, escalated_permission = \$3, escalated_to = \$4`
+	permission := "achievement:approve_affairs"
+	sla := model.VerificationSLA{StageKey: "advisor", DueDays: 7, EscalateAfterDays: 7, EscalationPermission: &permission}
+
+	t.Run("Pending stage is escalated", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectExec(escalateQuery).
+			WithArgs("stage-1", sqlmock.AnyArg(), "achievement:approve_affairs", nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		ok, err := achRepo.EscalateStage(ctx, "stage-1", sla, time.Now())
+
+		// Assertions
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stage escalated by another run is skipped", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		achRepo := repository.NewAchievementRepository(db, client.Database("test"))
+
+		mock.ExpectExec(escalateQuery).
+			WithArgs("stage-1", sqlmock.AnyArg(), "achievement:approve_affairs", nil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		ok, err := achRepo.EscalateStage(ctx, "stage-1", sla, time.Now())
+
+		// Assertions
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -2,14 +2,29 @@ package test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"github.com/WedhaWS/uasgosmt5/app/model"
 	"github.com/WedhaWS/uasgosmt5/app/repository"
+	"github.com/WedhaWS/uasgosmt5/app/service"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Mock Achievement Repository
@@ -32,13 +47,13 @@ func (m *MockAchievementRepository) FindDetail(ctx context.Context, id string) (
 	return args.Get(0).(*model.AchievementReference), args.Get(1).(*model.Achievement), args.Error(2)
 }
 
-func (m *MockAchievementRepository) UpdateStatus(id string, status string, verifiedBy string, note string, points int) error {
-	args := m.Called(id, status, verifiedBy, note, points)
+func (m *MockAchievementRepository) UpdateStatus(ctx context.Context, change model.StatusChange) error {
+	args := m.Called(ctx, change)
 	return args.Error(0)
 }
 
-func (m *MockAchievementRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockAchievementRepository) Delete(ctx context.Context, id string, actorID string) error {
+	args := m.Called(ctx, id, actorID)
 	return args.Error(0)
 }
 
@@ -47,13 +62,13 @@ func (m *MockAchievementRepository) AddAttachment(ctx context.Context, refID str
 	return args.Error(0)
 }
 
-func (m *MockAchievementRepository) GetStatistics(ctx context.Context) (*repository.StatsResult, error) {
-	args := m.Called(ctx)
+func (m *MockAchievementRepository) GetStatistics(ctx context.Context, filter repository.StatsFilter) (*repository.StatsResult, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).(*repository.StatsResult), args.Error(1)
 }
 
-func (m *MockAchievementRepository) GetStudentStatistics(ctx context.Context, studentID string) (*repository.StatsResult, error) {
-	args := m.Called(ctx, studentID)
+func (m *MockAchievementRepository) GetStudentStatistics(ctx context.Context, studentID string, filter repository.StatsFilter) (*repository.StatsResult, error) {
+	args := m.Called(ctx, studentID, filter)
 	return args.Get(0).(*repository.StatsResult), args.Error(1)
 }
 
@@ -102,8 +117,8 @@ func (m *MockUserRepository) Delete(id string) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) UpdateRole(userID, roleID string) error {
-	args := m.Called(userID, roleID)
+func (m *MockUserRepository) UpdateRole(userID, roleID string, version int) error {
+	args := m.Called(userID, roleID, version)
 	return args.Error(0)
 }
 
@@ -117,9 +132,9 @@ func (m *MockUserRepository) SaveLecturer(l *model.Lecturer) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) AssignAdvisor(studentID string, advisorID string) error {
-	args := m.Called(studentID, advisorID)
-	return args.Error(0)
+func (m *MockUserRepository) AssignAdvisor(studentID string, advisorID string, assignedBy string, policy string) (*model.AdvisorReassignment, error) {
+	args := m.Called(studentID, advisorID, assignedBy, policy)
+	return args.Get(0).(*model.AdvisorReassignment), args.Error(1)
 }
 
 func (m *MockUserRepository) FindAllStudents() ([]model.Student, error) {
@@ -147,6 +162,131 @@ func (m *MockRoleRepository) FindByName(name string) (*model.Role, error) {
 	return args.Get(0).(*model.Role), args.Error(1)
 }
 
+// testSession adalah user login yang dipasang di locals seperti AuthRequired
+type testSession struct {
+	UserID      string
+	Role        string
+	Permissions []string
+}
+
+// handlerResponse adalah WebResponse dari handler service yang diuji
+type handlerResponse struct {
+	Code    int                `json:"code"`
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    json.RawMessage    `json:"data"`
+	Errors  []model.FieldError `json:"errors"`
+	Header  http.Header        `json:"-"`
+}
+
+// callHandler menjalankan satu handler service lewat app Fiber. headers berisi
+// pasangan nama dan nilai header.
+func callHandler(t *testing.T, session testSession, method, route string, handler fiber.Handler, path, body string, headers ...string) (int, handlerResponse) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", session.UserID)
+		c.Locals("role", session.Role)
+		c.Locals("permissions", session.Permissions)
+		return c.Next()
+	})
+	app.Add(method, route, handler)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+
+	out := handlerResponse{Header: resp.Header}
+	raw, _ := io.ReadAll(resp.Body)
+	if len(raw) > 0 {
+		require.NoError(t, json.Unmarshal(raw, &out), string(raw))
+	}
+	return resp.StatusCode, out
+}
+
+// achievementFixture adalah prestasi yang dimuat FindDetail pada test handler
+type achievementFixture struct {
+	RefID     string
+	StudentID string
+	Status    string
+	Version   int
+	Content   model.Achievement
+}
+
+func newAchievementFixture(status string, version int) achievementFixture {
+	return achievementFixture{
+		RefID:     "ach-123",
+		StudentID: "student-123",
+		Status:    status,
+		Version:   version,
+		Content: model.Achievement{
+			ID:              primitive.NewObjectID(),
+			StudentID:       "student-123",
+			AchievementType: "competition",
+			Title:           "Juara 1 GEMASTIK",
+			Details:         model.AchievementDetails{CompetitionName: "GEMASTIK", CompetitionLevel: "national"},
+			Attachments:     []model.AchievementAttachment{},
+			Tags:            []string{},
+		},
+	}
+}
+
+// expectStudentProfile: resolveActor memuat profil mahasiswa yang login
+func expectStudentProfile(mock sqlmock.Sqlmock, userID, studentID string) {
+	mock.ExpectQuery(`FROM students s\s+JOIN users u ON s.user_id = u.id\s+LEFT JOIN lecturers l`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "student_id", "program_study", "academic_year", "advisor_id", "created_at",
+			"u_id", "username", "full_name", "email", "l_id", "lecturer_id", "department", "au_id", "au_full_name",
+		}).AddRow(studentID, userID, "2021001", "Informatika", "2021", "lecturer-1", time.Now(),
+			userID, "andi", "Andi", "andi@kampus.ac.id", "lecturer-1", "198501", "Informatika", "user-lecturer-1", "Dr. Sari"))
+}
+
+// expectFindDetail: reference individu tanpa putaran verifikasi dan dokumen MongoDB-nya
+func expectFindDetail(mock sqlmock.Sqlmock, mt *mtest.T, f achievementFixture) {
+	columns := []string{
+		"id", "student_id", "mongo_achievement_id", "title", "status",
+		"submitted_at", "verified_at", "verified_by", "rejection_note", "created_at", "updated_at",
+		"assigned_verifier_id", "version", "verification_round",
+		"nim", "advisor_id", "full_name", "verifier_name",
+		"stage_key", "stage_name", "stage_permission", "stage_order", "stage_started_at",
+		"stage_reminded_at", "stage_escalated_at", "stage_escalated_permission", "stage_escalated_to",
+		"sla_due_days", "sla_escalate_after_days",
+		"suggested_points", "rubric_version", "points_override_reason",
+		"revoked_at", "revoked_by", "revoked_by_name", "revocation_reason",
+		"is_team", "team_verification",
+		"valid_until", "expiry_reminded_at", "content_version",
+	}
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM achievement_references ar\s+JOIN students s ON ar.student_id = s.id.+WHERE ar.id = \$1 AND ar.status != 'deleted'`).
+		WithArgs(f.RefID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			f.RefID, f.StudentID, f.Content.ID.Hex(), f.Content.Title, f.Status,
+			nil, nil, nil, nil, now, now,
+			nil, f.Version, 0,
+			"2021001", "lecturer-1", "Andi", nil,
+			nil, nil, nil, nil, nil,
+			nil, nil, nil, nil,
+			nil, nil,
+			nil, nil, nil,
+			nil, nil, nil, nil,
+			false, nil,
+			nil, nil, 1,
+		))
+	mock.ExpectQuery(`FROM achievement_duplicate_flags f`).
+		WithArgs(f.RefID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	raw, err := bson.Marshal(f.Content)
+	require.NoError(mt, err)
+	var doc bson.D
+	require.NoError(mt, bson.Unmarshal(raw, &doc))
+	mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.achievements", mtest.FirstBatch, doc))
+}
+
 // Test Achievement Service Business Logic
 func TestAchievementService_BusinessLogic(t *testing.T) {
 	t.Run("CreateAchievement_ValidatesStudentExists", func(t *testing.T) {
@@ -369,6 +509,972 @@ func TestAchievementService_DeletionLogic(t *testing.T) {
 	})
 }
 
+func TestAuthService_AdvisorHistory(t *testing.T) {
+	admin := testSession{UserID: "admin-1", Role: "Admin", Permissions: []string{"user:manage"}}
+
+	t.Run("Unknown student returns 404", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		authService := service.NewAuthService(repository.NewUserRepository(db), repository.NewRoleRepository(db))
+
+		mock.ExpectQuery(`FROM advisor_assignments aa`).
+			WithArgs("student-404").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM students WHERE id = \$1\)`).
+			WithArgs("student-404").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		// Execute
+		status, resp := callHandler(t, admin, "GET", "/students/:id/advisor-history", authService.GetAdvisorHistory, "/students/student-404/advisor-history", "")
+
+		// Assertions
+		assert.Equal(t, 404, status)
+		assert.Equal(t, "Student not found", resp.Message)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAuthService_UpdateUserRole(t *testing.T) {
+	admin := testSession{UserID: "admin-1", Role: "Admin", Permissions: []string{"user:manage"}}
+	roleID := "3f2b8c1e-6d4a-4e5b-9c7d-1a2b3c4d5e6f"
+	body := `{"roleId":"` + roleID + `"}`
+
+	setup := func(t *testing.T) (*service.AuthService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return service.NewAuthService(repository.NewUserRepository(db), repository.NewRoleRepository(db)), mock
+	}
+	expectUser := func(mock sqlmock.Sqlmock, version int) {
+		mock.ExpectQuery(`FROM users u\s+JOIN roles r ON u.role_id = r.id\s+WHERE u.id = \$1`).
+			WithArgs("user-123").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "username", "email", "password_hash", "full_name", "role_id", "is_active", "created_at", "updated_at", "version",
+				"id", "name", "description",
+			}).AddRow("user-123", "andi", "andi@example.com", "hash", "Andi", "role-student", true, time.Now(), time.Now(), version,
+				"role-student", "Mahasiswa", ""))
+	}
+
+	t.Run("Missing If-Match returns 428", func(t *testing.T) {
+		authService, mock := setup(t)
+		expectUser(mock, 3)
+
+		// Execute
+		status, _ := callHandler(t, admin, "PUT", "/users/:id/role", authService.UpdateUserRole, "/users/user-123/role", body)
+
+		// Assertions
+		assert.Equal(t, 428, status)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stale If-Match returns 412 without touching the role", func(t *testing.T) {
+		authService, mock := setup(t)
+		expectUser(mock, 4)
+
+		// Execute
+		status, resp := callHandler(t, admin, "PUT", "/users/:id/role", authService.UpdateUserRole, "/users/user-123/role", body, "If-Match", `"v3"`)
+
+		// Assertions
+		assert.Equal(t, 412, status)
+		assert.Equal(t, `"v4"`, resp.Header.Get("ETag"))
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Concurrent change between read and update returns 412", func(t *testing.T) {
+		authService, mock := setup(t)
+		expectUser(mock, 3)
+		mock.ExpectExec(`UPDATE users SET role_id = \$1`).
+			WithArgs(roleID, sqlmock.AnyArg(), "user-123", 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Execute
+		status, _ := callHandler(t, admin, "PUT", "/users/:id/role", authService.UpdateUserRole, "/users/user-123/role", body, "If-Match", `"v3"`)
+
+		// Assertions
+		assert.Equal(t, 412, status)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Matching If-Match changes the role and returns the next ETag", func(t *testing.T) {
+		authService, mock := setup(t)
+		expectUser(mock, 3)
+		mock.ExpectExec(`UPDATE users SET role_id = \$1`).
+			WithArgs(roleID, sqlmock.AnyArg(), "user-123", 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Execute
+		status, resp := callHandler(t, admin, "PUT", "/users/:id/role", authService.UpdateUserRole, "/users/user-123/role", body, "If-Match", `"v3"`)
+
+		// Assertions
+		assert.Equal(t, 200, status)
+		assert.Equal(t, `"v4"`, resp.Header.Get("ETag"))
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementService_GetLecturerDetail(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	lecturerColumns := []string{"id", "user_id", "lecturer_id", "department", "created_at", "id", "username", "full_name", "email"}
+
+	setup := func(t *testing.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, client.Database("test")), repository.NewUserRepository(db)), mock
+	}
+
+	t.Run("Unknown lecturer returns 404", func(t *testing.T) {
+		achService, mock := setup(t)
+		admin := testSession{UserID: "admin-1", Role: "Admin"}
+
+		mock.ExpectQuery(`FROM lecturers l\s+JOIN users u ON l.user_id = u.id\s+WHERE l.id = \$1`).
+			WithArgs("lecturer-404").
+			WillReturnRows(sqlmock.NewRows(lecturerColumns))
+
+		// Execute
+		status, resp := callHandler(t, admin, "GET", "/lecturers/:id", achService.GetLecturerDetail, "/lecturers/lecturer-404", "")
+
+		// Assertions
+		assert.Equal(t, 404, status)
+		assert.Equal(t, "Lecturer not found", resp.Message)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Another lecturer cannot see the roster", func(t *testing.T) {
+		achService, mock := setup(t)
+		other := testSession{UserID: "user-lecturer-2", Role: "Dosen Wali"}
+
+		mock.ExpectQuery(`FROM lecturers l\s+JOIN users u ON l.user_id = u.id\s+WHERE l.id = \$1`).
+			WithArgs("lecturer-1").
+			WillReturnRows(sqlmock.NewRows(lecturerColumns).
+				AddRow("lecturer-1", "user-lecturer-1", "198501", "Informatika", time.Now(), "user-lecturer-1", "dosen1", "Dr. Sari", "sari@kampus.ac.id"))
+
+		// Execute
+		status, _ := callHandler(t, other, "GET", "/lecturers/:id", achService.GetLecturerDetail, "/lecturers/lecturer-1", "")
+
+		// Assertions
+		assert.Equal(t, 403, status)
+
+		// Verify all expectations were met
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// expectDetailView menyiapkan query GET detail prestasi f milik user-123 dengan
+// syarat bukti tipe competition sesuai requirements (JSON)
+func expectDetailView(mock sqlmock.Sqlmock, mt *mtest.T, f achievementFixture, requirements string) {
+	expectFindDetail(mock, mt, f)
+	expectStudentProfile(mock, "user-123", "student-123")
+	expectAchievementType(mock, requirements, nil)
+}
+
+// expectAchievementType menyiapkan tipe competition dengan bukti wajib
+// requirements (JSON) yang berlaku sejak requiredSince (nil = selalu)
+func expectAchievementType(mock sqlmock.Sqlmock, requirements string, requiredSince interface{}) {
+	mock.ExpectQuery(`FROM achievement_types\s+WHERE key = \$1`).
+		WithArgs("competition").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "description", "schema", "is_system", "is_active", "team_verification", "evidence_requirements", "evidence_required_since", "created_at", "updated_at"}).
+			AddRow("competition", "Kompetisi", "", []byte(`{"type":"object"}`), true, true, "leader", []byte(requirements), requiredSince, time.Time{}, time.Time{}))
+}
+
+func TestAchievementService_GetDetail(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	student := testSession{UserID: "user-123", Role: "Mahasiswa"}
+	certificate := `[{"key":"certificate","label":"Sertifikat","type":"attachment","category":"certificate"}]`
+
+	setup := func(mt *mtest.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		mt.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, mt.DB), repository.NewUserRepository(db)), mock
+	}
+
+	mt.Run("Matching If-None-Match returns 304", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		draft := newAchievementFixture(model.StatusDraft, 4)
+		expectDetailView(mock, mt, draft, `[]`)
+		status, first := callHandler(mt.T, student, "GET", "/achievements/:id", achService.GetDetail, "/achievements/ach-123", "")
+		require.Equal(mt, 200, status)
+		tag := first.Header.Get("ETag")
+		assert.Regexp(mt, `^"v4-[0-9a-f]{16}"$`, tag)
+		expectDetailView(mock, mt, draft, `[]`)
+
+		// Execute
+		status, second := callHandler(mt.T, student, "GET", "/achievements/:id", achService.GetDetail, "/achievements/ach-123", "", "If-None-Match", tag)
+
+		// Assertions
+		assert.Equal(mt, 304, status)
+		assert.Equal(mt, tag, second.Header.Get("ETag"))
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Change that does not bump the version still invalidates the ETag", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		draft := newAchievementFixture(model.StatusDraft, 4)
+		expectDetailView(mock, mt, draft, `[]`)
+		_, first := callHandler(mt.T, student, "GET", "/achievements/:id", achService.GetDetail, "/achievements/ach-123", "")
+		tag := first.Header.Get("ETag")
+
+		// Admin menambah syarat bukti pada tipe: version prestasi tetap 4
+		expectDetailView(mock, mt, draft, certificate)
+
+		// Execute
+		status, second := callHandler(mt.T, student, "GET", "/achievements/:id", achService.GetDetail, "/achievements/ach-123", "", "If-None-Match", tag)
+
+		// Assertions
+		assert.Equal(mt, 200, status)
+		assert.NotEqual(mt, tag, second.Header.Get("ETag"))
+		assert.Contains(mt, string(second.Data), `"certificate"`)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementService_RequestVerification(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	student := testSession{UserID: "user-123", Role: "Mahasiswa"}
+	requirements := `[
+		{"key":"certificate","label":"Sertifikat","type":"attachment","category":"certificate","fileTypes":["application/pdf"]},
+		{"key":"photo","label":"Foto","type":"attachment","category":"photo","fileTypes":["image/*"]}
+	]`
+
+	setup := func(mt *mtest.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		mt.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, mt.DB), repository.NewUserRepository(db)), mock
+	}
+
+	mt.Run("Missing evidence returns 422 with the checklist", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		draft := newAchievementFixture(model.StatusDraft, 2)
+		draft.Content.Attachments = []model.AchievementAttachment{
+			{FileName: "sertifikat.pdf", FileURL: "/uploads/sertifikat.pdf", FileType: "application/pdf", Category: "certificate"},
+		}
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, draft)
+		expectAchievementType(mock, requirements, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "POST", "/achievements/:id/submit", achService.RequestVerification, "/achievements/ach-123/submit", "")
+
+		// Assertions
+		assert.Equal(mt, 422, status)
+		assert.Equal(mt, "Required evidence is missing (1 of 2 complete)", resp.Message)
+		require.Len(mt, resp.Errors, 1)
+		assert.Equal(mt, "evidence.photo", resp.Errors[0].Field)
+		var checklist model.EvidenceCompleteness
+		require.NoError(mt, json.Unmarshal(resp.Data, &checklist))
+		assert.False(mt, checklist.Complete)
+		require.Len(mt, checklist.Items, 2)
+		assert.True(mt, checklist.Items[0].Satisfied)
+		assert.False(mt, checklist.Items[1].Satisfied)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Draft created before the requirements shows complete evidence", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		// Lampiran lama tanpa kategori; fixture dibuat 2 Maret 2026
+		draft := newAchievementFixture(model.StatusDraft, 2)
+		draft.Content.Attachments = []model.AchievementAttachment{
+			{FileName: "sertifikat.pdf", FileURL: "/uploads/sertifikat.pdf", FileType: "application/pdf"},
+		}
+		expectFindDetail(mock, mt, draft)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectAchievementType(mock, requirements, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+
+		// Execute: indikator kelengkapan di detail memakai aturan yang sama dengan submit
+		status, resp := callHandler(mt.T, student, "GET", "/achievements/:id", achService.GetDetail, "/achievements/ach-123", "")
+
+		// Assertions
+		require.Equal(mt, 200, status)
+		var data struct {
+			Meta model.AchievementReference `json:"meta"`
+		}
+		require.NoError(mt, json.Unmarshal(resp.Data, &data))
+		require.NotNil(mt, data.Meta.Evidence)
+		assert.True(mt, data.Meta.Evidence.Complete)
+		assert.Equal(mt, 0, data.Meta.Evidence.Required)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementService_EditDraft(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	student := testSession{UserID: "user-123", Role: "Mahasiswa"}
+	body := `{"achievementType":"competition","title":"Juara 2 GEMASTIK","details":{"competitionName":"GEMASTIK","competitionLevel":"national"}}`
+
+	setup := func(mt *mtest.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		mt.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, mt.DB), repository.NewUserRepository(db)), mock
+	}
+
+	mt.Run("PUT with a stale If-Match returns 412", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 4))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "PUT", "/achievements/:id", achService.Update, "/achievements/ach-123", body, "If-Match", `"v3"`)
+
+		// Assertions
+		assert.Equal(mt, 412, status)
+		assert.Equal(mt, "error", resp.Status)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("PATCH on a submitted achievement is rejected before any write", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusSubmitted, 4))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "PATCH", "/achievements/:id", achService.Patch, "/achievements/ach-123", `{"title":"Juara 2"}`, "If-Match", `"v4"`)
+
+		// Assertions
+		assert.Equal(mt, 400, status)
+		assert.Contains(mt, resp.Message, "'edit' is not allowed")
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	// expectSave menyiapkan validasi details & rubrik lalu update MongoDB;
+	// sisi PostgreSQL UpdateContent diatur per kasus
+	expectSave := func(mock sqlmock.Sqlmock, mt *mtest.T) {
+		expectAchievementType(mock, `[]`, nil)
+		mock.ExpectQuery(`FROM point_rubrics WHERE is_active`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+	}
+
+	mt.Run("PATCH that loses the race to a submit returns 409 and restores MongoDB", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 4))
+		expectSave(mock, mt)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		mock.ExpectRollback()
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "PATCH", "/achievements/:id", achService.Patch, "/achievements/ach-123", `{"title":"Juara 2 GEMASTIK"}`, "If-Match", `"v4"`)
+
+		// Assertions
+		assert.Equal(mt, 409, status)
+		assert.Equal(mt, "Achievement status changed and can no longer be edited", resp.Message)
+		assert.Equal(mt, "Juara 1 GEMASTIK", restoredTitle(mt))
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Detail ETag with a body hash is accepted as If-Match", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 4))
+		expectSave(mock, mt)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		mock.ExpectRollback()
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		// Execute: hanya version yang dijaga, hash body tidak ikut dibandingkan
+		status, _ := callHandler(mt.T, student, "PATCH", "/achievements/:id", achService.Patch, "/achievements/ach-123", `{"title":"Juara 2 GEMASTIK"}`, "If-Match", `"v4-0123456789abcdef"`)
+
+		// Assertions: lolos If-Match dan sampai ke UPDATE bersyarat
+		assert.Equal(mt, 409, status)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Synonym tags reach the database under their canonical key", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 4))
+		expectAchievementType(mock, `[]`, nil)
+		mock.ExpectQuery(`SELECT synonym, tag_key FROM tag_synonyms WHERE synonym = ANY\(\$1\)`).
+			WithArgs(`{"ml","machine-learning"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"synonym", "tag_key"}).AddRow("ml", "machine-learning"))
+		mock.ExpectQuery(`FROM point_rubrics WHERE is_active`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "ach-123", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 4, `{"machine-learning"}`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		mock.ExpectRollback()
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		// Execute
+		status, _ := callHandler(mt.T, student, "PATCH", "/achievements/:id", achService.Patch, "/achievements/ach-123", `{"tags":["ML","Machine Learning"]}`, "If-Match", `"v4"`)
+
+		// Assertions: dua sinonim dilebur menjadi satu tag kanonik
+		assert.Equal(mt, 409, status)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("PUT that loses the race to another edit returns 412", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 4))
+		expectSave(mock, mt)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE achievement_references SET title = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT version FROM achievement_references WHERE id = \$1`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+		mock.ExpectRollback()
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		// Execute
+		status, _ := callHandler(mt.T, student, "PUT", "/achievements/:id", achService.Update, "/achievements/ach-123", body, "If-Match", `"v4"`)
+
+		// Assertions
+		assert.Equal(mt, 412, status)
+		assert.Equal(mt, "Juara 1 GEMASTIK", restoredTitle(mt))
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+// restoredTitle mengembalikan title pada update MongoDB terakhir (kompensasi)
+func restoredTitle(mt *mtest.T) string {
+	var title string
+	for _, evt := range mt.GetAllStartedEvents() {
+		if evt.CommandName != "update" {
+			continue
+		}
+		updates, _ := evt.Command.Lookup("updates").Array().Values()
+		set := updates[0].Document().Lookup("u", "$set")
+		title = set.Document().Lookup("title").StringValue()
+	}
+	return title
+}
+
+func TestAchievementService_BulkReject(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	lecturer := testSession{UserID: "lecturer-user-1", Role: "Dosen Wali", Permissions: []string{"achievement:verify"}}
+	draftID := "3f1b2c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	unknownID := "a0b1c2d3-e4f5-4a6b-9c8d-7e6f5a4b3c2d"
+
+	setup := func(mt *mtest.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		mt.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, mt.DB), repository.NewUserRepository(db)), mock
+	}
+
+	mt.Run("Valid UUIDs pass validation and are decided per item", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		now := time.Now()
+		mock.ExpectQuery(`FROM users u\s+JOIN roles r ON u.role_id = r.id\s+WHERE u.id = \$1`).
+			WithArgs("lecturer-user-1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "username", "email", "password_hash", "full_name", "role_id", "is_active", "created_at", "updated_at", "version",
+				"role_id", "role_name", "role_description",
+			}).AddRow("lecturer-user-1", "budi", "budi@example.com", "hash", "Budi", "role-2", true, now, now, 1, "role-2", "Dosen Wali", "Dosen wali"))
+		mock.ExpectQuery(`FROM lecturers l`).
+			WithArgs("lecturer-user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "lecturer_id", "department", "created_at", "user_id", "username", "full_name", "email"}).
+				AddRow("lecturer-1", "lecturer-user-1", "198001", "Informatika", now, "lecturer-user-1", "budi", "Budi", "budi@example.com"))
+
+		draft := newAchievementFixture(model.StatusDraft, 2)
+		draft.RefID = draftID
+		expectFindDetail(mock, mt, draft)
+		mock.ExpectQuery(`FROM achievement_references ar\s+JOIN students s ON ar.student_id = s.id.+WHERE ar.id = \$1`).
+			WithArgs(unknownID).
+			WillReturnError(sql.ErrNoRows)
+
+		// Execute
+		status, resp := callHandler(mt.T, lecturer, "POST", "/achievements/bulk/reject", achService.BulkReject, "/achievements/bulk/reject",
+			`{"ids":["`+draftID+`","`+unknownID+`"],"note":"Bukti kurang"}`)
+
+		// Assertions
+		assert.Equal(mt, 200, status)
+		var data struct {
+			Failed  int                    `json:"failed"`
+			Results []model.BulkItemResult `json:"results"`
+		}
+		require.NoError(mt, json.Unmarshal(resp.Data, &data))
+		assert.Equal(mt, 2, data.Failed)
+		require.Len(mt, data.Results, 2)
+		assert.Equal(mt, draftID, data.Results[0].ID)
+		assert.Equal(mt, 400, data.Results[0].Code)
+		assert.Equal(mt, unknownID, data.Results[1].ID)
+		assert.Equal(mt, 404, data.Results[1].Code)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Malformed id is reported with its index", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+
+		// Execute
+		status, resp := callHandler(mt.T, lecturer, "POST", "/achievements/bulk/reject", achService.BulkReject, "/achievements/bulk/reject",
+			`{"ids":["`+draftID+`","ach-123"],"note":"Bukti kurang"}`)
+
+		// Assertions
+		assert.Equal(mt, 422, status)
+		require.Len(mt, resp.Errors, 1)
+		assert.Equal(mt, "ids[1]", resp.Errors[0].Field)
+		assert.Equal(mt, "uuid", resp.Errors[0].Rule)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementService_UpdateTeam(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	student := testSession{UserID: "user-123", Role: "Mahasiswa"}
+
+	setup := func(mt *mtest.T) (*service.AchievementService, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(mt, err)
+		mt.Cleanup(func() { db.Close() })
+		return service.NewAchievementService(repository.NewAchievementRepository(db, mt.DB), repository.NewUserRepository(db)), mock
+	}
+
+	mt.Run("Empty member list reverts to an individual achievement", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		f := newAchievementFixture(model.StatusDraft, 3)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, f)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM achievement_team_members\s+WHERE achievement_ref_id = \$1 AND role = 'member'`).
+			WithArgs("ach-123", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM achievement_team_members WHERE achievement_ref_id = \$1`).
+			WithArgs("ach-123").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE achievement_references SET is_team = \$2`).
+			WithArgs("ach-123", false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT ar.mongo_achievement_id`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"mongo_achievement_id", "members"}).AddRow(f.Content.ID.Hex(), "{}"))
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		mock.ExpectQuery(`FROM achievement_team_members tm`).
+			WithArgs("ach-123").
+			WillReturnRows(sqlmock.NewRows([]string{"student_id", "role", "status", "responded_at", "advisor_approved_by", "advisor_approved_at", "nim", "user_id", "advisor_id", "full_name"}))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "PUT", "/achievements/:id/team", achService.UpdateTeam, "/achievements/ach-123/team", `{"members":[]}`)
+
+		// Assertions
+		assert.Equal(mt, 200, status)
+		var data struct {
+			IsTeam  bool              `json:"isTeam"`
+			Members []json.RawMessage `json:"members"`
+		}
+		require.NoError(mt, json.Unmarshal(resp.Data, &data))
+		assert.False(mt, data.IsTeam)
+		assert.Empty(mt, data.Members)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+
+	mt.Run("Blank member NIM is rejected before any write", func(mt *mtest.T) {
+		achService, mock := setup(mt)
+		expectStudentProfile(mock, "user-123", "student-123")
+		expectFindDetail(mock, mt, newAchievementFixture(model.StatusDraft, 3))
+
+		// Execute
+		status, resp := callHandler(mt.T, student, "PUT", "/achievements/:id/team", achService.UpdateTeam, "/achievements/ach-123/team", `{"members":["2021002","  "]}`)
+
+		// Assertions
+		assert.Equal(mt, 422, status)
+		require.Len(mt, resp.Errors, 1)
+		assert.Equal(mt, "members[1]", resp.Errors[0].Field)
+		assert.Equal(mt, "required", resp.Errors[0].Rule)
+
+		// Verify all expectations were met
+		assert.NoError(mt, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementStatus_Transitions(t *testing.T) {
+	t.Run("Rejected can be revised back to draft and resubmitted", func(t *testing.T) {
+		assert.True(t, model.CanTransition(model.StatusRejected, model.StatusDraft))
+		assert.True(t, model.CanTransition(model.StatusDraft, model.StatusSubmitted))
+		assert.False(t, model.CanTransition(model.StatusRejected, model.StatusSubmitted))
+	})
+
+	t.Run("Submission can be withdrawn", func(t *testing.T) {
+		assert.True(t, model.CanTransition(model.StatusSubmitted, model.StatusDraft))
+	})
+
+	t.Run("Verified can only be revoked", func(t *testing.T) {
+		assert.True(t, model.CanTransition(model.StatusVerified, model.StatusRevoked))
+		for _, to := range []string{model.StatusDraft, model.StatusSubmitted, model.StatusRejected, model.StatusDeleted} {
+			assert.False(t, model.CanTransition(model.StatusVerified, to))
+		}
+	})
+
+	t.Run("Revoked is terminal", func(t *testing.T) {
+		for _, to := range []string{model.StatusDraft, model.StatusSubmitted, model.StatusVerified, model.StatusRejected, model.StatusDeleted, model.StatusRevoked} {
+			assert.False(t, model.CanTransition(model.StatusRevoked, to))
+		}
+	})
+
+	t.Run("Deleted can only be restored to draft", func(t *testing.T) {
+		assert.True(t, model.CanTransition(model.StatusDeleted, model.StatusDraft))
+		for _, to := range []string{model.StatusSubmitted, model.StatusVerified, model.StatusRejected, model.StatusRevoked} {
+			assert.False(t, model.CanTransition(model.StatusDeleted, to))
+		}
+	})
+
+	t.Run("Only drafts can be deleted", func(t *testing.T) {
+		assert.True(t, model.CanTransition(model.StatusDraft, model.StatusDeleted))
+		assert.False(t, model.CanTransition(model.StatusSubmitted, model.StatusDeleted))
+		assert.False(t, model.CanTransition(model.StatusRejected, model.StatusDeleted))
+	})
+}
+
+func TestVerificationSLA_Evaluate(t *testing.T) {
+	sla := model.VerificationSLA{StageKey: model.StageAdvisor, DueDays: 7, EscalateAfterDays: 3}
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	stage := &model.VerificationStage{Key: model.StageAdvisor, StartedAt: &started}
+
+	t.Run("Fresh submission is on time", func(t *testing.T) {
+		status := sla.Evaluate(stage, started.Add(48*time.Hour))
+		require.NotNil(t, status)
+		assert.Equal(t, model.SLAOnTime, status.State)
+		assert.Equal(t, started.AddDate(0, 0, 7), status.DueAt)
+		assert.Equal(t, started.AddDate(0, 0, 10), status.EscalateAt)
+	})
+
+	t.Run("Last day before due is due soon", func(t *testing.T) {
+		status := sla.Evaluate(stage, started.AddDate(0, 0, 7).Add(-2*time.Hour))
+		assert.Equal(t, model.SLADueSoon, status.State)
+	})
+
+	t.Run("Past due is overdue", func(t *testing.T) {
+		status := sla.Evaluate(stage, started.AddDate(0, 0, 7))
+		assert.Equal(t, model.SLAOverdue, status.State)
+	})
+
+	t.Run("Stage that has not started has no SLA", func(t *testing.T) {
+		assert.Nil(t, sla.Evaluate(&model.VerificationStage{Key: model.StageAdvisor}, started))
+	})
+}
+
+func TestEvaluateCertification(t *testing.T) {
+	validUntil := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Far from expiry is valid", func(t *testing.T) {
+		status := model.EvaluateCertification(&validUntil, nil, validUntil.AddDate(0, -3, 0))
+		require.NotNil(t, status)
+		assert.Equal(t, model.CertificationValid, status.State)
+		assert.False(t, status.Expired)
+	})
+
+	t.Run("Within the reminder window is expiring soon", func(t *testing.T) {
+		status := model.EvaluateCertification(&validUntil, nil, validUntil.AddDate(0, 0, -10))
+		assert.Equal(t, model.CertificationExpiringSoon, status.State)
+		assert.False(t, status.Expired)
+	})
+
+	t.Run("From the last valid moment on it is expired", func(t *testing.T) {
+		status := model.EvaluateCertification(&validUntil, nil, validUntil)
+		assert.Equal(t, model.CertificationExpired, status.State)
+		assert.True(t, status.Expired)
+	})
+
+	t.Run("Achievement without validUntil has no certification status", func(t *testing.T) {
+		assert.Nil(t, model.EvaluateCertification(nil, nil, validUntil))
+	})
+}
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("Free-form tags become lowercase keys", func(t *testing.T) {
+		assert.Equal(t, "machine-learning", model.NormalizeTag("  Machine   Learning "))
+		assert.Equal(t, "web-development", model.NormalizeTag("Web_Development"))
+		assert.Equal(t, "c#", model.NormalizeTag("C#"))
+		assert.Equal(t, "node.js", model.NormalizeTag("Node.js!"))
+		assert.Equal(t, "", model.NormalizeTag(" -- "))
+	})
+
+	t.Run("Empty and duplicate tags are dropped in order", func(t *testing.T) {
+		tags := model.NormalizeTags([]string{"AI", "Machine Learning", "", "ai", "machine-learning"})
+		assert.Equal(t, []string{"ai", "machine-learning"}, tags)
+	})
+}
+
+func TestEvaluateEvidence(t *testing.T) {
+	competition := []model.EvidenceRequirement{
+		{Key: "certificate", Label: "Sertifikat", Type: model.EvidenceAttachment, Category: "certificate", FileTypes: []string{"application/pdf"}},
+		{Key: "photo", Label: "Foto", Type: model.EvidenceAttachment, Category: "photo", FileTypes: []string{"image/*"}},
+	}
+
+	t.Run("Missing photo is listed in the checklist", func(t *testing.T) {
+		content := &model.Achievement{Attachments: []model.AchievementAttachment{
+			{FileName: "sertifikat.pdf", FileType: "application/pdf", Category: "certificate"},
+		}}
+
+		result := model.EvaluateEvidence(competition, content)
+
+		assert.False(t, result.Complete)
+		assert.Equal(t, 1, result.Satisfied)
+		assert.Equal(t, 50, result.Percent)
+		assert.True(t, result.Items[0].Satisfied)
+		assert.False(t, result.Items[1].Satisfied)
+		assert.Contains(t, result.Items[1].Missing, "category 'photo'")
+	})
+
+	t.Run("Attachments must match both category and file type", func(t *testing.T) {
+		content := &model.Achievement{Attachments: []model.AchievementAttachment{
+			{FileName: "sertifikat.jpg", FileType: "image/jpeg", Category: "certificate"},
+			{FileName: "foto.png", FileType: "image/png", Category: "photo"},
+		}}
+
+		result := model.EvaluateEvidence(competition, content)
+
+		assert.False(t, result.Items[0].Satisfied)
+		assert.True(t, result.Items[1].Satisfied)
+	})
+
+	t.Run("DOI must be filled in with a valid format", func(t *testing.T) {
+		publication := []model.EvidenceRequirement{
+			{Key: "doi", Label: "DOI", Type: model.EvidenceField, Field: "doi", Pattern: `^10\.\d{4,9}/\S+$`},
+		}
+
+		assert.False(t, model.EvaluateEvidence(publication, &model.Achievement{}).Complete)
+		assert.False(t, model.EvaluateEvidence(publication, &model.Achievement{Details: model.AchievementDetails{DOI: "doi-belum-ada"}}).Complete)
+		assert.True(t, model.EvaluateEvidence(publication, &model.Achievement{Details: model.AchievementDetails{DOI: "10.1109/ICOIACT.2024.123"}}).Complete)
+	})
+
+	t.Run("Type without requirements is always complete", func(t *testing.T) {
+		result := model.EvaluateEvidence(nil, &model.Achievement{})
+
+		assert.True(t, result.Complete)
+		assert.Equal(t, 100, result.Percent)
+		assert.Empty(t, result.Items)
+	})
+}
+
+func TestAchievementComment_Threads(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	root1, root2 := "c-1", "c-2"
+	comments := []model.AchievementComment{
+		{ID: root1, AuthorID: "student-user-1", Body: "Apakah sertifikat perlu dilegalisir?", CreatedAt: created},
+		{ID: root2, AuthorID: "lecturer-user-1", Body: "Peringkat belum sesuai", CreatedAt: created.Add(time.Minute)},
+		{ID: "c-3", ParentID: &root1, AuthorID: "lecturer-user-1", Body: "Tidak perlu", CreatedAt: created.Add(2 * time.Minute)},
+		{ID: "c-4", ParentID: &root1, AuthorID: "student-user-1", Body: "Baik, terima kasih", CreatedAt: created.Add(3 * time.Minute)},
+	}
+
+	t.Run("Replies are grouped under their root in order", func(t *testing.T) {
+		threads := model.CommentThreads(comments)
+		require.Len(t, threads, 2)
+		assert.Equal(t, root1, threads[0].ID)
+		require.Len(t, threads[0].Replies, 2)
+		assert.Equal(t, "c-3", threads[0].Replies[0].ID)
+		assert.Equal(t, "c-4", threads[0].Replies[1].ID)
+		assert.Empty(t, threads[1].Replies)
+	})
+
+	t.Run("Only the author can edit within the edit window", func(t *testing.T) {
+		comment := comments[0]
+		assert.True(t, comment.Editable("student-user-1", created.Add(model.CommentEditWindow)))
+		assert.False(t, comment.Editable("student-user-1", created.Add(model.CommentEditWindow+time.Second)))
+		assert.False(t, comment.Editable("lecturer-user-1", created.Add(time.Minute)))
+	})
+}
+
+func TestTeamAwards(t *testing.T) {
+	team := []model.TeamMember{
+		{StudentID: "student-2", Role: model.TeamRoleMember, Status: model.MemberConfirmed},
+		{StudentID: "student-1", Role: model.TeamRoleLeader, Status: model.MemberConfirmed},
+		{StudentID: "student-3", Role: model.TeamRoleMember, Status: model.MemberDeclined},
+		{StudentID: "student-4", Role: model.TeamRoleMember, Status: model.MemberConfirmed},
+	}
+
+	t.Run("Duplicate gives every confirmed member the full points", func(t *testing.T) {
+		awards := model.TeamAwards(team, 100, model.TeamPointsDuplicate)
+		assert.Equal(t, []model.MemberPoints{
+			{StudentID: "student-1", Points: 100},
+			{StudentID: "student-2", Points: 100},
+			{StudentID: "student-4", Points: 100},
+		}, awards)
+	})
+
+	t.Run("Split divides points and gives the remainder to the leader first", func(t *testing.T) {
+		awards := model.TeamAwards(team, 100, model.TeamPointsSplit)
+		assert.Equal(t, []model.MemberPoints{
+			{StudentID: "student-1", Points: 34},
+			{StudentID: "student-2", Points: 33},
+			{StudentID: "student-4", Points: 33},
+		}, awards)
+	})
+}
+
+func TestFingerprint_Compare(t *testing.T) {
+	eventDate := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	competition := func(name, organizer, certNumber string, date *time.Time) model.Fingerprint {
+		return model.NewFingerprint("ach-1", &model.Achievement{
+			AchievementType: "competition",
+			Details: model.AchievementDetails{
+				CompetitionName: name, Organizer: organizer, CertificationNumber: certNumber, EventDate: date,
+			},
+		})
+	}
+	base := competition("GEMASTIK XVI - Divisi Keamanan Siber", "Puspresnas", "SRT-001/2024", &eventDate)
+
+	tests := []struct {
+		name       string
+		other      model.Fingerprint
+		wantReason string
+		flagged    bool
+	}{
+		{
+			name:       "Same certification number regardless of formatting",
+			other:      competition("Lomba lain", "", "SRT/001-2024", nil),
+			wantReason: model.DuplicateCertificationNumber,
+			flagged:    true,
+		},
+		{
+			name:       "Same competition and date with different punctuation",
+			other:      competition("Gemastik XVI: divisi keamanan siber", "PUSPRESNAS", "", &eventDate),
+			wantReason: model.DuplicateSimilarDetails,
+			flagged:    true,
+		},
+		{
+			name:       "Same competition on a different date",
+			other:      competition("GEMASTIK XVI - Divisi Keamanan Siber", "Puspresnas", "", func() *time.Time { d := eventDate.AddDate(1, 0, 0); return &d }()),
+			wantReason: "",
+		},
+		{
+			name:       "Different competition on the same date",
+			other:      competition("Kontes Robot Indonesia", "Puspresnas", "", &eventDate),
+			wantReason: model.DuplicateSimilarDetails,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason := base.Compare(tt.other)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.flagged, score >= model.DuplicateThreshold)
+		})
+	}
+}
+
+func TestPointRubric_Calculate(t *testing.T) {
+	str := func(v string) *string { return &v }
+	num := func(v int) *int { return &v }
+
+	rubric := model.PointRubric{Version: 3, Rules: []model.PointRule{
+		{ID: "comp-any", AchievementType: "competition", Points: 10},
+		{ID: "comp-national", AchievementType: "competition", CompetitionLevel: str("national"), Points: 75},
+		{ID: "comp-national-gold", AchievementType: "competition", CompetitionLevel: str("national"), MedalType: str("gold"), Points: 100},
+		{ID: "comp-national-rank1", AchievementType: "competition", CompetitionLevel: str("national"), Rank: num(1), Points: 95},
+		{ID: "journal-scopus", AchievementType: "publication", PublicationType: str("journal"), Indexing: str("scopus"), Points: 100},
+		{ID: "org-ketua", AchievementType: "organization", Position: str("ketua"), Points: 40},
+	}}
+
+	tests := []struct {
+		name       string
+		content    model.Achievement
+		wantRule   string
+		wantPoints int
+	}{
+		{
+			name:       "Most specific matching rule wins",
+			content:    model.Achievement{AchievementType: "competition", Details: model.AchievementDetails{CompetitionLevel: "national", MedalType: "gold", Rank: 2}},
+			wantRule:   "comp-national-gold",
+			wantPoints: 100,
+		},
+		{
+			name:       "Equal specificity picks higher points",
+			content:    model.Achievement{AchievementType: "competition", Details: model.AchievementDetails{CompetitionLevel: "national", MedalType: "gold", Rank: 1}},
+			wantRule:   "comp-national-gold",
+			wantPoints: 100,
+		},
+		{
+			name:       "Falls back to general type rule",
+			content:    model.Achievement{AchievementType: "competition", Details: model.AchievementDetails{CompetitionLevel: "local"}},
+			wantRule:   "comp-any",
+			wantPoints: 10,
+		},
+		{
+			name:       "Text criteria ignore case",
+			content:    model.Achievement{AchievementType: "organization", Details: model.AchievementDetails{Position: "Ketua"}},
+			wantRule:   "org-ketua",
+			wantPoints: 40,
+		},
+		{
+			name:       "No matching rule yields zero",
+			content:    model.Achievement{AchievementType: "publication", Details: model.AchievementDetails{PublicationType: "journal", Indexing: "sinta3"}},
+			wantPoints: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := rubric.Calculate(&tt.content)
+
+			assert.Equal(t, 3, calc.RubricVersion)
+			assert.Equal(t, tt.wantPoints, calc.Points)
+			if tt.wantRule == "" {
+				assert.Nil(t, calc.Rule)
+			} else {
+				require.NotNil(t, calc.Rule)
+				assert.Equal(t, tt.wantRule, calc.Rule.ID)
+			}
+		})
+	}
+}
+
 func TestAuthService_BusinessLogic(t *testing.T) {
 	t.Run("Login_ValidatesUserExists", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
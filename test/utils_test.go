@@ -1,9 +1,20 @@
 package test
 
 import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"github.com/WedhaWS/uasgosmt5/app/model"
 	"github.com/WedhaWS/uasgosmt5/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -258,6 +269,410 @@ func TestPasswordUtils_CheckPasswordHash(t *testing.T) {
 	}
 }
 
+// Test Validator utilities
+func TestValidatorUtils_ValidateStruct(t *testing.T) {
+	type nested struct {
+		Level string `json:"level" validate:"omitempty,oneof=local national"`
+	}
+	type request struct {
+		Email   string   `json:"email" validate:"required,email"`
+		Name    string   `json:"name" validate:"required,min=3,max=10"`
+		Points  int      `json:"points" validate:"gt=0"`
+		Role    string   `json:"role" validate:"omitempty,oneof=admin student"`
+		Details nested   `json:"details"`
+		Ptr     *nested  `json:"ptr,omitempty"`
+		IDs     []string `json:"ids" validate:"omitempty,max=2,dive,uuid"`
+	}
+
+	tests := []struct {
+		name       string
+		input      request
+		wantFields map[string]string // field -> rule
+	}{
+		{
+			name:       "Valid request",
+			input:      request{Email: "john@example.com", Name: "John", Points: 10, Role: "admin"},
+			wantFields: map[string]string{},
+		},
+		{
+			name:  "Missing required fields",
+			input: request{Points: 1},
+			wantFields: map[string]string{
+				"email": "required",
+				"name":  "required",
+			},
+		},
+		{
+			name:  "Invalid email, length and number",
+			input: request{Email: "not-an-email", Name: "Jo", Points: 0},
+			wantFields: map[string]string{
+				"email":  "email",
+				"name":   "min",
+				"points": "gt",
+			},
+		},
+		{
+			name:  "Whitespace only counts as empty",
+			input: request{Email: "john@example.com", Name: "   ", Points: 1},
+			wantFields: map[string]string{
+				"name": "required",
+			},
+		},
+		{
+			name: "Enum and nested struct",
+			input: request{
+				Email: "john@example.com", Name: "John", Points: 1, Role: "superuser",
+				Details: nested{Level: "galaxy"},
+				Ptr:     &nested{Level: "moon"},
+			},
+			wantFields: map[string]string{
+				"role":          "oneof",
+				"details.level": "oneof",
+				"ptr.level":     "oneof",
+			},
+		},
+		{
+			name: "Dive validates every element",
+			input: request{
+				Email: "john@example.com", Name: "John", Points: 1,
+				IDs: []string{"3f1b2c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", "not-a-uuid"},
+			},
+			wantFields: map[string]string{
+				"ids[1]": "uuid",
+			},
+		},
+		{
+			name: "Dive accepts valid elements",
+			input: request{
+				Email: "john@example.com", Name: "John", Points: 1,
+				IDs: []string{"3f1b2c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", "a0b1c2d3-e4f5-4a6b-9c8d-7e6f5a4b3c2d"},
+			},
+			wantFields: map[string]string{},
+		},
+		{
+			name: "Slice rules run before dive",
+			input: request{
+				Email: "john@example.com", Name: "John", Points: 1,
+				IDs: []string{"x", "y", "z"},
+			},
+			wantFields: map[string]string{
+				"ids": "max",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := utils.ValidateStruct(&tt.input)
+
+			got := map[string]string{}
+			for _, e := range errs {
+				got[e.Field] = e.Rule
+				assert.NotEmpty(t, e.Message)
+			}
+			assert.Equal(t, tt.wantFields, got)
+		})
+	}
+}
+
+func TestValidatorUtils_CheckTag(t *testing.T) {
+	t.Run("Unknown rules and bad parameters are rejected", func(t *testing.T) {
+		for _, tag := range []string{"required,ne0", "requird", "min=abc", "max=", "oneof=", "uuid=4", "omitempty,dive,unique"} {
+			assert.Error(t, utils.CheckTag(tag), tag)
+		}
+	})
+
+	t.Run("Unknown rule panics instead of passing silently", func(t *testing.T) {
+		type request struct {
+			Points int `json:"points" validate:"nonzero"`
+		}
+		assert.Panics(t, func() { utils.ValidateStruct(&request{Points: 0}) })
+	})
+
+	t.Run("ne rejects the excluded value", func(t *testing.T) {
+		type request struct {
+			Points int      `json:"points" validate:"ne=0"`
+			Tags   []string `json:"tags" validate:"ne=1"`
+		}
+		errs := utils.ValidateStruct(&request{Points: 0, Tags: []string{"ai"}})
+		require.Len(t, errs, 2)
+		assert.Equal(t, "ne", errs[0].Rule)
+		assert.Equal(t, "points must not be 0", errs[0].Message)
+		assert.Equal(t, "tags", errs[1].Field)
+
+		assert.Empty(t, utils.ValidateStruct(&request{Points: -5}))
+	})
+
+	// Setiap tag `validate` di kode aplikasi (termasuk struct request anonim
+	// di handler) harus lolos CheckTag, agar tidak ada yang panic saat request
+	t.Run("Every validate tag in the tree is well formed", func(t *testing.T) {
+		checked := 0
+		err := filepath.WalkDir("../app", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+				return err
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				field, ok := n.(*ast.Field)
+				if !ok || field.Tag == nil {
+					return true
+				}
+				raw, err := strconv.Unquote(field.Tag.Value)
+				require.NoError(t, err)
+				if tag := reflect.StructTag(raw).Get("validate"); tag != "" && tag != "-" {
+					assert.NoError(t, utils.CheckTag(tag), "%s: %s", path, tag)
+					checked++
+				}
+				return true
+			})
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Greater(t, checked, 50)
+	})
+}
+
+func TestMergePatchUtils_MergePatch(t *testing.T) {
+	t.Run("Nested objects are merged and null removes keys", func(t *testing.T) {
+		target := map[string]interface{}{
+			"title": "Juara 2",
+			"tags":  []interface{}{"a", "b"},
+			"details": map[string]interface{}{
+				"competitionName": "Gemastik",
+				"rank":            float64(2),
+				"customFields": map[string]interface{}{
+					"team":  "Alpha",
+					"coach": "Budi",
+				},
+			},
+		}
+		patch := map[string]interface{}{
+			"title": "Juara 1",
+			"tags":  []interface{}{"c"},
+			"details": map[string]interface{}{
+				"rank": float64(1),
+				"customFields": map[string]interface{}{
+					"coach": nil,
+					"venue": "Surabaya",
+				},
+			},
+		}
+
+		result := utils.MergePatch(target, patch)
+
+		assert.Equal(t, "Juara 1", result["title"])
+		assert.Equal(t, []interface{}{"c"}, result["tags"])
+		details := result["details"].(map[string]interface{})
+		assert.Equal(t, "Gemastik", details["competitionName"])
+		assert.Equal(t, float64(1), details["rank"])
+		custom := details["customFields"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"team": "Alpha", "venue": "Surabaya"}, custom)
+	})
+
+	t.Run("Patch creates missing nested object", func(t *testing.T) {
+		result := utils.MergePatch(nil, map[string]interface{}{
+			"details": map[string]interface{}{"location": "Jakarta"},
+		})
+
+		assert.Equal(t, map[string]interface{}{"location": "Jakarta"}, result["details"])
+	})
+}
+
+func TestJSONDiffUtils_DiffJSON(t *testing.T) {
+	t.Run("Nested fields are compared per path, arrays as a whole", func(t *testing.T) {
+		from := map[string]interface{}{
+			"title":     "Juara 2",
+			"tags":      []interface{}{"a", "b"},
+			"updatedAt": "2024-01-01T00:00:00Z",
+			"details": map[string]interface{}{
+				"competitionName": "Gemastik",
+				"rank":            float64(2),
+				"organizer":       "Kemdikbud",
+			},
+		}
+		to := map[string]interface{}{
+			"title":     "Juara 1",
+			"tags":      []interface{}{"a", "b", "c"},
+			"updatedAt": "2024-02-01T00:00:00Z",
+			"details": map[string]interface{}{
+				"competitionName": "Gemastik",
+				"rank":            float64(1),
+				"location":        "Surabaya",
+			},
+		}
+
+		changes := utils.DiffJSON(from, to, "updatedAt")
+
+		assert.Equal(t, []model.ContentChange{
+			{Path: "details.location", Op: "added", To: "Surabaya"},
+			{Path: "details.organizer", Op: "removed", From: "Kemdikbud"},
+			{Path: "details.rank", Op: "changed", From: float64(2), To: float64(1)},
+			{Path: "tags", Op: "changed", From: []interface{}{"a", "b"}, To: []interface{}{"a", "b", "c"}},
+			{Path: "title", Op: "changed", From: "Juara 2", To: "Juara 1"},
+		}, changes)
+	})
+
+	t.Run("Null and empty values are treated as missing", func(t *testing.T) {
+		changes := utils.DiffJSON(
+			map[string]interface{}{"tags": nil, "details": map[string]interface{}{}},
+			map[string]interface{}{"tags": []interface{}{}},
+		)
+
+		assert.Empty(t, changes)
+		assert.NotNil(t, changes)
+	})
+}
+
+func TestSchemaUtils_ValidateSchema(t *testing.T) {
+	var schema model.DetailSchema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["competitionName", "competitionLevel", "period"],
+		"properties": {
+			"competitionName":  {"type": "string", "maxLength": 20},
+			"competitionLevel": {"type": "string", "enum": ["local", "national"]},
+			"rank":             {"type": "integer", "minimum": 1},
+			"authors":          {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+			"period": {
+				"type": "object",
+				"required": ["start", "end"],
+				"properties": {
+					"start": {"type": "string", "format": "date-time", "x-notFuture": true},
+					"end":   {"type": "string", "format": "date-time", "x-after": "start"}
+				}
+			},
+			"customFields": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"teamSize": {"type": "integer"}}
+			}
+		}
+	}`), &schema))
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"competitionName":  "Gemastik",
+			"competitionLevel": "national",
+			"rank":             float64(1),
+			"period": map[string]interface{}{
+				"start": "2024-01-10T00:00:00Z",
+				"end":   "2024-01-12T00:00:00Z",
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(doc map[string]interface{})
+		wantFields map[string]string // field -> rule
+	}{
+		{
+			name:       "Valid details",
+			modify:     func(doc map[string]interface{}) {},
+			wantFields: map[string]string{},
+		},
+		{
+			name: "Missing required fields",
+			modify: func(doc map[string]interface{}) {
+				delete(doc, "competitionLevel")
+				doc["competitionName"] = "  "
+				doc["period"] = map[string]interface{}{"start": "2024-01-10T00:00:00Z"}
+			},
+			wantFields: map[string]string{
+				"details.competitionName":  "required",
+				"details.competitionLevel": "required",
+				"details.period.end":       "required",
+			},
+		},
+		{
+			name: "Enum, type and range",
+			modify: func(doc map[string]interface{}) {
+				doc["competitionLevel"] = "galaxy"
+				doc["rank"] = float64(1.5)
+				doc["authors"] = []interface{}{"Budi", ""}
+			},
+			wantFields: map[string]string{
+				"details.competitionLevel": "oneof",
+				"details.rank":             "type",
+				"details.authors[1]":       "min",
+			},
+		},
+		{
+			name: "Date rules",
+			modify: func(doc map[string]interface{}) {
+				doc["period"] = map[string]interface{}{
+					"start": time.Now().AddDate(1, 0, 0).Format(time.RFC3339),
+					"end":   "not-a-date",
+				}
+			},
+			wantFields: map[string]string{
+				"details.period.start": "notFuture",
+				"details.period.end":   "format",
+			},
+		},
+		{
+			name: "End before start and unknown custom field",
+			modify: func(doc map[string]interface{}) {
+				doc["period"] = map[string]interface{}{
+					"start": "2024-01-10T00:00:00Z",
+					"end":   "2024-01-09T00:00:00Z",
+				}
+				doc["customFields"] = map[string]interface{}{"teamSize": float64(3), "coach": "Budi"}
+			},
+			wantFields: map[string]string{
+				"details.period.end":         "after",
+				"details.customFields.coach": "unknown",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := valid()
+			tt.modify(doc)
+
+			errs := utils.ValidateSchema(&schema, doc, "details")
+
+			got := map[string]string{}
+			for _, e := range errs {
+				got[e.Field] = e.Rule
+				assert.NotEmpty(t, e.Message)
+			}
+			assert.Equal(t, tt.wantFields, got)
+		})
+	}
+}
+
+func TestSchemaUtils_CheckSchema(t *testing.T) {
+	var schema model.DetailSchema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["issuedBy", "missing"],
+		"properties": {
+			"issuedBy":   {"type": "text"},
+			"validUntil": {"type": "string", "format": "timestamp", "x-after": "eventDate"},
+			"authors":    {"type": "array"}
+		}
+	}`), &schema))
+
+	errs := utils.CheckSchema(&schema, "schema")
+
+	got := map[string]string{}
+	for _, e := range errs {
+		got[e.Field] = e.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"schema.required":                      "exists",
+		"schema.properties.issuedBy.type":      "oneof",
+		"schema.properties.validUntil.format":  "oneof",
+		"schema.properties.validUntil.x-after": "exists",
+		"schema.properties.authors.items":      "required",
+	}, got)
+}
+
 // Benchmark tests
 func BenchmarkHashPassword(b *testing.B) {
 	password := "benchmarkpassword123"
@@ -280,3 +695,32 @@ func BenchmarkCheckPasswordHash(b *testing.B) {
 		utils.CheckPasswordHash(password, hash)
 	}
 }
+
+func TestPeriodicUtils_RunPeriodic(t *testing.T) {
+	t.Run("Runs immediately, then every interval until ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		runs := 0
+		done := make(chan struct{})
+
+		// Execute
+		go func() {
+			utils.RunPeriodic(ctx, time.Millisecond, func(ctx context.Context) {
+				runs++
+				if runs == 3 {
+					cancel()
+				}
+			})
+			close(done)
+		}()
+
+		// Assertions
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("RunPeriodic did not stop after ctx was cancelled")
+		}
+		assert.Equal(t, 3, runs)
+	})
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+// DiffJSON membandingkan dua object JSON (hasil json.Unmarshal ke map) dan
+// mengembalikan perbedaannya, urut berdasarkan path. Object bersarang
+// dibandingkan per field (path "details.rank"); array dan nilai lain
+// dibandingkan utuh. Key di ignore (level teratas) dilewati.
+func DiffJSON(from, to map[string]interface{}, ignore ...string) []model.ContentChange {
+	skip := make(map[string]bool, len(ignore))
+	for _, key := range ignore {
+		skip[key] = true
+	}
+
+	changes := []model.ContentChange{}
+	diffObject("", from, to, skip, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffObject(prefix string, from, to map[string]interface{}, skip map[string]bool, changes *[]model.ContentChange) {
+	keys := make(map[string]bool, len(from)+len(to))
+	for key := range from {
+		keys[key] = true
+	}
+	for key := range to {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		if prefix == "" && skip[key] {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		oldValue, inFrom := from[key]
+		newValue, inTo := to[key]
+		// null, array/object kosong, dan field yang tidak ada dianggap sama (omitempty)
+		inFrom = inFrom && !isEmptyJSON(oldValue)
+		inTo = inTo && !isEmptyJSON(newValue)
+
+		switch {
+		case !inFrom && !inTo:
+		case !inFrom:
+			*changes = append(*changes, model.ContentChange{Path: path, Op: "added", To: newValue})
+		case !inTo:
+			*changes = append(*changes, model.ContentChange{Path: path, Op: "removed", From: oldValue})
+		default:
+			oldObj, oldIsObj := oldValue.(map[string]interface{})
+			newObj, newIsObj := newValue.(map[string]interface{})
+			if oldIsObj && newIsObj {
+				diffObject(path, oldObj, newObj, skip, changes)
+				continue
+			}
+			if !reflect.DeepEqual(oldValue, newValue) {
+				*changes = append(*changes, model.ContentChange{Path: path, Op: "changed", From: oldValue, To: newValue})
+			}
+		}
+	}
+}
+
+func isEmptyJSON(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
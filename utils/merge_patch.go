@@ -0,0 +1,23 @@
+package utils
+
+// MergePatch menerapkan JSON Merge Patch (RFC 7396) dari patch ke target.
+// Object bersarang digabung secara rekursif, nilai null menghapus key,
+// dan nilai lain (termasuk array) menggantikan nilai lama.
+func MergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if patchObj, ok := value.(map[string]interface{}); ok {
+			targetObj, _ := target[key].(map[string]interface{})
+			target[key] = MergePatch(targetObj, patchObj)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
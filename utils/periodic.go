@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodic menjalankan fn sekali di awal lalu setiap interval sampai ctx
+// selesai. Dipakai job latar belakang (SLA, purge, sertifikat, idempotency).
+func RunPeriodic(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+var schemaTypes = map[string]bool{"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true}
+var schemaFormats = map[string]bool{"date": true, "date-time": true, "email": true, "uri": true}
+
+// ValidateSchema memeriksa value hasil json.Unmarshal (map/slice/string/float64/bool)
+// terhadap schema. Nama field di error diberi prefix name, misal "details.rank"
+// atau "details.authors[0]". Seperti ValidateStruct, satu field cukup satu error.
+func ValidateSchema(schema *model.DetailSchema, value interface{}, name string) []model.FieldError {
+	return validateNode(schema, value, name, time.Now())
+}
+
+func validateNode(s *model.DetailSchema, v interface{}, name string, now time.Time) []model.FieldError {
+	if s == nil || v == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return typeError(name, "an object")
+		}
+		return validateObject(s, obj, name, now)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return typeError(name, "an array")
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return []model.FieldError{{Field: name, Rule: "min", Message: fmt.Sprintf("%s must be at least %d items", name, *s.MinItems)}}
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			return []model.FieldError{{Field: name, Rule: "max", Message: fmt.Sprintf("%s must be at most %d items", name, *s.MaxItems)}}
+		}
+		var errs []model.FieldError
+		for i, item := range items {
+			errs = append(errs, validateNode(s.Items, item, fmt.Sprintf("%s[%d]", name, i), now)...)
+		}
+		return errs
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return typeError(name, "a string")
+		}
+		if msg := checkString(s, str, name, now); msg != nil {
+			return msg
+		}
+	case "number", "integer":
+		num, ok := v.(float64)
+		if !ok || (s.Type == "integer" && num != math.Trunc(num)) {
+			return typeError(name, "a "+s.Type)
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			return []model.FieldError{{Field: name, Rule: "gte", Message: fmt.Sprintf("%s must be at least %v", name, *s.Minimum)}}
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			return []model.FieldError{{Field: name, Rule: "lte", Message: fmt.Sprintf("%s must be at most %v", name, *s.Maximum)}}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return typeError(name, "a boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		options := make([]string, len(s.Enum))
+		for i, o := range s.Enum {
+			options[i] = fmt.Sprint(o)
+			if options[i] == fmt.Sprint(v) {
+				return nil
+			}
+		}
+		return []model.FieldError{{Field: name, Rule: "oneof", Message: fmt.Sprintf("%s must be one of [%s]", name, strings.Join(options, ", "))}}
+	}
+	return nil
+}
+
+func validateObject(s *model.DetailSchema, obj map[string]interface{}, name string, now time.Time) []model.FieldError {
+	var errs []model.FieldError
+
+	for _, key := range s.Required {
+		if isBlankValue(obj[key]) {
+			field := childName(name, key)
+			errs = append(errs, model.FieldError{Field: field, Rule: "required", Message: field + " is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := childName(name, key)
+		prop, known := s.Properties[key]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, model.FieldError{Field: field, Rule: "unknown", Message: field + " is not a recognized field"})
+			}
+			continue
+		}
+		fieldErrs := validateNode(prop, obj[key], field, now)
+		errs = append(errs, fieldErrs...)
+
+		// x-after hanya dicek jika kedua tanggal valid
+		if len(fieldErrs) == 0 && prop.After != "" {
+			end, okEnd := parseSchemaTime(obj[key])
+			start, okStart := parseSchemaTime(obj[prop.After])
+			if okEnd && okStart && !end.After(start) {
+				errs = append(errs, model.FieldError{Field: field, Rule: "after", Message: fmt.Sprintf("%s must be after %s", field, childName(name, prop.After))})
+			}
+		}
+	}
+	return errs
+}
+
+func checkString(s *model.DetailSchema, str, name string, now time.Time) []model.FieldError {
+	length := len([]rune(str))
+	if s.MinLength != nil && length < *s.MinLength {
+		return []model.FieldError{{Field: name, Rule: "min", Message: fmt.Sprintf("%s must be at least %d characters", name, *s.MinLength)}}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return []model.FieldError{{Field: name, Rule: "max", Message: fmt.Sprintf("%s must be at most %d characters", name, *s.MaxLength)}}
+	}
+
+	switch s.Format {
+	case "date", "date-time":
+		t, ok := parseSchemaTime(str)
+		if !ok || (s.Format == "date" && len(str) != len("2006-01-02")) {
+			return []model.FieldError{{Field: name, Rule: "format", Message: fmt.Sprintf("%s must be a valid %s", name, s.Format)}}
+		}
+		if s.NotFuture && t.After(now) {
+			return []model.FieldError{{Field: name, Rule: "notFuture", Message: name + " cannot be in the future"}}
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+			return []model.FieldError{{Field: name, Rule: "format", Message: name + " must be a valid email address"}}
+		}
+	case "uri":
+		if u, err := url.ParseRequestURI(str); err != nil || u.Scheme == "" {
+			return []model.FieldError{{Field: name, Rule: "format", Message: name + " must be a valid URI"}}
+		}
+	}
+	return nil
+}
+
+// CheckSchema memeriksa definisi schema yang dikirim Admin: type & format dikenal,
+// required dan x-after merujuk ke property yang ada.
+func CheckSchema(s *model.DetailSchema, name string) []model.FieldError {
+	if s == nil {
+		return nil
+	}
+
+	var errs []model.FieldError
+	if !schemaTypes[s.Type] {
+		errs = append(errs, model.FieldError{Field: name + ".type", Rule: "oneof", Message: name + ".type must be one of [array, boolean, integer, number, object, string]"})
+	}
+	if s.Format != "" && !schemaFormats[s.Format] {
+		errs = append(errs, model.FieldError{Field: name + ".format", Rule: "oneof", Message: name + ".format must be one of [date, date-time, email, uri]"})
+	}
+	if s.Type == "array" && s.Items == nil {
+		errs = append(errs, model.FieldError{Field: name + ".items", Rule: "required", Message: name + ".items is required for arrays"})
+	}
+
+	for _, key := range s.Required {
+		if _, ok := s.Properties[key]; !ok {
+			errs = append(errs, model.FieldError{Field: name + ".required", Rule: "exists", Message: fmt.Sprintf("required field '%s' is not defined in properties", key)})
+		}
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		prop := s.Properties[key]
+		field := name + ".properties." + key
+		if prop == nil {
+			errs = append(errs, model.FieldError{Field: field, Rule: "required", Message: field + " must be a schema object"})
+			continue
+		}
+		if prop.After != "" {
+			if _, ok := s.Properties[prop.After]; !ok {
+				errs = append(errs, model.FieldError{Field: field + ".x-after", Rule: "exists", Message: fmt.Sprintf("x-after refers to unknown field '%s'", prop.After)})
+			}
+		}
+		errs = append(errs, CheckSchema(prop, field)...)
+	}
+	errs = append(errs, CheckSchema(s.Items, name+".items")...)
+	return errs
+}
+
+func parseSchemaTime(v interface{}) (time.Time, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", str); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func isBlankValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(val) == ""
+	case []interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+func typeError(name, kind string) []model.FieldError {
+	return []model.FieldError{{Field: name, Rule: "type", Message: fmt.Sprintf("%s must be %s", name, kind)}}
+}
+
+func childName(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
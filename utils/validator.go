@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/WedhaWS/uasgosmt5/app/model"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ruleParams mendaftar rule yang dikenal beserta jenis parameternya:
+// "" = tanpa parameter, "number" = angka, "list" = daftar dipisah spasi
+var ruleParams = map[string]string{
+	"required":  "",
+	"omitempty": "",
+	"dive":      "",
+	"email":     "",
+	"uuid":      "",
+	"min":       "number",
+	"max":       "number",
+	"gt":        "number",
+	"gte":       "number",
+	"lte":       "number",
+	"ne":        "number",
+	"oneof":     "list",
+}
+
+// checkedTags menyimpan tag yang sudah lolos CheckTag agar tidak diurai ulang
+var checkedTags sync.Map
+
+// CheckTag memeriksa bahwa tag `validate` hanya memakai rule yang dikenal
+// dengan parameter yang benar. Rule yang salah ketik tidak boleh lolos diam-diam.
+func CheckTag(tag string) error {
+	for _, r := range strings.Split(tag, ",") {
+		rule, param, hasParam := strings.Cut(strings.TrimSpace(r), "=")
+		kind, ok := ruleParams[rule]
+		if !ok {
+			return fmt.Errorf("unknown rule %q", rule)
+		}
+		switch kind {
+		case "":
+			if hasParam {
+				return fmt.Errorf("rule %q takes no parameter", rule)
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				return fmt.Errorf("rule %q needs a numeric parameter, got %q", rule, param)
+			}
+		case "list":
+			if len(strings.Fields(param)) == 0 {
+				return fmt.Errorf("rule %q needs at least one option", rule)
+			}
+		}
+	}
+	return nil
+}
+
+// mustCheckTag menjalankan CheckTag sekali per tag dan panic jika tag salah,
+// karena itu kesalahan program (bukan input client)
+func mustCheckTag(name, tag string) {
+	if _, ok := checkedTags.Load(tag); ok {
+		return
+	}
+	if err := CheckTag(tag); err != nil {
+		panic(fmt.Sprintf("utils: invalid validate tag %q on %s: %v", tag, name, err))
+	}
+	checkedTags.Store(tag, true)
+}
+
+// ValidateStruct memeriksa field berdasarkan tag `validate` dan mengembalikan
+// daftar error per field (kosong jika valid). Nama field mengikuti tag `json`
+// agar sesuai dengan body request yang dikirim client.
+//
+// Rule yang didukung (dipisah koma):
+//
+//	required, omitempty, email, uuid, min=N, max=N, gt=N, gte=N, lte=N, ne=N, oneof=a b c, dive
+//
+// Rule lain atau parameter yang salah membuat ValidateStruct panic (lihat CheckTag).
+// Rule sebelum 'dive' berlaku untuk slice-nya, rule sesudahnya untuk setiap
+// elemen dengan nama "field[i]" (misal: "ids[2] must be a valid UUID").
+// Seperti validator pada umumnya, rule tetap dijalankan untuk zero value kecuali
+// field diberi 'omitempty'.
+// Untuk string, min/max dihitung dari panjang karakter; untuk angka dari nilainya;
+// untuk slice/map dari jumlah elemen. Struct bersarang divalidasi rekursif
+// dengan prefix nama field induk (misal: "details.competitionLevel"), begitu
+// juga elemen slice of struct (misal: "stages[0].key").
+func ValidateStruct(s interface{}) []model.FieldError {
+	v := reflect.ValueOf(s)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateValue(v, "")
+}
+
+func validateValue(v reflect.Value, prefix string) []model.FieldError {
+	var errs []model.FieldError
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		name := prefix + jsonName(sf)
+
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			mustCheckTag(t.String()+"."+sf.Name, tag)
+			errs = append(errs, checkRules(fv, name, tag)...)
+		}
+
+		// Rekursif ke struct bersarang (selain time.Time dan sejenisnya yang tanpa tag)
+		nested := fv
+		if nested.Kind() == reflect.Ptr {
+			if nested.IsNil() {
+				continue
+			}
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && hasValidateTags(nested.Type()) {
+			errs = append(errs, validateValue(nested, name+".")...)
+		}
+
+		// Slice of struct: setiap elemen divalidasi dengan prefix "field[i]."
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct && hasValidateTags(fv.Type().Elem()) {
+			for j := 0; j < fv.Len(); j++ {
+				errs = append(errs, validateValue(fv.Index(j), fmt.Sprintf("%s[%d].", name, j))...)
+			}
+		}
+	}
+	return errs
+}
+
+func checkRules(fv reflect.Value, name string, tag string) []model.FieldError {
+	// Rule setelah 'dive' berlaku untuk setiap elemen slice, bukan slice-nya
+	rules := strings.Split(tag, ",")
+	dive := len(rules)
+	for i, r := range rules {
+		if strings.TrimSpace(r) == "dive" {
+			dive = i
+			break
+		}
+	}
+	if errs := checkFieldRules(fv, name, rules[:dive]); len(errs) > 0 || dive >= len(rules)-1 {
+		return errs
+	}
+
+	if fv.Kind() != reflect.Slice && fv.Kind() != reflect.Array {
+		return nil
+	}
+	elemTag := strings.Join(rules[dive+1:], ",")
+	var errs []model.FieldError
+	for j := 0; j < fv.Len(); j++ {
+		errs = append(errs, checkRules(fv.Index(j), fmt.Sprintf("%s[%d]", name, j), elemTag)...)
+	}
+	return errs
+}
+
+func checkFieldRules(fv reflect.Value, name string, rules []string) []model.FieldError {
+
+	empty := isEmpty(fv)
+	for _, r := range rules {
+		if r == "omitempty" && empty {
+			return nil
+		}
+	}
+
+	for _, r := range rules {
+		rule, param, _ := strings.Cut(strings.TrimSpace(r), "=")
+		if rule == "" || rule == "omitempty" {
+			continue
+		}
+
+		if rule == "required" {
+			if empty {
+				return []model.FieldError{{Field: name, Rule: "required", Message: name + " is required"}}
+			}
+			continue
+		}
+
+		if msg := applyRule(fv, rule, param, name); msg != "" {
+			// Satu error per field sudah cukup untuk client
+			return []model.FieldError{{Field: name, Rule: rule, Message: msg}}
+		}
+	}
+	return nil
+}
+
+func applyRule(fv reflect.Value, rule, param, name string) string {
+	if fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+
+	switch rule {
+	case "email":
+		addr, err := mail.ParseAddress(fv.String())
+		if err != nil || addr.Address != fv.String() {
+			return name + " must be a valid email address"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(fv.String()) {
+			return name + " must be a valid UUID"
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		val := fmt.Sprint(fv.Interface())
+		for _, o := range options {
+			if o == val {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s must be one of [%s]", name, strings.Join(options, ", "))
+	case "ne":
+		limit, _ := strconv.ParseFloat(param, 64)
+		if size, _ := measure(fv); size == limit {
+			return fmt.Sprintf("%s must not be %s", name, param)
+		}
+	case "min", "max", "gt", "gte", "lte":
+		limit, _ := strconv.ParseFloat(param, 64)
+		size, unit := measure(fv)
+		ok := true
+		switch rule {
+		case "min", "gte":
+			ok = size >= limit
+		case "max", "lte":
+			ok = size <= limit
+		case "gt":
+			ok = size > limit
+		}
+		if !ok {
+			return describeLimit(name, rule, param, unit)
+		}
+	}
+	return ""
+}
+
+// measure mengembalikan "ukuran" value: panjang string/slice atau nilai angka
+func measure(fv reflect.Value) (float64, string) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(len([]rune(fv.String()))), "characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), ""
+	}
+	return 0, ""
+}
+
+func describeLimit(name, rule, param, unit string) string {
+	words := map[string]string{
+		"min": "at least", "gte": "at least",
+		"max": "at most", "lte": "at most",
+		"gt": "greater than",
+	}
+	if unit == "" {
+		return fmt.Sprintf("%s must be %s %s", name, words[rule], param)
+	}
+	return fmt.Sprintf("%s must be %s %s %s", name, words[rule], param, unit)
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(fv.String()) == ""
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return fv.Len() == 0
+	}
+	return fv.IsZero()
+}
+
+func jsonName(sf reflect.StructField) string {
+	tag := sf.Tag.Get("json")
+	if tag == "" || tag == "-" {
+		return sf.Name
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+func hasValidateTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("validate") != "" {
+			return true
+		}
+	}
+	return false
+}